@@ -25,22 +25,38 @@ func getLabel(action string, label string) string {
 }
 
 // ReportSuccess is invoked when a simulated user action is successfully completed.
-// This then updates Prometheus metrics correlating to this (ReponseTimes | Latency | success counter for an action)
-func ReportSuccess(action string, label string, time float64) {
+// This then updates Prometheus metrics correlating to this (ReponseTimes | Latency | success counter for an action).
+// Persona and node metrics are updated when persona and node are not empty.
+func ReportSuccess(action string, label string, persona string, node string, time float64) {
 	actionlabel := getLabel(action, label)
 	if metricEnabled() {
 		metrics.GopherResponseTimes.WithLabelValues(actionlabel).Observe(time)
 		metrics.GopherActionLatencyHist.WithLabelValues(actionlabel).Observe(time)
 		metrics.GopherActions.WithLabelValues("success", actionlabel).Inc()
+		if persona != "" {
+			metrics.GopherPersonaLatencyHist.WithLabelValues(persona).Observe(time)
+			metrics.GopherPersonaActions.WithLabelValues("success", persona).Inc()
+		}
+		if node != "" {
+			metrics.GopherNodeLatencyHist.WithLabelValues(node).Observe(time)
+			metrics.GopherNodeActions.WithLabelValues("success", node).Inc()
+		}
 	}
 }
 
 // ReportFailure is invoked when a simulated user action fails.
-// This then updates Prometheus metrics correlating to this (Failure counter for an action)
-func ReportFailure(action string, label string) {
+// This then updates Prometheus metrics correlating to this (Failure counter for an action).
+// Persona and node metrics are updated when persona and node are not empty.
+func ReportFailure(action string, label string, persona string, node string) {
 	actionlabel := getLabel(action, label)
 	if metricEnabled() {
 		metrics.GopherActions.WithLabelValues("failure", actionlabel).Inc()
+		if persona != "" {
+			metrics.GopherPersonaActions.WithLabelValues("failure", persona).Inc()
+		}
+		if node != "" {
+			metrics.GopherNodeActions.WithLabelValues("failure", node).Inc()
+		}
 	}
 }
 
@@ -62,6 +78,24 @@ func ReportWarning(action string, label string) {
 	}
 }
 
+// ReportReconnect is invoked when a websocket has been reconnected or failed to reconnect.
+// This then updates Prometheus metrics correlating to this (Reconnect counter per result | Reconnect times)
+func ReportReconnect(result string, time float64) {
+	if metricEnabled() {
+		metrics.GopherReconnects.WithLabelValues(result).Inc()
+		metrics.GopherReconnectLatencyHist.Observe(time)
+	}
+}
+
+// ReportExport is invoked when object data has been exported.
+// This then updates Prometheus metrics correlating to this (Export times | Export file sizes)
+func ReportExport(fileType string, time float64, size int) {
+	if metricEnabled() {
+		metrics.GopherExportLatencyHist.WithLabelValues(fileType).Observe(time)
+		metrics.GopherExportSizeHist.WithLabelValues(fileType).Observe(float64(size))
+	}
+}
+
 // AddUser is invoked when a new simulated user is added.
 // This then updates Prometheus metrics correlating to this (Users total | Active users)
 func AddUser() {
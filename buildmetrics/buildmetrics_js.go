@@ -24,13 +24,13 @@ func getLabel(action string, label string) string {
 
 // ReportSuccess shall never report Prometheus metrics for WASM/JS builds as it is not supported nor wanted, hence "return"
 // Implemented as a way to dynamically import prometheus
-func ReportSuccess(action string, label string, time float64) {
+func ReportSuccess(action string, label string, persona string, node string, time float64) {
 	return
 }
 
 // ReportFailure shall never report Prometheus metrics for WASM/JS builds as it is not supported nor wanted, hence "return"
 // Implemented as a way to dynamically import prometheus
-func ReportFailure(action string, label string) {
+func ReportFailure(action string, label string, persona string, node string) {
 	return
 }
 
@@ -46,6 +46,18 @@ func ReportWarning(action string, label string) {
 	return
 }
 
+// ReportReconnect shall never report Prometheus metrics for WASM/JS builds as it is not supported nor wanted, hence "return"
+// Implemented as a way to dynamically import prometheus
+func ReportReconnect(result string, time float64) {
+	return
+}
+
+// ReportExport shall never report Prometheus metrics for WASM/JS builds as it is not supported nor wanted, hence "return"
+// Implemented as a way to dynamically import prometheus
+func ReportExport(fileType string, time float64, size int) {
+	return
+}
+
 // AddUser shall never report Prometheus metrics for WASM/JS builds as it is not supported nor wanted, hence "return"
 // Implemented as a way to dynamically import prometheus
 func AddUser() {
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/distributed"
+	"github.com/spf13/cobra"
+)
+
+var (
+	workers       int
+	listenAddress string
+)
+
+// controllerCmd represents the controller command
+var controllerCmd = &cobra.Command{
+	Use:   "controller",
+	Short: "distribute execution of scenario over multiple workers",
+	Long: `distribute execution of scenario over multiple workers. The controller waits for all workers to register,
+splits the load of the scheduler between the workers and starts them at the same time. Statistics reported by the
+workers are merged into one summary.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfgFile == "" {
+			_, _ = os.Stderr.WriteString("Error: No config provided\n")
+			if err := cmd.Help(); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Error: %+v\n", err)
+			}
+			return
+		}
+
+		exitOnError(executeController())
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(controllerCmd)
+	AddAllSharedParameters(controllerCmd)
+
+	controllerCmd.Flags().IntVarP(&workers, "workers", "w", 1, "Number of workers to distribute the execution over.")
+	controllerCmd.Flags().StringVar(&listenAddress, "listen", ":9090", "Address to listen on for worker connections.")
+
+	// Logging
+	controllerCmd.Flags().StringVar(&logFormat, "logformat", "", getLogFormatHelpString())
+	controllerCmd.Flags().StringVar(&summaryType, "summary", "", getSummaryTypeHelpString())
+}
+
+func executeController() error {
+	cfg, rawConfig, errUnmarshal := readConfigFile()
+	if errUnmarshal != nil {
+		return JSONParseError(errUnmarshal.Error())
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return JSONValidateError(err.Error())
+	}
+
+	if err := overrideLogSettings(cfg); err != nil {
+		return err
+	}
+
+	// Data for variable templates
+	configName := strings.Split(filepath.Base(cfgFile), ".")[0]
+	templateData := distributed.WorkerTemplateData{ConfigFile: configName}
+
+	controller, err := distributed.NewController(cfg, rawConfig, workers, configName, templateData)
+	if err != nil {
+		return JSONValidateError(err.Error())
+	}
+
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		return OsError(fmt.Sprintf("failed to listen on address<%s>: %v", listenAddress, err))
+	}
+	srv := &http.Server{Handler: controller.Handler()}
+	go func() {
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			_, _ = fmt.Fprintf(os.Stderr, "controller server error: %v\n", err)
+		}
+	}()
+	defer func() {
+		if err := srv.Shutdown(context.Background()); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "error shutting down controller server: %v\n", err)
+		}
+	}()
+
+	_, _ = fmt.Fprintf(os.Stderr, "Waiting for %d workers to connect to %s\n", workers, listener.Addr())
+
+	// === Handle SIGINT ===
+	ctx, cancel := context.WithCancel(context.Background())
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	go func() {
+		<-c
+		cancel()
+	}()
+
+	return errors.WithStack(cfg.ExecuteWith(ctx, templateData, controller.Run))
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -13,6 +14,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/qlik-oss/gopherciser/buildmetrics"
 	"github.com/qlik-oss/gopherciser/config"
+	"github.com/qlik-oss/gopherciser/control"
 	"github.com/qlik-oss/gopherciser/helpers"
 	"github.com/qlik-oss/gopherciser/profile"
 	"github.com/qlik-oss/gopherciser/scenario"
@@ -47,6 +49,7 @@ var (
 	metricsAddress   string
 	metricsLabel     string
 	metricsGroupings []string
+	controlPort      int
 	logFormat        string
 	profTyp          string
 	objDefFile       string
@@ -78,6 +81,8 @@ const (
 	ExitCodeOsError
 	// ExitCodeSummaryTypeError incorrect summary type
 	ExitCodeSummaryTypeError
+	// ExitCodeStopConditionError execution stopped by a stop condition
+	ExitCodeStopConditionError
 )
 
 // *** Custom errors ***
@@ -139,55 +144,7 @@ var executeCmd = &cobra.Command{
 			return
 		}
 
-		if execErr := execute(); execErr != nil {
-			errMsg := "Unknown error"
-			var exitCode int
-
-			cause := errors.Cause(execErr)
-			switch cause.(type) {
-			case JSONParseError:
-				errMsg = fmt.Sprint("JSONParseError: ", execErr)
-				exitCode = ExitCodeJSONParseError
-			case JSONValidateError:
-				errMsg = fmt.Sprint("JSONValidateError: ", execErr)
-				exitCode = ExitCodeJSONValidateError
-			case LogFormatError:
-				errMsg = fmt.Sprint("LogFormatError: ", execErr)
-				exitCode = ExitCodeLogFormatError
-			case ObjectDefError:
-				errMsg = fmt.Sprint("ObjectDefError: ", execErr)
-				exitCode = ExitCodeObjectDefError
-			case ProfilingError:
-				errMsg = fmt.Sprint("ProfilingError: ", execErr)
-				exitCode = ExitCodeProfilingError
-			case MetricError:
-				errMsg = fmt.Sprint("MetricError: ", execErr)
-				exitCode = ExitCodeMetricError
-			case OsError:
-				errMsg = fmt.Sprint("OsError: ", execErr)
-				exitCode = ExitCodeOsError
-			case SummaryTypeError:
-				errMsg = fmt.Sprint("SummaryError: ", execErr)
-				exitCode = ExitCodeSummaryTypeError
-			case *multierror.Error:
-				mErr := cause.(*multierror.Error)
-				errCount := len(mErr.Errors)
-				if mErr != nil && errCount > 0 {
-					errMsg = fmt.Sprintf("%d errors occurred:\nFirst error: %s", errCount, mErr.Errors[0].Error())
-				}
-				if errCount > 0x7F {
-					errCount = 0x7F
-				}
-				exitCode = errCount
-			default:
-				// only one error
-				errMsg = fmt.Sprint("1 error occurred:\n", execErr)
-				exitCode = 1
-			}
-
-			_, _ = fmt.Fprintf(os.Stderr, "%s\n", errMsg)
-			os.Exit(exitCode)
-		}
+		exitOnError(execute())
 	},
 }
 
@@ -211,6 +168,9 @@ func init() {
 	executeCmd.Flags().StringVar(&metricsLabel, "metricslabel", "gopherciser", "The job label to use for push metrics")
 	executeCmd.Flags().StringSliceVarP(&metricsGroupings, "metricsgroupingkey", "g", nil, "The grouping keys (in key=value form) to use for push metrics. Specify multiple times for more grouping keys.")
 
+	// Control API
+	executeCmd.Flags().IntVar(&controlPort, "controlport", 0, "Serve control API, for pausing, resuming, stopping and changing concurrent users of execution, on port. Use same port as --metrics to serve it next to pulled prometheus metrics.")
+
 	// profiling
 	executeCmd.Flags().StringVar(&profTyp, "profile", "", profile.Help())
 }
@@ -241,20 +201,8 @@ func execute() error {
 		cfg.SetDebugLogging()
 	}
 
-	if logFormat != "" {
-		var errLogformat error
-		cfg.Settings.LogSettings.Format, errLogformat = resolveLogFormat(logFormat)
-		if errLogformat != nil {
-			return LogFormatError(fmt.Sprintf("error resolving log format<%s>: %v", logFormat, errLogformat))
-		}
-	}
-
-	if summaryType != "" {
-		if summary, errSummaryType := resolveSummaryType(); errSummaryType != nil {
-			return SummaryTypeError(fmt.Sprintf("error resolving summary type<%s>: %v", summaryType, errSummaryType))
-		} else {
-			cfg.Settings.LogSettings.Summary = summary
-		}
+	if err := overrideLogSettings(cfg); err != nil {
+		return err
 	}
 
 	// === object definition section ===
@@ -307,6 +255,16 @@ func execute() error {
 		}
 	}
 
+	// === Control API section ===
+	if controlPort > 0 {
+		if controlPort == metricsPort && metricsAddress == "" {
+			// served by prometheus pull metrics server
+			control.Register(http.DefaultServeMux)
+		} else {
+			control.Serve(ctx, controlPort)
+		}
+	}
+
 	// Data for variable templates
 	templateData := struct {
 		ConfigFile string
@@ -316,6 +274,85 @@ func execute() error {
 	return cfg.Execute(ctx, templateData)
 }
 
+// exitOnError exit with exit code corresponding to error, does nothing if execErr is nil
+func exitOnError(execErr error) {
+	if execErr == nil {
+		return
+	}
+
+	errMsg := "Unknown error"
+	var exitCode int
+
+	cause := errors.Cause(execErr)
+	switch cause.(type) {
+	case JSONParseError:
+		errMsg = fmt.Sprint("JSONParseError: ", execErr)
+		exitCode = ExitCodeJSONParseError
+	case JSONValidateError:
+		errMsg = fmt.Sprint("JSONValidateError: ", execErr)
+		exitCode = ExitCodeJSONValidateError
+	case LogFormatError:
+		errMsg = fmt.Sprint("LogFormatError: ", execErr)
+		exitCode = ExitCodeLogFormatError
+	case ObjectDefError:
+		errMsg = fmt.Sprint("ObjectDefError: ", execErr)
+		exitCode = ExitCodeObjectDefError
+	case ProfilingError:
+		errMsg = fmt.Sprint("ProfilingError: ", execErr)
+		exitCode = ExitCodeProfilingError
+	case MetricError:
+		errMsg = fmt.Sprint("MetricError: ", execErr)
+		exitCode = ExitCodeMetricError
+	case OsError:
+		errMsg = fmt.Sprint("OsError: ", execErr)
+		exitCode = ExitCodeOsError
+	case SummaryTypeError:
+		errMsg = fmt.Sprint("SummaryError: ", execErr)
+		exitCode = ExitCodeSummaryTypeError
+	case config.StopConditionError:
+		errMsg = fmt.Sprint("StopConditionError: ", execErr)
+		exitCode = ExitCodeStopConditionError
+	case *multierror.Error:
+		mErr := cause.(*multierror.Error)
+		errCount := len(mErr.Errors)
+		if mErr != nil && errCount > 0 {
+			errMsg = fmt.Sprintf("%d errors occurred:\nFirst error: %s", errCount, mErr.Errors[0].Error())
+		}
+		if errCount > 0x7F {
+			errCount = 0x7F
+		}
+		exitCode = errCount
+	default:
+		// only one error
+		errMsg = fmt.Sprint("1 error occurred:\n", execErr)
+		exitCode = 1
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "%s\n", errMsg)
+	os.Exit(exitCode)
+}
+
+// overrideLogSettings override log format and summary type of config with command line parameters
+func overrideLogSettings(cfg *config.Config) error {
+	if logFormat != "" {
+		var errLogformat error
+		cfg.Settings.LogSettings.Format, errLogformat = resolveLogFormat(logFormat)
+		if errLogformat != nil {
+			return LogFormatError(fmt.Sprintf("error resolving log format<%s>: %v", logFormat, errLogformat))
+		}
+	}
+
+	if summaryType != "" {
+		if summary, errSummaryType := resolveSummaryType(); errSummaryType != nil {
+			return SummaryTypeError(fmt.Sprintf("error resolving summary type<%s>: %v", summaryType, errSummaryType))
+		} else {
+			cfg.Settings.LogSettings.Summary = summary
+		}
+	}
+
+	return nil
+}
+
 func ReadObjectDefinitions() error {
 	if objDefFile != "" {
 		if _, err := senseobjdef.OverrideFromFile(objDefFile); err != nil {
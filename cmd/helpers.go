@@ -25,19 +25,25 @@ func AddConfigParameter(cmd *cobra.Command) {
 }
 
 func unmarshalConfigFile() (*config.Config, error) {
+	cfg, _, err := readConfigFile()
+	return cfg, err
+}
+
+// readConfigFile unmarshal config file and return config together with the raw JSON read from file
+func readConfigFile() (*config.Config, []byte, error) {
 	if cfgFile == "" {
-		return nil, errors.Errorf("No config file defined")
+		return nil, nil, errors.Errorf("No config file defined")
 	}
 
 	cfgJSON, err := ioutil.ReadFile(cfgFile)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Error reading config from file<%s>", cfgFile)
+		return nil, nil, errors.Wrapf(err, "Error reading config from file<%s>", cfgFile)
 	}
 
 	var cfg config.Config
 	if err = jsonit.Unmarshal(cfgJSON, &cfg); err != nil {
-		return nil, errors.Wrap(err, "Failed to unmarshal config from json")
+		return nil, nil, errors.Wrap(err, "Failed to unmarshal config from json")
 	}
 
-	return &cfg, nil
+	return &cfg, cfgJSON, nil
 }
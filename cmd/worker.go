@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/qlik-oss/gopherciser/distributed"
+	"github.com/spf13/cobra"
+)
+
+var controllerAddress string
+
+// workerCmd represents the worker command
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "execute part of scenario assigned by a controller",
+	Long: `execute part of scenario assigned by a controller. The worker registers with the controller, waits for
+all workers to be registered, executes the assigned part of the load and reports statistics to the controller.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if controllerAddress == "" {
+			_, _ = os.Stderr.WriteString("Error: No controller provided\n")
+			if err := cmd.Help(); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Error: %+v\n", err)
+			}
+			return
+		}
+
+		exitOnError(executeWorker())
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(workerCmd)
+
+	workerCmd.Flags().StringVar(&controllerAddress, "controller", "", "URL of controller, e.g. http://localhost:9090")
+
+	// Custom object definitions
+	workerCmd.Flags().StringVarP(&objDefFile, "definitions", "d", "", `Custom object definitions and overrides.`)
+}
+
+func executeWorker() error {
+	// === object definition section ===
+	if err := ReadObjectDefinitions(); err != nil {
+		return err
+	}
+
+	// === Handle SIGINT ===
+	ctx, cancel := context.WithCancel(context.Background())
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	go func() {
+		<-c
+		cancel()
+	}()
+
+	return distributed.NewWorker(controllerAddress).Run(ctx)
+}
@@ -47,6 +47,8 @@ type (
 
 	// SummaryActionDataEntry data entry for action summary table
 	SummaryActionDataEntry struct {
+		Persona     string
+		Node        string
 		Action      string
 		Label       string
 		AppGUID     string
@@ -89,10 +91,12 @@ type (
 		Timeout         int             `json:"timeout" displayname:"WebSocket timeout" doc-key:"config.settings.timeout"` // Timeout in seconds
 		LogSettings     LogSettings     `json:"logs" doc-key:"config.settings.logs"`
 		OutputsSettings OutputsSettings `json:"outputs,omitempty" doc-key:"config.settings.outputs"`
+		StopConditions  []StopCondition `json:"stopconditions,omitempty" displayname:"Stop conditions" doc-key:"config.settings.stopconditions"`
 	}
 
 	cfgCore struct {
 		Scenario           []scenario.Action             `json:"scenario"`
+		Personas           []scheduler.Persona           `json:"personas,omitempty"`
 		Settings           Settings                      `json:"settings"`
 		LoginSettings      users.UserGenerator           `json:"loginSettings"`
 		ConnectionSettings connection.ConnectionSettings `json:"connectionSettings"`
@@ -431,26 +435,43 @@ func (cfg *Config) Validate() error {
 		return errors.Wrap(err, "Scheduler settings validation failed")
 	}
 
-	if cfg.Scheduler.RequireScenario() {
+	if cfg.Scheduler.RequireScenario() && len(cfg.Personas) < 1 {
 		if cfg.Scenario == nil || len(cfg.Scenario) < 1 {
 			return errors.Errorf("No scenario items defined")
 		}
 	}
 
-	if cfg.LoginSettings.Settings == nil {
-		return errors.Errorf("No LoginSettings defined")
+	if err := scheduler.ValidatePersonas(cfg.Personas); err != nil {
+		return errors.Wrap(err, "Personas validation failed")
 	}
-	if err := cfg.LoginSettings.Settings.Validate(); err != nil {
-		return errors.Wrap(err, "LoginSettings validation failed")
+	if len(cfg.Personas) > 0 {
+		if _, ok := cfg.Scheduler.(scheduler.PersonaScheduler); !ok {
+			return errors.Errorf("Scheduler<%T> does not support personas", cfg.Scheduler)
+		}
 	}
 
-	if cfg.ConnectionSettings.Server == "" {
+	if cfg.requireLoginSettings() {
+		if cfg.LoginSettings.Settings == nil {
+			return errors.Errorf("No LoginSettings defined")
+		}
+		if err := cfg.LoginSettings.Settings.Validate(); err != nil {
+			return errors.Wrap(err, "LoginSettings validation failed")
+		}
+	}
+
+	if cfg.ConnectionSettings.Server == "" && len(cfg.ConnectionSettings.Servers) < 1 {
 		return errors.Errorf("Empty server name, server name is required")
 	}
 	if err := cfg.ConnectionSettings.Validate(); err != nil {
 		return errors.Wrap(err, "ConnectionSettings validation failed")
 	}
 
+	for i, condition := range cfg.Settings.StopConditions {
+		if err := condition.Validate(); err != nil {
+			return errors.Wrapf(err, "Stop condition<%d> validation failed", i)
+		}
+	}
+
 	// Validate all actions before executing
 	for _, v := range cfg.Scenario {
 		if err := v.Validate(); err != nil {
@@ -461,8 +482,25 @@ func (cfg *Config) Validate() error {
 	return nil
 }
 
+// requireLoginSettings default LoginSettings are required unless all personas define their own
+func (cfg *Config) requireLoginSettings() bool {
+	if len(cfg.Personas) < 1 {
+		return true
+	}
+	for _, persona := range cfg.Personas {
+		if persona.LoginSettings == nil {
+			return true
+		}
+	}
+	return false
+}
+
 func (cfg *Config) TestConnection(ctx context.Context) error {
-	user := cfg.LoginSettings.GetNext()
+	userGenerator := cfg.LoginSettings
+	if userGenerator.Settings == nil && len(cfg.Personas) > 0 && cfg.Personas[0].LoginSettings != nil {
+		userGenerator = *cfg.Personas[0].LoginSettings
+	}
+	user := userGenerator.GetNext()
 	cfg.Settings.LogSettings.Format = LogFormatNoLogs
 	log, err := setupLogging(ctx, cfg.Settings.LogSettings, cfg.CustomLoggers, nil)
 	if err != nil {
@@ -471,23 +509,37 @@ func (cfg *Config) TestConnection(ctx context.Context) error {
 	if log == nil {
 		return errors.New("setup logging returned nil logger")
 	}
-	sessionState := session.New(ctx, "", time.Duration(cfg.Settings.Timeout)*time.Second, user, 1, 1, cfg.ConnectionSettings.VirtualProxy)
+
+	// test connection to each server node
+	for _, connectionSettings := range cfg.ConnectionSettings.NodeSettings() {
+		if err := cfg.testConnection(ctx, log, user, connectionSettings); err != nil {
+			if node := connectionSettings.Node(); node != "" {
+				return errors.Wrapf(err, "server node<%s>", node)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *Config) testConnection(ctx context.Context, log *logger.Log, user *users.User, connectionSettings *connection.ConnectionSettings) error {
+	sessionState := session.New(ctx, "", time.Duration(cfg.Settings.Timeout)*time.Second, user, 1, 1, connectionSettings.VirtualProxy)
 	logEntry := log.NewLogEntry()
 	sessionState.SetLogEntry(logEntry)
 	sessionState.LogEntry.Session = &logger.SessionEntry{}
 
-	headers, err := cfg.ConnectionSettings.GetHeaders(sessionState)
+	headers, err := connectionSettings.GetHeaders(sessionState)
 	if err != nil {
 		return errors.Wrap(err, "failed to generate authentication headers")
 	}
-	host, err := cfg.ConnectionSettings.GetHost()
+	host, err := connectionSettings.GetHost()
 	if err != nil {
 		return errors.Wrap(err, "failed to extract hostname")
 	}
 	sessionState.HeaderJar.SetHeader(host, headers)
 	sessionState.LoggedIn = true
 
-	client, err := session.DefaultClient(&cfg.ConnectionSettings, sessionState)
+	client, err := session.DefaultClient(connectionSettings, sessionState)
 	if err != nil {
 		return errors.Wrap(err, "failed to set up REST client")
 	}
@@ -498,7 +550,7 @@ func (cfg *Config) TestConnection(ctx context.Context) error {
 
 	errs := make([]error, 0)
 	for _, connFunc := range scenario.GetConnTestFuncs() {
-		if err = connFunc(&cfg.ConnectionSettings, sessionState, actionState); err == nil {
+		if err = connFunc(connectionSettings, sessionState, actionState); err == nil {
 			break
 		}
 		errs = append(errs, err)
@@ -520,7 +572,32 @@ func (cfg *Config) TestConnection(ctx context.Context) error {
 
 // Execute scenario (will be replaced by scheduler)
 func (cfg *Config) Execute(ctx context.Context, templateData interface{}) error {
-	timeout := time.Duration(cfg.Settings.Timeout) * time.Second
+	return cfg.ExecuteWith(ctx, templateData, func(ctx context.Context, log *logger.Log) error {
+		timeout := time.Duration(cfg.Settings.Timeout) * time.Second
+
+		// Setup outputs folder
+		outputsDir, err := setupOutputs(cfg.Settings.OutputsSettings)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if personaScheduler, ok := cfg.Scheduler.(scheduler.PersonaScheduler); ok {
+			if err := personaScheduler.SetPersonas(cfg.Personas); err != nil {
+				return errors.WithStack(err)
+			}
+		} else if len(cfg.Personas) > 0 {
+			return errors.Errorf("Scheduler<%T> does not support personas", cfg.Scheduler)
+		}
+
+		return cfg.Scheduler.Execute(
+			ctx, log, timeout, cfg.Scenario, outputsDir, cfg.LoginSettings, &cfg.ConnectionSettings,
+		)
+	})
+}
+
+// ExecuteWith set up logging and statistics according to config, execute function and log summary. Used when
+// execution is not done by the scheduler of the config in this process, e.g. by distributed workers.
+func (cfg *Config) ExecuteWith(ctx context.Context, templateData interface{}, execute func(ctx context.Context, log *logger.Log) error) error {
 	// Setup logging
 	log, err := setupLogging(ctx, cfg.Settings.LogSettings, cfg.CustomLoggers, templateData)
 	if err != nil {
@@ -547,22 +624,31 @@ func (cfg *Config) Execute(ctx context.Context, templateData interface{}) error
 		entry.LogInfo("Script", string(script))
 	}
 
-	// Setup outputs folder
-	outputsDir, err := setupOutputs(cfg.Settings.OutputsSettings)
-	if err != nil {
-		return errors.WithStack(err)
-	}
-
 	// start statistics collection if summarylevel high enough
 	summaryType := cfg.Settings.LogSettings.getSummaryType()
 	setupStatistics(summaryType)
 
 	// Log test summary after test is done
-	defer summary(log, summaryType, time.Now())
+	startTime := time.Now()
+	defer func() {
+		var results []scheduler.SummaryResult
+		if summaryScheduler, ok := cfg.Scheduler.(scheduler.SummaryScheduler); ok {
+			results = summaryScheduler.SummaryResults()
+		}
+		summary(log, summaryType, startTime, results...)
+	}()
+
+	// Stop execution when a stop condition is met
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	monitor := newStopMonitor(cfg.Settings.StopConditions)
+	monitor.start(ctx, entry, cancel)
 
-	execErr := cfg.Scheduler.Execute(
-		ctx, log, timeout, cfg.Scenario, outputsDir, cfg.LoginSettings, &cfg.ConnectionSettings,
-	)
+	execErr := execute(ctx, log)
+	cancel()
+	if stopErr := monitor.stop(); stopErr != nil {
+		return errors.WithStack(stopErr)
+	}
 	if execErr != nil {
 		return errors.WithStack(execErr)
 	}
@@ -640,7 +726,7 @@ func (summary *SummaryType) EntryEnd() string {
 	}
 }
 
-func summary(log *logger.Log, summary SummaryType, startTime time.Time) {
+func summary(log *logger.Log, summary SummaryType, startTime time.Time, results ...scheduler.SummaryResult) {
 	testDuration := time.Since(startTime)
 
 	entry := logger.NewLogEntry(log)
@@ -654,6 +740,9 @@ func summary(log *logger.Log, summary SummaryType, startTime time.Time) {
 	entry.LogInfo("TotActions", actions)
 	entry.LogInfo("TotRequests", requests)
 	entry.LogInfo("TestDuration", strconv.FormatInt(testDuration.Nanoseconds(), 10))
+	for _, result := range results {
+		entry.LogInfo(result.Name, result.Value)
+	}
 
 	buf := helpers.NewBuffer()
 	defer func() {
@@ -687,6 +776,9 @@ func summary(log *logger.Log, summary SummaryType, startTime time.Time) {
 		{"Total requests", "TotRequests", requests, ansiBoldBlue},
 		{"Duration", "Duration", testDuration.String(), ansiBoldBlue},
 	}
+	for _, result := range results {
+		summaryData = append(summaryData, SummaryEntry{result.Title, result.Name, result.Value, ansiBoldBlue})
+	}
 
 	// Decide summary output
 	switch summary {
@@ -730,6 +822,8 @@ func summary(log *logger.Log, summary SummaryType, startTime time.Time) {
 	actionTblData := make([]SummaryActionDataEntry, 0, statistics.GlobalActionsLen())
 
 	// Create headers and default column sizes
+	summaryHeaders["persona"] = &SummaryHeaderEntry{"Persona", 7}
+	summaryHeaders["node"] = &SummaryHeaderEntry{"Node", 4}
 	summaryHeaders["actn"] = &SummaryHeaderEntry{"Action", 6}
 	summaryHeaders["lbl"] = &SummaryHeaderEntry{"Label", 5}
 	summaryHeaders["app"] = &SummaryHeaderEntry{"AppGUID", 7}
@@ -743,6 +837,7 @@ func summary(log *logger.Log, summary SummaryType, startTime time.Time) {
 
 	// todo max column size and truncate?
 	// Calculate column lengths and fill data struct
+	var usePersonas, useNodes bool
 	statistics.ForEachAction(func(stats *statistics.ActionStats) {
 		// add data entry
 		resp, successful := stats.RespAvg.Average()
@@ -759,6 +854,8 @@ func summary(log *logger.Log, summary SummaryType, startTime time.Time) {
 		}
 
 		entry := SummaryActionDataEntry{
+			Persona:     stats.Persona(),
+			Node:        stats.Node(),
 			Action:      stats.Name(),
 			Label:       stats.Label(),
 			AppGUID:     stats.AppGUID(),
@@ -772,6 +869,14 @@ func summary(log *logger.Log, summary SummaryType, startTime time.Time) {
 		}
 		actionTblData = append(actionTblData, entry)
 
+		if stats.Persona() != "" {
+			usePersonas = true
+		}
+		if stats.Node() != "" {
+			useNodes = true
+		}
+		summaryHeaders["persona"].UpdateColSize(len(stats.Persona()))
+		summaryHeaders["node"].UpdateColSize(len(stats.Node()))
 		summaryHeaders["actn"].UpdateColSize(len(stats.Name()))
 		summaryHeaders["lbl"].UpdateColSize(len(stats.Label()))
 		summaryHeaders["app"].UpdateColSize(len(stats.AppGUID()))
@@ -787,6 +892,12 @@ func summary(log *logger.Log, summary SummaryType, startTime time.Time) {
 	// Actions table
 	tabbedOutput := tabular.New()
 
+	if usePersonas {
+		summaryHeaders.Col("persona", &tabbedOutput)
+	}
+	if useNodes {
+		summaryHeaders.Col("node", &tabbedOutput)
+	}
 	for _, v := range []string{"actn", "lbl", "app"} {
 		summaryHeaders.Col(v, &tabbedOutput)
 	}
@@ -800,8 +911,17 @@ func summary(log *logger.Log, summary SummaryType, startTime time.Time) {
 	writeTableHeaders(buf, &table)
 
 	for _, v := range actionTblData {
+		columns := make([]interface{}, 0, 12)
+		if usePersonas {
+			columns = append(columns, v.Persona)
+		}
+		if useNodes {
+			columns = append(columns, v.Node)
+		}
+		columns = append(columns, v.Action, v.Label, v.AppGUID, v.SuccessRate, v.AvgResp, v.Requests, v.Errs, v.Warns, v.Sent, v.Received)
+
 		buf.WriteString(ansiBoldBlue)
-		buf.WriteString(fmt.Sprintf(table.Format, v.Action, v.Label, v.AppGUID, v.SuccessRate, v.AvgResp, v.Requests, v.Errs, v.Warns, v.Sent, v.Received))
+		buf.WriteString(fmt.Sprintf(table.Format, columns...))
 		buf.WriteString(ansiReset)
 	}
 
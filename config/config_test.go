@@ -718,3 +718,71 @@ func isEntryEquatable(msg logger.LogChanMsg, wrt writerEntry) bool {
 	}
 	return true
 }
+
+func TestPersonas(t *testing.T) {
+	JSONConfigFile := `{
+		"settings" : {
+			"timeout" : 300
+		},
+		"connectionSettings" : {
+			"mode" : "ws",
+			"server" : "localhost"
+		},
+		"scheduler" : {
+			"type" : "simple",
+			"settings" : {
+				"executionTime" : -1,
+				"iterations" : 1,
+				"rampupDelay" : 1.0,
+				"concurrentUsers" : 10
+			}
+		},
+		"personas" : [
+			{
+				"name" : "consumer",
+				"share" : 80,
+				"scenario" : [
+					{ "action" : "thinktime", "settings" : { "type" : "static", "delay" : 1 } }
+				]
+			},
+			{
+				"name" : "author",
+				"share" : 20,
+				"loginSettings" : {
+					"type" : "prefix",
+					"settings" : { "prefix" : "author" }
+				},
+				"scenario" : [
+					{ "action" : "thinktime", "settings" : { "type" : "static", "delay" : 2 } }
+				]
+			}
+		]
+	}`
+
+	var cfg config.Config
+	if err := jsonit.Unmarshal([]byte(JSONConfigFile), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cfg.Personas) != 2 {
+		t.Fatalf("Unexpected amount of personas<%d> expected<2>", len(cfg.Personas))
+	}
+	if cfg.Personas[1].LoginSettings == nil {
+		t.Fatal("Expected persona<author> to have login settings")
+	}
+
+	// Consumer persona uses default login settings, which are missing
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected validation to fail without default login settings")
+	}
+
+	cfg.Personas[0].LoginSettings = cfg.Personas[1].LoginSettings
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.Personas[1].Share = 30
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected validation to fail with shares not adding up to 100")
+	}
+}
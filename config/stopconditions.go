@@ -0,0 +1,323 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/enummap"
+	"github.com/qlik-oss/gopherciser/globals"
+	"github.com/qlik-oss/gopherciser/helpers"
+	"github.com/qlik-oss/gopherciser/logger"
+	"github.com/qlik-oss/gopherciser/statistics"
+)
+
+type (
+	// StopConditionType type of stop condition
+	StopConditionType int
+
+	// StopCondition stops execution when condition is met
+	StopCondition struct {
+		Type       StopConditionType    `json:"type" displayname:"Condition type" doc-key:"config.settings.stopconditions.type"`
+		Threshold  float64              `json:"threshold,omitempty" displayname:"Threshold" doc-key:"config.settings.stopconditions.threshold"`
+		Window     int                  `json:"window,omitempty" displayname:"Window of actions" doc-key:"config.settings.stopconditions.window"`
+		Percentile float64              `json:"percentile,omitempty" displayname:"Percentile" doc-key:"config.settings.stopconditions.percentile"`
+		Label      string               `json:"label,omitempty" displayname:"Action label" doc-key:"config.settings.stopconditions.label"`
+		Duration   helpers.TimeDuration `json:"duration,omitempty" displayname:"Duration" doc-key:"config.settings.stopconditions.duration"`
+	}
+
+	// StopConditionError execution was stopped due to a stop condition being met
+	StopConditionError string
+
+	// stopMonitor evaluates stop conditions during execution
+	stopMonitor struct {
+		conditions []*stopConditionState
+
+		mu      sync.Mutex
+		reason  string
+		started bool
+		done    chan struct{}
+	}
+
+	// stopConditionState state of a stop condition being evaluated
+	stopConditionState struct {
+		StopCondition
+
+		// samples ring buffer of the last Window failed (1) or successful (0) actions, or of response times
+		samples []float64
+		next    int
+		count   int
+
+		// wasActive users have been active, zeroSince time when active users dropped to zero
+		wasActive bool
+		zeroSince time.Time
+	}
+)
+
+// StopConditionType enum
+const (
+	// StopOnErrorRate stop when percentage of failed actions within window is above threshold
+	StopOnErrorRate StopConditionType = iota
+	// StopOnResponseTime stop when response time percentile within window is above threshold
+	StopOnResponseTime
+	// StopOnNoActiveUsers stop when there have been no active users for duration
+	StopOnNoActiveUsers
+)
+
+const (
+	// DefaultStopPercentile default response time percentile of StopOnResponseTime
+	DefaultStopPercentile = 95
+	// DefaultStopNoUsersDuration default duration of StopOnNoActiveUsers
+	DefaultStopNoUsersDuration = 10 * time.Second
+
+	// stopConditionInterval interval in between evaluation of stop conditions
+	stopConditionInterval = time.Second
+)
+
+func (value StopConditionType) GetEnumMap() *enummap.EnumMap {
+	enumMap, _ := enummap.NewEnumMap(map[string]int{
+		"errorrate":     int(StopOnErrorRate),
+		"responsetime":  int(StopOnResponseTime),
+		"noactiveusers": int(StopOnNoActiveUsers),
+	})
+	return enumMap
+}
+
+// UnmarshalJSON unmarshal StopConditionType
+func (value *StopConditionType) UnmarshalJSON(arg []byte) error {
+	i, err := value.GetEnumMap().UnMarshal(arg)
+	if err != nil {
+		return errors.Wrap(err, "Failed to unmarshal StopConditionType")
+	}
+
+	*value = StopConditionType(i)
+	return nil
+}
+
+// MarshalJSON marshal StopConditionType
+func (value StopConditionType) MarshalJSON() ([]byte, error) {
+	str, err := value.GetEnumMap().String(int(value))
+	return []byte(fmt.Sprintf(`"%s"`, str)), errors.Wrapf(err, "failed to marshal StopConditionType<%d>", value)
+}
+
+// Error implementation of Error interface
+func (err StopConditionError) Error() string {
+	return string(err)
+}
+
+// Validate stop condition
+func (condition *StopCondition) Validate() error {
+	switch condition.Type {
+	case StopOnErrorRate:
+		if condition.Threshold < 0 || condition.Threshold >= 100 {
+			return errors.Errorf("errorrate threshold<%v> not within 0-100%%", condition.Threshold)
+		}
+		if condition.Window < 1 {
+			return errors.Errorf("errorrate window<%d> needs to be at least 1 action", condition.Window)
+		}
+	case StopOnResponseTime:
+		if condition.Threshold <= 0 {
+			return errors.Errorf("responsetime threshold<%v> needs to be above 0 ms", condition.Threshold)
+		}
+		if condition.Window < 1 {
+			return errors.Errorf("responsetime window<%d> needs to be at least 1 action", condition.Window)
+		}
+		if condition.Percentile < 0 || condition.Percentile > 100 {
+			return errors.Errorf("responsetime percentile<%v> not within 0-100", condition.Percentile)
+		}
+	case StopOnNoActiveUsers:
+		if condition.Duration < 0 {
+			return errors.Errorf("noactiveusers duration<%v> is negative", time.Duration(condition.Duration))
+		}
+	default:
+		return errors.Errorf("unknown stop condition type<%d>", condition.Type)
+	}
+	return nil
+}
+
+// String description of stop condition
+func (condition *StopCondition) String() string {
+	switch condition.Type {
+	case StopOnErrorRate:
+		return fmt.Sprintf("error rate of last %d actions above %v%%", condition.Window, condition.Threshold)
+	case StopOnResponseTime:
+		action := "actions"
+		if condition.Label != "" {
+			action = fmt.Sprintf("actions labeled <%s>", condition.Label)
+		}
+		return fmt.Sprintf("p%v response time of last %d %s above %vms", condition.percentile(), condition.Window,
+			action, condition.Threshold)
+	case StopOnNoActiveUsers:
+		return fmt.Sprintf("no active users for %v", condition.noUsersDuration())
+	default:
+		return fmt.Sprintf("unknown stop condition type<%d>", condition.Type)
+	}
+}
+
+func (condition *StopCondition) percentile() float64 {
+	if condition.Percentile <= 0 {
+		return DefaultStopPercentile
+	}
+	return condition.Percentile
+}
+
+func (condition *StopCondition) noUsersDuration() time.Duration {
+	if condition.Duration <= 0 {
+		return DefaultStopNoUsersDuration
+	}
+	return time.Duration(condition.Duration)
+}
+
+// newStopMonitor monitor evaluating conditions, returns nil if there are no conditions
+func newStopMonitor(conditions []StopCondition) *stopMonitor {
+	if len(conditions) < 1 {
+		return nil
+	}
+
+	monitor := &stopMonitor{
+		conditions: make([]*stopConditionState, 0, len(conditions)),
+		done:       make(chan struct{}),
+	}
+	for _, condition := range conditions {
+		state := &stopConditionState{StopCondition: condition}
+		if condition.Type != StopOnNoActiveUsers {
+			state.samples = make([]float64, condition.Window)
+		}
+		monitor.conditions = append(monitor.conditions, state)
+	}
+	return monitor
+}
+
+// start evaluating stop conditions until ctx is done or stop is called, stopExecution is called when a condition
+// is met
+func (monitor *stopMonitor) start(ctx context.Context, entry *logger.LogEntry, stopExecution func()) {
+	if monitor == nil {
+		return
+	}
+
+	monitor.started = true
+	removeListener := statistics.AddResultListener(monitor.addResult)
+	go func() {
+		defer close(monitor.done)
+		defer removeListener()
+
+		ticker := time.NewTicker(stopConditionInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if reason := monitor.evaluate(time.Now()); reason != "" {
+					entry.LogInfo("StopCondition", fmt.Sprintf("stopping execution: %s", reason))
+					stopExecution()
+					return
+				}
+			}
+		}
+	}()
+}
+
+// stop evaluating stop conditions, returns StopConditionError if execution was stopped by a stop condition
+func (monitor *stopMonitor) stop() error {
+	if monitor == nil || !monitor.started {
+		return nil
+	}
+	<-monitor.done
+
+	monitor.mu.Lock()
+	defer monitor.mu.Unlock()
+	if monitor.reason == "" {
+		return nil
+	}
+	return StopConditionError(fmt.Sprintf("execution stopped: %s", monitor.reason))
+}
+
+// addResult add action result to windows of stop conditions
+func (monitor *stopMonitor) addResult(result statistics.ActionResult) {
+	monitor.mu.Lock()
+	defer monitor.mu.Unlock()
+
+	for _, state := range monitor.conditions {
+		switch state.Type {
+		case StopOnErrorRate:
+			failed := 0.0
+			if !result.Success {
+				failed = 1
+			}
+			state.add(failed)
+		case StopOnResponseTime:
+			// response time is only measured for successful actions
+			if result.Success && (state.Label == "" || state.Label == result.Label) {
+				state.add(float64(result.ResponseTime) / float64(time.Millisecond))
+			}
+		}
+	}
+}
+
+// evaluate stop conditions, returns reason for stopping or empty string when no condition is met
+func (monitor *stopMonitor) evaluate(now time.Time) string {
+	monitor.mu.Lock()
+	defer monitor.mu.Unlock()
+
+	if monitor.reason != "" {
+		return monitor.reason
+	}
+
+	for _, state := range monitor.conditions {
+		if value, met := state.evaluate(now); met {
+			monitor.reason = fmt.Sprintf("%s (%s)", state.String(), value)
+			return monitor.reason
+		}
+	}
+	return ""
+}
+
+func (state *stopConditionState) add(sample float64) {
+	state.samples[state.next] = sample
+	state.next = (state.next + 1) % len(state.samples)
+	if state.count < len(state.samples) {
+		state.count++
+	}
+}
+
+// evaluate condition, returns current value and if condition is met. Window conditions are only evaluated once the
+// window is full.
+func (state *stopConditionState) evaluate(now time.Time) (string, bool) {
+	switch state.Type {
+	case StopOnErrorRate:
+		if state.count < len(state.samples) {
+			return "", false
+		}
+		var failed float64
+		for _, sample := range state.samples {
+			failed += sample
+		}
+		rate := 100 * failed / float64(len(state.samples))
+		return fmt.Sprintf("%.1f%%", rate), rate > state.Threshold
+	case StopOnResponseTime:
+		if state.count < len(state.samples) {
+			return "", false
+		}
+		value := statistics.Percentile(state.samples, state.percentile())
+		return fmt.Sprintf("%.0fms", value), value > state.Threshold
+	case StopOnNoActiveUsers:
+		if globals.ActiveUsers.Current() > 0 {
+			state.wasActive = true
+			state.zeroSince = time.Time{}
+			return "", false
+		}
+		if !state.wasActive {
+			return "", false
+		}
+		if state.zeroSince.IsZero() {
+			state.zeroSince = now
+		}
+		zero := now.Sub(state.zeroSince)
+		return zero.String(), zero >= state.noUsersDuration()
+	}
+	return "", false
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/globals"
+	"github.com/qlik-oss/gopherciser/logger"
+	"github.com/qlik-oss/gopherciser/statistics"
+)
+
+func TestStopConditionUnmarshal(t *testing.T) {
+	raw := `[
+		{ "type" : "errorrate", "threshold" : 10, "window" : 100 },
+		{ "type" : "responsetime", "threshold" : 2000, "window" : 50, "label" : "open app" },
+		{ "type" : "noactiveusers", "duration" : "30s" }
+	]`
+
+	var conditions []StopCondition
+	if err := jsonit.Unmarshal([]byte(raw), &conditions); err != nil {
+		t.Fatal(err)
+	}
+	if len(conditions) != 3 {
+		t.Fatalf("expected 3 conditions, got<%d>", len(conditions))
+	}
+	for i, condition := range conditions {
+		if err := condition.Validate(); err != nil {
+			t.Errorf("condition<%d> validation failed: %v", i, err)
+		}
+	}
+
+	if conditions[1].Type != StopOnResponseTime || conditions[1].percentile() != DefaultStopPercentile {
+		t.Errorf("unexpected response time condition<%+v>", conditions[1])
+	}
+	if conditions[2].noUsersDuration() != 30*time.Second {
+		t.Errorf("expected no users duration<30s>, got<%v>", conditions[2].noUsersDuration())
+	}
+
+	invalid := []StopCondition{
+		{Type: StopOnErrorRate, Threshold: 10},
+		{Type: StopOnErrorRate, Threshold: 100, Window: 10},
+		{Type: StopOnResponseTime, Window: 10},
+		{Type: StopOnResponseTime, Threshold: 100, Window: 10, Percentile: 101},
+	}
+	for i, condition := range invalid {
+		if err := condition.Validate(); err == nil {
+			t.Errorf("expected invalid condition<%d> to fail validation", i)
+		}
+	}
+}
+
+func TestStopMonitor(t *testing.T) {
+	monitor := newStopMonitor([]StopCondition{
+		{Type: StopOnErrorRate, Threshold: 20, Window: 10},
+		{Type: StopOnResponseTime, Threshold: 500, Window: 5, Label: "slow"},
+	})
+	now := time.Now()
+
+	// window not full
+	for i := 0; i < 9; i++ {
+		monitor.addResult(statistics.ActionResult{Success: i > 2})
+	}
+	if reason := monitor.evaluate(now); reason != "" {
+		t.Errorf("unexpected stop before error rate window is full: %s", reason)
+	}
+
+	// 2 of last 10 actions failed
+	monitor.addResult(statistics.ActionResult{Success: true})
+	monitor.addResult(statistics.ActionResult{Success: true})
+	if reason := monitor.evaluate(now); reason != "" {
+		t.Errorf("unexpected stop at error rate 20%%: %s", reason)
+	}
+
+	// response times of other labels are ignored, p95 of 5 samples is the max
+	for i := 0; i < 5; i++ {
+		monitor.addResult(statistics.ActionResult{Label: "fast", Success: true, ResponseTime: time.Second})
+	}
+	for _, ms := range []time.Duration{100, 100, 100, 100, 600} {
+		monitor.addResult(statistics.ActionResult{Label: "slow", Success: true, ResponseTime: ms * time.Millisecond})
+	}
+	reason := monitor.evaluate(now)
+	if reason == "" {
+		t.Fatal("expected stop on response time")
+	}
+	t.Log(reason)
+
+	// reason is kept once a condition is met
+	monitor.addResult(statistics.ActionResult{Label: "slow", Success: true, ResponseTime: time.Millisecond})
+	if monitor.evaluate(now) != reason {
+		t.Error("expected reason to be kept")
+	}
+}
+
+func TestStopOnNoActiveUsers(t *testing.T) {
+	state := &stopConditionState{StopCondition: StopCondition{
+		Type:     StopOnNoActiveUsers,
+		Duration: 0, // default
+	}}
+	now := time.Now()
+
+	// not active yet
+	if _, met := state.evaluate(now.Add(time.Hour)); met {
+		t.Error("unexpected stop before users have been active")
+	}
+
+	globals.ActiveUsers.Inc()
+	_, met := state.evaluate(now)
+	globals.ActiveUsers.Dec()
+	if met {
+		t.Error("unexpected stop with active users")
+	}
+
+	if _, met := state.evaluate(now); met {
+		t.Error("unexpected stop when users dropped to zero")
+	}
+	if _, met := state.evaluate(now.Add(DefaultStopNoUsersDuration)); !met {
+		t.Error("expected stop after default duration without active users")
+	}
+}
+
+func TestStopMonitorStopsExecution(t *testing.T) {
+	monitor := newStopMonitor([]StopCondition{{Type: StopOnErrorRate, Threshold: 50, Window: 2}})
+
+	log := logger.NewLog(logger.LogSettings{})
+	log.AddLoggers(logger.CreateDummyLogger())
+	log.StartLogger(context.Background())
+	defer func() {
+		_ = log.Close()
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	monitor.start(ctx, log.NewLogEntry(), cancel)
+
+	statistics.ReportResult(statistics.ActionResult{Success: false})
+	statistics.ReportResult(statistics.ActionResult{Success: false})
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * stopConditionInterval):
+		t.Fatal("execution not stopped")
+	}
+
+	err := monitor.stop()
+	if _, ok := errors.Cause(err).(StopConditionError); !ok {
+		t.Errorf("expected StopConditionError, got<%v>", err)
+	}
+}
@@ -2,6 +2,7 @@ package connection
 
 import (
 	"bytes"
+	"crypto/x509"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -13,7 +14,9 @@ import (
 
 	jsoniter "github.com/json-iterator/go"
 	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/enigmahandlers"
 	"github.com/qlik-oss/gopherciser/enummap"
+	"github.com/qlik-oss/gopherciser/netemulation"
 	"github.com/qlik-oss/gopherciser/session"
 	"github.com/qlik-oss/gopherciser/users"
 )
@@ -22,14 +25,22 @@ type (
 	AuthenticationMode int
 
 	ConnectionSettings struct {
-		// Mode authentication mode, either JWT or WS
+		// Mode authentication mode, either JWT, WS, FORM or TICKET
 		Mode AuthenticationMode `json:"mode" doc-key:"config.connectionSettings.mode"`
 		// JwtSettings JWT mode specific settings
 		JwtSettings *ConnectJWTSettings `json:"jwtsettings,omitempty" doc-key:"config.connectionSettings.jwtsettings"`
 		// WsSettings WS mode specific settings
 		WsSettings *ConnectWsSettings `json:"wssettings,omitempty" doc-key:"config.connectionSettings.wssettings"`
+		// FormSettings FORM mode specific settings
+		FormSettings *ConnectFormSettings `json:"formsettings,omitempty" doc-key:"config.connectionSettings.formsettings"`
+		// TicketSettings TICKET mode specific settings
+		TicketSettings *ConnectTicketSettings `json:"ticketsettings,omitempty" doc-key:"config.connectionSettings.ticketsettings"`
 		// Server remote host
 		Server string `json:"server" doc-key:"config.connectionSettings.server"`
+		// Servers server nodes of multi-node deployment, used instead of Server
+		Servers []ServerNode `json:"servers,omitempty" doc-key:"config.connectionSettings.servers"`
+		// ServerStrategy strategy for selecting server node of each session
+		ServerStrategy ServerStrategy `json:"serverstrategy,omitempty" doc-key:"config.connectionSettings.serverstrategy"`
 		// VirtualProxy sense virtual proxy used (added to connect path)
 		VirtualProxy string `json:"virtualproxy" doc-key:"config.connectionSettings.virtualproxy"`
 		// RawURL used to specify custom path for connection to sense app
@@ -40,6 +51,22 @@ type (
 		Security bool `json:"security" doc-key:"config.connectionSettings.security"`
 		// Allowuntrusted certificates
 		Allowuntrusted bool `json:"allowuntrusted" doc-key:"config.connectionSettings.allowuntrusted"`
+		// ClientCertPath path to client certificate, processed as a GO template allowing certificates per user
+		ClientCertPath session.SyncedTemplate `json:"clientcertpath,omitempty" doc-key:"config.connectionSettings.clientcertpath"`
+		// ClientKeyPath path to private key of client certificate, processed as a GO template
+		ClientKeyPath session.SyncedTemplate `json:"clientkeypath,omitempty" doc-key:"config.connectionSettings.clientkeypath"`
+		// CAPath path to CA bundle used in addition to system root certificates
+		CAPath string `json:"capath,omitempty" doc-key:"config.connectionSettings.capath"`
+		// Proxy HTTP or SOCKS5 proxy used for websocket and REST traffic
+		Proxy *ProxySettings `json:"proxy,omitempty" doc-key:"config.connectionSettings.proxy"`
+		// Network emulated network conditions of websocket and REST connections
+		Network *netemulation.Conditions `json:"network,omitempty" doc-key:"config.connectionSettings.network"`
+		// Compression negotiate permessage-deflate compression of websocket messages
+		Compression bool `json:"compression,omitempty" doc-key:"config.connectionSettings.compression"`
+		// CompressionLevel compression level (1-9) of sent websocket messages, defaults to flate default level
+		CompressionLevel int `json:"compressionlevel,omitempty" doc-key:"config.connectionSettings.compressionlevel"`
+		// Reconnect reconnect policy of websocket closed unexpectedly
+		Reconnect *enigmahandlers.ReconnectSettings `json:"reconnect,omitempty" doc-key:"config.connectionSettings.reconnect"`
 		// AppExt : By making this a pointer, we can check whether it was initialized
 		// so that if omitted, it defaults to "app", but can be explicitly set to an empty string as well
 		AppExt *string `json:"appext,omitempty" doc-key:"config.connectionSettings.appext"`
@@ -48,6 +75,16 @@ type (
 
 		syncTemplates sync.Once
 		templates     map[string]*template.Template
+
+		loadRootCAs sync.Once
+		rootCAs     *x509.CertPool
+		rootCAsErr  error
+		clientCerts clientCertificates
+
+		// node name when connection settings are those of a server node
+		node      string
+		loadNodes sync.Once
+		nodes     *serverNodes
 	}
 )
 
@@ -56,6 +93,10 @@ const (
 	JWT AuthenticationMode = iota
 	// WS connect websocket without auth
 	WS
+	// FORM connect websocket after HTTP login flow
+	FORM
+	// TICKET connect websocket after redeeming ticket from Qlik Sense Proxy Service ticket API
+	TICKET
 )
 
 var (
@@ -67,8 +108,10 @@ var (
 
 func (value AuthenticationMode) GetEnumMap() *enummap.EnumMap {
 	enumMap, _ := enummap.NewEnumMap(map[string]int{
-		"jwt": int(JWT),
-		"ws":  int(WS),
+		"jwt":    int(JWT),
+		"ws":     int(WS),
+		"form":   int(FORM),
+		"ticket": int(TICKET),
 	})
 	return enumMap
 }
@@ -104,10 +147,46 @@ func (connectionSettings *ConnectionSettings) Validate() error {
 		if err := connectionSettings.WsSettings.Validate(); err != nil {
 			return errors.WithStack(err)
 		}
+	case FORM:
+		if err := connectionSettings.FormSettings.Validate(); err != nil {
+			return errors.WithStack(err)
+		}
+	case TICKET:
+		if err := connectionSettings.TicketSettings.Validate(); err != nil {
+			return errors.WithStack(err)
+		}
 	default:
 		return errors.Errorf("Unknown connection mode <%d>", connectionSettings.Mode)
 	}
 
+	if err := connectionSettings.validateServers(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := connectionSettings.validateTLS(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if connectionSettings.Proxy != nil {
+		if err := connectionSettings.Proxy.Validate(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if connectionSettings.CompressionLevel < 0 || connectionSettings.CompressionLevel > 9 {
+		return errors.Errorf("invalid compressionlevel<%d>, should be 1-9", connectionSettings.CompressionLevel)
+	}
+
+	if connectionSettings.Network != nil {
+		if err := connectionSettings.Network.Validate(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if err := connectionSettings.Reconnect.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+
 	if connectionSettings.RawURL != "" {
 		if strings.HasPrefix(connectionSettings.RawURL, "wss://") ||
 			strings.HasPrefix(connectionSettings.RawURL, "ws://") {
@@ -130,6 +209,10 @@ func (connectionSettings *ConnectionSettings) GetConnectFunc(state *session.Stat
 		return connectionSettings.JwtSettings.GetConnectFunc(state, connectionSettings, appGUID, header), nil
 	case WS:
 		return connectionSettings.WsSettings.GetConnectFunc(state, connectionSettings, appGUID, header), nil
+	case FORM:
+		return connectionSettings.FormSettings.GetConnectFunc(state, connectionSettings, appGUID, header), nil
+	case TICKET:
+		return connectionSettings.TicketSettings.GetConnectFunc(state, connectionSettings, appGUID, header), nil
 	default:
 		return nil, errors.Errorf("Unknown connection mode <%d>", connectionSettings.Mode)
 	}
@@ -142,7 +225,10 @@ func (connectionSettings *ConnectionSettings) GetHeaders(state *session.State) (
 		return nil, errors.WithStack(err)
 	}
 
-	header := state.HeaderJar.GetHeader(host)
+	header, err := state.HeaderJar.GetRefreshedHeader(host)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
 	if header != nil {
 		return header, nil
 	}
@@ -152,18 +238,38 @@ func (connectionSettings *ConnectionSettings) GetHeaders(state *session.State) (
 		return nil, errors.WithStack(err)
 	}
 
+	var (
+		expires time.Time
+		refresh session.HeaderRefreshFunc
+	)
 	switch connectionSettings.Mode {
 	case JWT:
-		header, err = connectionSettings.JwtSettings.GetJwtHeader(state, header)
+		header, expires, err = connectionSettings.JwtSettings.jwtHeader(state, header)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
+		refresh = connectionSettings.JwtSettings.refreshHeader(state)
 	case WS:
+	case FORM:
+		if err := connectionSettings.FormSettings.Login(state, connectionSettings); err != nil {
+			return nil, errors.Wrap(err, "form login failed")
+		}
+		// non-nil header is kept in header jar, not repeating login flow until session is reset
+		if header == nil {
+			header = make(http.Header)
+		}
+	case TICKET:
+		if err := connectionSettings.TicketSettings.Login(state, connectionSettings); err != nil {
+			return nil, errors.Wrap(err, "ticket login failed")
+		}
+		if header == nil {
+			header = make(http.Header)
+		}
 	default:
 		return nil, errors.Errorf("Unknown connection mode <%d>", connectionSettings.Mode)
 	}
 
-	state.HeaderJar.SetHeader(host, header)
+	state.HeaderJar.SetHeaderWithRefresh(host, header, expires, refresh)
 
 	return header, nil
 }
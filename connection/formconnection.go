@@ -0,0 +1,299 @@
+package connection
+
+import (
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/session"
+)
+
+type (
+	// ConnectFormSettings app and server settings using a HTTP login flow, e.g. an identity provider login page,
+	// before connecting websocket
+	ConnectFormSettings struct {
+		// Steps HTTP requests of login flow, performed in order
+		Steps []FormLoginStep `json:"steps" doc-key:"config.connectionSettings.formsettings.steps"`
+	}
+
+	// FormLoginStep HTTP request of form login flow. URL and form field values are processed as GO templates where
+	// the session variables can be used, as well as values extracted in previous steps as {{.Local.name}}.
+	FormLoginStep struct {
+		// Method GET or POST, defaults to GET
+		Method session.RestMethod `json:"method,omitempty" doc-key:"config.connectionSettings.formsettings.steps.method"`
+		// URL absolute URL or path relative to the URL of the previous response, or to the server for the first step
+		URL session.SyncedTemplate `json:"url" doc-key:"config.connectionSettings.formsettings.steps.url"`
+		// Form fields, sent URL encoded in the body of POST requests and as query parameters of GET requests
+		Form []FormField `json:"form,omitempty" doc-key:"config.connectionSettings.formsettings.steps.form"`
+		// Extract values from response to be used by later steps
+		Extract []FormExtract `json:"extract,omitempty" doc-key:"config.connectionSettings.formsettings.steps.extract"`
+	}
+
+	// FormField templated form field
+	FormField struct {
+		Name  string                 `json:"name" doc-key:"config.connectionSettings.formsettings.steps.form.name"`
+		Value session.SyncedTemplate `json:"value" doc-key:"config.connectionSettings.formsettings.steps.form.value"`
+	}
+
+	// FormExtract extract value from response using one of Input, Regex or JSONPath
+	FormExtract struct {
+		// Name of extracted value
+		Name string `json:"name" doc-key:"config.connectionSettings.formsettings.steps.extract.name"`
+		// Input value of HTML input element with name, e.g. a hidden form field
+		Input string `json:"input,omitempty" doc-key:"config.connectionSettings.formsettings.steps.extract.input"`
+		// Regex first sub match of regular expression, or whole match if it has no sub match
+		Regex string `json:"regex,omitempty" doc-key:"config.connectionSettings.formsettings.steps.extract.regex"`
+		// JSONPath of value in JSON response, e.g. $.data.token
+		JSONPath string `json:"jsonpath,omitempty" doc-key:"config.connectionSettings.formsettings.steps.extract.jsonpath"`
+
+		// regex compiled Regex, set when validated
+		regex *regexp.Regexp
+	}
+)
+
+var (
+	htmlInputRegex     = regexp.MustCompile(`(?is)<input\s[^>]*>`)
+	htmlAttributeRegex = regexp.MustCompile(`(?is)([a-z][a-z0-9_:-]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// GetConnectFunc connect websocket using cookies from login flow
+func (connectForm *ConnectFormSettings) GetConnectFunc(sessionState *session.State, connection *ConnectionSettings, appGUID string, header http.Header) func() (string, error) {
+	return (&ConnectWsSettings{}).GetConnectFunc(sessionState, connection, appGUID, header)
+}
+
+// Validate form login settings
+func (connectForm *ConnectFormSettings) Validate() error {
+	if connectForm == nil || len(connectForm.Steps) < 1 {
+		return errors.New("form login requires at least one step")
+	}
+	for i := range connectForm.Steps {
+		if err := connectForm.Steps[i].validate(); err != nil {
+			return errors.Wrapf(err, "form login step<%d>", i+1)
+		}
+	}
+	return nil
+}
+
+func (step *FormLoginStep) validate() error {
+	if step.URL.String() == "" {
+		return errors.New("no url defined")
+	}
+	if step.Method != session.GET && step.Method != session.POST {
+		return errors.Errorf("unsupported method<%s>", step.Method)
+	}
+	for _, field := range step.Form {
+		if field.Name == "" {
+			return errors.New("form field without name")
+		}
+	}
+	for i := range step.Extract {
+		if err := step.Extract[i].validate(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func (extract *FormExtract) validate() error {
+	if extract.Name == "" {
+		return errors.New("extract without name")
+	}
+	defined := 0
+	for _, s := range []string{extract.Input, extract.Regex, extract.JSONPath} {
+		if s != "" {
+			defined++
+		}
+	}
+	if defined != 1 {
+		return errors.Errorf("extract<%s> requires exactly one of input, regex or jsonpath", extract.Name)
+	}
+	if extract.Regex != "" {
+		regex, err := regexp.Compile(extract.Regex)
+		if err != nil {
+			return errors.Wrapf(err, "extract<%s> has invalid regex", extract.Name)
+		}
+		extract.regex = regex
+	}
+	return nil
+}
+
+// Login perform login flow, cookies set during login flow are added to session cookie jar
+func (connectForm *ConnectFormSettings) Login(sessionState *session.State, connection *ConnectionSettings) error {
+	if sessionState.Cookies == nil {
+		var err error
+		sessionState.Cookies, err = cookiejar.New(nil)
+		if err != nil {
+			return errors.Wrap(err, "failed creating cookie jar")
+		}
+	}
+
+	client, err := session.DefaultClient(connection, sessionState)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	restURL, err := connection.GetRestUrl()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	base, err := url.Parse(restURL)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse server url<%s>", restURL)
+	}
+
+	values := make(map[string]string)
+	for i := range connectForm.Steps {
+		base, err = connectForm.Steps[i].do(sessionState, client, base, values)
+		if err != nil {
+			return errors.Wrapf(err, "form login step<%d> failed", i+1)
+		}
+	}
+	return nil
+}
+
+// do perform step request and extract values, returns URL of final response
+func (step *FormLoginStep) do(sessionState *session.State, client *http.Client, base *url.URL, values map[string]string) (*url.URL, error) {
+	rawURL, err := sessionState.ReplaceSessionVariablesWithLocalData(&step.URL, values)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	reqURL, err := base.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse url<%s>", rawURL)
+	}
+
+	form := make(url.Values, len(step.Form))
+	for _, field := range step.Form {
+		value, err := sessionState.ReplaceSessionVariablesWithLocalData(&field.Value, values)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to execute template of form field<%s>", field.Name)
+		}
+		form.Add(field.Name, value)
+	}
+
+	ctx, cancel := sessionState.ContextWithTimeout(sessionState.BaseContext())
+	defer cancel()
+
+	var req *http.Request
+	switch step.Method {
+	case session.POST:
+		req, err = http.NewRequest(http.MethodPost, reqURL.String(), strings.NewReader(form.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	default:
+		if len(form) > 0 {
+			query := reqURL.Query()
+			for name, fieldValues := range form {
+				query[name] = append(query[name], fieldValues...)
+			}
+			reqURL.RawQuery = query.Encode()
+		}
+		req, err = http.NewRequest(http.MethodGet, reqURL.String(), nil)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create request to<%s>", reqURL)
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s failed", req.Method, reqURL)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read response of %s %s", req.Method, reqURL)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, errors.Errorf("unexpected status code<%d> from %s %s", resp.StatusCode, req.Method, resp.Request.URL)
+	}
+
+	for _, extract := range step.Extract {
+		value, err := extract.from(body)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		values[extract.Name] = value
+	}
+
+	// redirects were followed, resolve next step relative to final response
+	return resp.Request.URL, nil
+}
+
+// from extract value from response body
+func (extract *FormExtract) from(body []byte) (string, error) {
+	switch {
+	case extract.Input != "":
+		value, ok := htmlInputValue(body, extract.Input)
+		if !ok {
+			return "", errors.Errorf("extract<%s> found no input<%s>", extract.Name, extract.Input)
+		}
+		return value, nil
+	case extract.Regex != "":
+		regex := extract.regex
+		if regex == nil {
+			// settings not validated
+			var err error
+			if regex, err = regexp.Compile(extract.Regex); err != nil {
+				return "", errors.Wrapf(err, "extract<%s> has invalid regex", extract.Name)
+			}
+		}
+		match := regex.FindSubmatch(body)
+		if match == nil {
+			return "", errors.Errorf("extract<%s> found no match of regex<%s>", extract.Name, extract.Regex)
+		}
+		if len(match) > 1 {
+			return string(match[1]), nil
+		}
+		return string(match[0]), nil
+	case extract.JSONPath != "":
+		value, dataType, _, err := jsonparser.Get(body, jsonPathKeys(extract.JSONPath)...)
+		if err != nil {
+			return "", errors.Wrapf(err, "extract<%s> found no value at jsonpath<%s>", extract.Name, extract.JSONPath)
+		}
+		if dataType == jsonparser.String {
+			str, err := jsonparser.ParseString(value)
+			return str, errors.Wrapf(err, "extract<%s> failed to parse string at jsonpath<%s>", extract.Name, extract.JSONPath)
+		}
+		return string(value), nil
+	default:
+		return "", errors.Errorf("extract<%s> has nothing to extract", extract.Name)
+	}
+}
+
+// htmlInputValue value of first HTML input element with name
+func htmlInputValue(body []byte, name string) (string, bool) {
+	for _, input := range htmlInputRegex.FindAll(body, -1) {
+		attributes := make(map[string]string)
+		for _, attribute := range htmlAttributeRegex.FindAllSubmatch(input, -1) {
+			attributes[strings.ToLower(string(attribute[1]))] = string(attribute[2]) + string(attribute[3]) + string(attribute[4])
+		}
+		if html.UnescapeString(attributes["name"]) == name {
+			return html.UnescapeString(attributes["value"]), true
+		}
+	}
+	return "", false
+}
+
+// jsonPathKeys keys of simple JSONPath in dot notation, e.g. "$.data.items[0].token" -> data, items, [0], token
+func jsonPathKeys(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.Replace(path, "[", ".[", -1)
+
+	var keys []string
+	for _, key := range strings.Split(path, ".") {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,159 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/qlik-oss/gopherciser/logger"
+	"github.com/qlik-oss/gopherciser/session"
+	"github.com/qlik-oss/gopherciser/users"
+)
+
+// newTestIdP stand-in identity provider with a login page protected by a hidden csrf field, which redirects to a
+// callback returning a JSON token, which in turn is exchanged for a session cookie
+func newTestIdP() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			http.SetCookie(w, &http.Cookie{Name: "idp", Value: "started", Path: "/"})
+			_, _ = fmt.Fprint(w, `<html><form method="post" action="/login?tenant=`+r.URL.Query().Get("tenant")+`">`+
+				`<input type="hidden" value="csrf&amp;123" name="csrf"/>`+
+				`<input type="text" name="username"></form></html>`)
+		case http.MethodPost:
+			if _, err := r.Cookie("idp"); err != nil {
+				http.Error(w, "no idp cookie", http.StatusForbidden)
+				return
+			}
+			if r.FormValue("csrf") != "csrf&123" || r.FormValue("username") != "user1" ||
+				r.FormValue("password") != "pw1" || r.URL.Query().Get("tenant") != "tenantA" {
+				http.Error(w, "invalid login", http.StatusUnauthorized)
+				return
+			}
+			http.Redirect(w, r, "/callback", http.StatusFound)
+		}
+	})
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"data":{"tokens":[{"token":"abc\/def"}]}}`)
+	})
+	mux.HandleFunc("/hub", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("token") != "abc/def" {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "X-Qlik-Session", Value: "mysession", Path: "/"})
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestFormConnection(t *testing.T) {
+	idp := newTestIdP()
+	defer idp.Close()
+
+	raw := fmt.Sprintf(`{
+		"server" : "127.0.0.1",
+		"port" : %d,
+		"mode" : "form",
+		"formsettings" : {
+			"steps" : [
+				{
+					"url" : "/login?tenant={{.Attributes.tenant}}",
+					"extract" : [
+						{ "name" : "csrf", "input" : "csrf" },
+						{ "name" : "action", "regex" : "action=\"([^\"]+)\"" }
+					]
+				},
+				{
+					"method" : "POST",
+					"url" : "{{.Local.action}}",
+					"form" : [
+						{ "name" : "csrf", "value" : "{{.Local.csrf}}" },
+						{ "name" : "username", "value" : "{{.UserName}}" },
+						{ "name" : "password", "value" : "{{.Password}}" }
+					],
+					"extract" : [
+						{ "name" : "token", "jsonpath" : "$.data.tokens[0].token" }
+					]
+				},
+				{
+					"url" : "/hub",
+					"form" : [
+						{ "name" : "token", "value" : "{{.Local.token}}" }
+					]
+				}
+			]
+		}
+	}`, idp.Listener.Addr().(*net.TCPAddr).Port)
+
+	var connection ConnectionSettings
+	if err := jsonit.Unmarshal([]byte(raw), &connection); err != nil {
+		t.Fatal("failed to unmarshal connectionsettings:", err)
+	}
+	if err := connection.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if connection.FormSettings.Steps[0].Extract[1].regex == nil {
+		t.Error("extract regex not compiled when validated")
+	}
+
+	user := &users.User{UserName: "user1", Password: "pw1", Attributes: map[string]string{"tenant": "tenantA"}}
+	state := session.New(context.Background(), "", time.Minute, user, 1, 1, "")
+	state.SetLogEntry(logger.NewLogEntry(&logger.Log{}))
+	state.LogEntry.Session = &logger.SessionEntry{}
+
+	if _, err := connection.GetHeaders(state); err != nil {
+		t.Fatal(err)
+	}
+
+	serverURL, _ := url.Parse(idp.URL)
+	cookies := state.Cookies.Cookies(serverURL)
+	found := false
+	for _, cookie := range cookies {
+		if cookie.Name == "X-Qlik-Session" && cookie.Value == "mysession" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("session cookie not set after login, cookies<%v>", cookies)
+	}
+
+	// wrong password fails login
+	user.Password = "wrong"
+	state = session.New(context.Background(), "", time.Minute, user, 2, 1, "")
+	state.SetLogEntry(logger.NewLogEntry(&logger.Log{}))
+	state.LogEntry.Session = &logger.SessionEntry{}
+	if _, err := connection.GetHeaders(state); err == nil {
+		t.Error("expected login to fail with wrong password")
+	}
+}
+
+func TestFormConnectionValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps string
+	}{
+		{"no steps", `[]`},
+		{"no url", `[{ "method" : "GET" }]`},
+		{"unsupported method", `[{ "method" : "DELETE", "url" : "/login" }]`},
+		{"extract without name", `[{ "url" : "/login", "extract" : [{ "input" : "csrf" }] }]`},
+		{"extract multiple", `[{ "url" : "/login", "extract" : [{ "name" : "csrf", "input" : "csrf", "regex" : "csrf" }] }]`},
+		{"invalid regex", `[{ "url" : "/login", "extract" : [{ "name" : "csrf", "regex" : "(" }] }]`},
+	}
+
+	for _, test := range tests {
+		var connection ConnectionSettings
+		raw := fmt.Sprintf(`{ "server" : "myhost", "mode" : "form", "formsettings" : { "steps" : %s } }`, test.steps)
+		if err := jsonit.Unmarshal([]byte(raw), &connection); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if err := connection.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", test.name)
+		}
+	}
+}
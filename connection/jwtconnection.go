@@ -7,10 +7,12 @@ import (
 	"net/http/cookiejar"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/pkg/errors"
 	"github.com/qlik-oss/gopherciser/enigmahandlers"
+	"github.com/qlik-oss/gopherciser/helpers"
 	"github.com/qlik-oss/gopherciser/session"
 )
 
@@ -41,15 +43,44 @@ type (
 		// "{\"exp\":{{(now.Add 18000000000000).Unix}}}"
 		Claims session.SyncedTemplate `json:"claims,omitempty" doc-key:"config.connectionSettings.jwtsettings.claims"`
 
-		// Alg is the signing method to be used for the JWT. Defaults to RS512 if omitted
+		// Alg is the signing method to be used for the JWT. Defaults to RS512 for RSA keys, ES256, ES384 or ES512
+		// depending on curve of EC keys and EdDSA for Ed25519 keys if omitted
 		Alg string `json:"alg,omitempty" doc-key:"config.connectionSettings.jwtsettings.alg"`
+		// JwksPath path to JSON Web Key Set with private signing keys, used instead of KeyPath
+		JwksPath string `json:"jwkspath,omitempty" doc-key:"config.connectionSettings.jwtsettings.jwkspath"`
+		// Kid key ID of JWKS key to sign with, processed as a GO template. Added as "kid" header.
+		Kid session.SyncedTemplate `json:"kid,omitempty" doc-key:"config.connectionSettings.jwtsettings.kid"`
+		// CacheToken re-use signed token of user until it's about to expire
+		CacheToken bool `json:"cachetoken,omitempty" doc-key:"config.connectionSettings.jwtsettings.cachetoken"`
+		// RefreshBefore time before expiry a token is refreshed, defaults to DefaultJWTRefreshBefore
+		RefreshBefore helpers.TimeDuration `json:"refreshbefore,omitempty" doc-key:"config.connectionSettings.jwtsettings.refreshbefore"`
 
 		// handle jwt private key
 		key     []byte
 		readKey sync.Once
+
+		// parsed signing keys, by kid when read from JWKS
+		loadKeys sync.Once
+		keys     map[string]*signingKey
+		keysErr  error
+
+		// signed tokens per user
+		tokensMu sync.Mutex
+		tokens   map[string]*signedToken
+	}
+
+	// signedToken signed JWT and its expiry, zero expiry when token doesn't expire
+	signedToken struct {
+		token   string
+		expires time.Time
 	}
 )
 
+const (
+	// DefaultJWTRefreshBefore time before expiry a JWT is refreshed
+	DefaultJWTRefreshBefore = time.Minute
+)
+
 // GetConnectFunc which establishes a connection to Qlik Sense
 func (connectJWT *ConnectJWTSettings) GetConnectFunc(sessionState *session.State, connection *ConnectionSettings, appGUID string, headers http.Header) func() (string, error) {
 	connectFunc := func() (string, error) {
@@ -80,7 +111,22 @@ func (connectJWT *ConnectJWTSettings) GetConnectFunc(sessionState *session.State
 				return appGUID, errors.Wrap(err, "failed creating cookie jar")
 			}
 		}
-		if err = sense.Connect(ctx, url, headers, sessionState.Cookies, connection.Allowuntrusted, sessionState.Timeout); err != nil {
+		tlsConfig, err := connection.TLSClientConfig(sessionState)
+		if err != nil {
+			return appGUID, errors.WithStack(err)
+		}
+		proxyURL, err := connection.websocketProxy(url)
+		if err != nil {
+			return appGUID, errors.WithStack(err)
+		}
+		if err = sense.ConnectWithOptions(ctx, url, headers, sessionState.Cookies, sessionState.Timeout, enigmahandlers.ConnectOptions{
+			TLSConfig:        tlsConfig,
+			ProxyURL:         proxyURL,
+			Network:          sessionState.Network,
+			Compression:      connection.Compression,
+			CompressionLevel: connection.CompressionLevel,
+			Reconnect:        connection.Reconnect,
+		}); err != nil {
 			return appGUID, errors.WithStack(err)
 		}
 
@@ -92,6 +138,15 @@ func (connectJWT *ConnectJWTSettings) GetConnectFunc(sessionState *session.State
 
 // Validate connectJWTSettings
 func (connectJWT *ConnectJWTSettings) Validate() error {
+	if connectJWT.RefreshBefore < 0 {
+		return errors.Errorf("refreshbefore<%v> can't be negative", connectJWT.RefreshBefore)
+	}
+
+	if connectJWT.JwksPath != "" {
+		_, err := connectJWT.signingKeys()
+		return errors.WithStack(err)
+	}
+
 	// Do we have a key? (if so, also read into memory)
 	key, err := connectJWT.getPrivateKey()
 	if err != nil {
@@ -106,10 +161,67 @@ func (connectJWT *ConnectJWTSettings) Validate() error {
 
 // GetJwtHeader get Authorization header
 func (connectJWT *ConnectJWTSettings) GetJwtHeader(sessionState *session.State, header http.Header) (http.Header, error) {
-	// Do we have a key? (then read into memory)
-	key, err := connectJWT.getPrivateKey()
+	header, _, err := connectJWT.jwtHeader(sessionState, header)
+	return header, errors.WithStack(err)
+}
+
+// jwtHeader set Authorization header, returns expiry of token
+func (connectJWT *ConnectJWTSettings) jwtHeader(sessionState *session.State, header http.Header) (http.Header, time.Time, error) {
+	token, err := connectJWT.getToken(sessionState)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Error reading private key from file<%s>", connectJWT.KeyPath)
+		return nil, time.Time{}, errors.WithStack(err)
+	}
+
+	// set request headers
+	if header == nil {
+		header = make(http.Header, 1)
+	}
+	header.Set("Authorization", fmt.Sprintf("Bearer %s", token.token))
+
+	return header, token.expires, nil
+}
+
+// refreshHeader re-signs JWT of header when within refresh time of its expiry, keeping the other headers
+func (connectJWT *ConnectJWTSettings) refreshHeader(sessionState *session.State) session.HeaderRefreshFunc {
+	return func(header http.Header, expires time.Time) (http.Header, time.Time, error) {
+		if !connectJWT.shouldRefresh(expires) {
+			return header, expires, nil
+		}
+		header, expires, err := connectJWT.jwtHeader(sessionState, header.Clone())
+		return header, expires, errors.Wrap(err, "failed to refresh JWT")
+	}
+}
+
+// getToken signed token of user, re-used from cache when caching tokens
+func (connectJWT *ConnectJWTSettings) getToken(sessionState *session.State) (*signedToken, error) {
+	if !connectJWT.CacheToken || sessionState.User == nil {
+		return connectJWT.signToken(sessionState)
+	}
+
+	cacheKey := fmt.Sprintf("%s\x00%s", sessionState.User.UserName, sessionState.User.Directory)
+	connectJWT.tokensMu.Lock()
+	defer connectJWT.tokensMu.Unlock()
+
+	if token := connectJWT.tokens[cacheKey]; token != nil && !connectJWT.shouldRefresh(token.expires) {
+		return token, nil
+	}
+
+	token, err := connectJWT.signToken(sessionState)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if connectJWT.tokens == nil {
+		connectJWT.tokens = make(map[string]*signedToken)
+	}
+	connectJWT.tokens[cacheKey] = token
+	return token, nil
+}
+
+// signToken sign a new token of user
+func (connectJWT *ConnectJWTSettings) signToken(sessionState *session.State) (*signedToken, error) {
+	key, err := connectJWT.signingKey(sessionState)
+	if err != nil {
+		return nil, errors.WithStack(err)
 	}
 
 	// replace variables in jwt claims and create token
@@ -119,13 +231,19 @@ func (connectJWT *ConnectJWTSettings) GetJwtHeader(sessionState *session.State,
 	}
 	alg := connectJWT.Alg
 	if alg == "" {
-		alg = "RS512"
+		alg = key.alg
+	}
+	if alg == "" {
+		alg = defaultAlg(key.key)
 	}
 	signingMethod := jwt.GetSigningMethod(alg)
 	if signingMethod == nil {
 		return nil, errors.Errorf("Unknown signing method<%s>", alg)
 	}
 	token := jwt.NewWithClaims(signingMethod, jwt.MapClaims(claims))
+	if key.kid != "" {
+		token.Header["kid"] = key.kid
+	}
 
 	// replace variables and set jwt headers
 	jwtHeader, errJwtHeader := connectJWT.executeJWTHeaderTemplates(sessionState)
@@ -137,18 +255,96 @@ func (connectJWT *ConnectJWTSettings) GetJwtHeader(sessionState *session.State,
 	}
 
 	// sign JWT
-	signedToken, err := GetSignedJwtToken(key, token)
+	signed, err := token.SignedString(key.key)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Error signing token with key from file<%s>", connectJWT.KeyPath)
+		return nil, errors.Wrapf(err, "Error signing token with key<%s>", connectJWT.keyName(key))
 	}
 
-	// set request headers
-	if header == nil {
-		header = make(http.Header, 1)
+	var expires time.Time
+	if exp, ok := claims["exp"].(float64); ok {
+		expires = time.Unix(int64(exp), 0)
+	}
+
+	return &signedToken{token: signed, expires: expires}, nil
+}
+
+// signingKey key to sign token of user with
+func (connectJWT *ConnectJWTSettings) signingKey(sessionState *session.State) (*signingKey, error) {
+	keys, err := connectJWT.signingKeys()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if connectJWT.JwksPath == "" {
+		return keys[""], nil
+	}
+
+	kid, err := sessionState.ReplaceSessionVariables(&connectJWT.Kid)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if kid == "" {
+		if len(keys) != 1 {
+			return nil, errors.Errorf("kid must be set to select one of %d keys in JWKS<%s>", len(keys), connectJWT.JwksPath)
+		}
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+	key, ok := keys[kid]
+	if !ok {
+		return nil, errors.Errorf("kid<%s> not found in JWKS<%s>", kid, connectJWT.JwksPath)
+	}
+	return key, nil
+}
+
+// signingKeys parsed private key from key file, or keys of JWKS file
+func (connectJWT *ConnectJWTSettings) signingKeys() (map[string]*signingKey, error) {
+	connectJWT.loadKeys.Do(func() {
+		if connectJWT.JwksPath != "" {
+			data, err := ioutil.ReadFile(connectJWT.JwksPath)
+			if err != nil {
+				connectJWT.keysErr = errors.Wrapf(err, "Error reading JWKS from file<%s>", connectJWT.JwksPath)
+				return
+			}
+			connectJWT.keys, connectJWT.keysErr = parseJWKS(data)
+			connectJWT.keysErr = errors.Wrapf(connectJWT.keysErr, "Error parsing JWKS from file<%s>", connectJWT.JwksPath)
+			return
+		}
+
+		key, err := connectJWT.getPrivateKey()
+		if err != nil {
+			connectJWT.keysErr = errors.WithStack(err)
+			return
+		}
+		privateKey, err := parsePrivateKey(key)
+		if err != nil {
+			connectJWT.keysErr = errors.Wrapf(err, "Error parsing private key from file<%s>", connectJWT.KeyPath)
+			return
+		}
+		connectJWT.keys = map[string]*signingKey{"": {key: privateKey}}
+	})
+	return connectJWT.keys, connectJWT.keysErr
+}
+
+// keyName of signing key used in errors
+func (connectJWT *ConnectJWTSettings) keyName(key *signingKey) string {
+	if connectJWT.JwksPath != "" {
+		return fmt.Sprintf("%s from JWKS<%s>", key.kid, connectJWT.JwksPath)
 	}
-	header.Set("Authorization", fmt.Sprintf("Bearer %s", signedToken))
+	return connectJWT.KeyPath
+}
 
-	return header, err
+// shouldRefresh token expiring at expires is within refresh time
+func (connectJWT *ConnectJWTSettings) shouldRefresh(expires time.Time) bool {
+	return !expires.IsZero() && time.Until(expires) <= connectJWT.refreshBefore()
+}
+
+func (connectJWT *ConnectJWTSettings) refreshBefore() time.Duration {
+	if connectJWT.RefreshBefore > 0 {
+		return time.Duration(connectJWT.RefreshBefore)
+	}
+	return DefaultJWTRefreshBefore
 }
 
 func parseAlgo(key []byte) (string, error) {
@@ -223,8 +419,10 @@ func GetSignedJwtToken(key []byte, token *jwt.Token) (string, error) {
 	var privKey interface{}
 	if err == nil && parsedKeyFormat == "EC" {
 		privKey, err = jwt.ParseECPrivateKeyFromPEM(key)
-	} else { // Key is either RSA *or* fallback
+	} else if err == nil && parsedKeyFormat == "RSA" {
 		privKey, err = jwt.ParseRSAPrivateKeyFromPEM(key)
+	} else { // PKCS8 key of any supported type
+		privKey, err = parsePrivateKey(key)
 	}
 
 	if err != nil {
@@ -0,0 +1,241 @@
+package connection
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+)
+
+type (
+	// SigningMethodEdDSA JWT signing method using Ed25519 keys
+	SigningMethodEdDSA struct{}
+
+	// signingKey private key used to sign JWT
+	signingKey struct {
+		key interface{}
+		// kid key ID, empty for key not read from JWKS
+		kid string
+		// alg signing method of key, empty when not given by JWK
+		alg string
+	}
+
+	// jsonWebKeySet JSON Web Key Set (RFC 7517)
+	jsonWebKeySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	// jsonWebKey private JSON Web Key of type RSA, EC or OKP (Ed25519)
+	jsonWebKey struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Alg string `json:"alg"`
+		Crv string `json:"crv"`
+		// RSA parameters
+		N string `json:"n"`
+		E string `json:"e"`
+		P string `json:"p"`
+		Q string `json:"q"`
+		// EC and OKP parameters
+		X string `json:"x"`
+		Y string `json:"y"`
+		// D private exponent of RSA key or private key of EC and OKP keys
+		D string `json:"d"`
+	}
+)
+
+var (
+	// EdDSA signing method
+	EdDSA = &SigningMethodEdDSA{}
+)
+
+func init() {
+	jwt.RegisterSigningMethod(EdDSA.Alg(), func() jwt.SigningMethod {
+		return EdDSA
+	})
+}
+
+// Alg implements jwt.SigningMethod interface
+func (method *SigningMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+// Sign implements jwt.SigningMethod interface
+func (method *SigningMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
+
+// Verify implements jwt.SigningMethod interface
+func (method *SigningMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return errors.New("EdDSA verification failed")
+	}
+	return nil
+}
+
+// parsePrivateKey parse PEM encoded RSA, EC or PKCS8 (RSA, EC or Ed25519) private key
+func parsePrivateKey(key []byte) (interface{}, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, jwt.ErrKeyMustBePEMEncoded
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		return privateKey, errors.WithStack(err)
+	case "EC PRIVATE KEY":
+		privateKey, err := x509.ParseECPrivateKey(block.Bytes)
+		return privateKey, errors.WithStack(err)
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	switch privateKey.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return privateKey, nil
+	default:
+		return nil, errors.Errorf("unsupported private key type<%T>", privateKey)
+	}
+}
+
+// defaultAlg signing method used with key when not set
+func defaultAlg(key interface{}) string {
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		switch k.Curve.Params().BitSize {
+		case 384:
+			return jwt.SigningMethodES384.Alg()
+		case 521:
+			return jwt.SigningMethodES512.Alg()
+		default:
+			return jwt.SigningMethodES256.Alg()
+		}
+	case ed25519.PrivateKey:
+		return EdDSA.Alg()
+	default:
+		return jwt.SigningMethodRS512.Alg()
+	}
+}
+
+// parseJWKS parse private keys of JSON Web Key Set
+func parseJWKS(data []byte) (map[string]*signingKey, error) {
+	var set jsonWebKeySet
+	if err := jsonit.Unmarshal(data, &set); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal JWKS")
+	}
+	if len(set.Keys) < 1 {
+		return nil, errors.New("no keys in JWKS")
+	}
+
+	keys := make(map[string]*signingKey, len(set.Keys))
+	for i, jwk := range set.Keys {
+		key, err := jwk.privateKey()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse key<%d> kid<%s> of JWKS", i, jwk.Kid)
+		}
+		if _, exists := keys[jwk.Kid]; exists {
+			return nil, errors.Errorf("kid<%s> used by several keys in JWKS", jwk.Kid)
+		}
+		keys[jwk.Kid] = &signingKey{key: key, kid: jwk.Kid, alg: jwk.Alg}
+	}
+	return keys, nil
+}
+
+// privateKey of JWK
+func (jwk *jsonWebKey) privateKey() (interface{}, error) {
+	if jwk.D == "" {
+		return nil, errors.New("not a private key")
+	}
+
+	switch jwk.Kty {
+	case "RSA":
+		ints, err := decodeInts(jwk.N, jwk.E, jwk.D, jwk.P, jwk.Q)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		n, e, d, p, q := ints[0], ints[1], ints[2], ints[3], ints[4]
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: int(e.Int64())},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		if err := key.Validate(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		key.Precompute()
+		return key, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("unsupported curve<%s>", jwk.Crv)
+		}
+		ints, err := decodeInts(jwk.X, jwk.Y, jwk.D)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		x, y, d := ints[0], ints[1], ints[2]
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC public key")
+		}
+		return &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y}, D: d}, nil
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, errors.Errorf("unsupported curve<%s>", jwk.Crv)
+		}
+		seed, err := base64.RawURLEncoding.DecodeString(jwk.D)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if len(seed) != ed25519.SeedSize {
+			return nil, errors.Errorf("invalid Ed25519 private key size<%d>", len(seed))
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	default:
+		return nil, errors.Errorf("unsupported key type<%s>", jwk.Kty)
+	}
+}
+
+// decodeInts decode base64url encoded big endian integers of JWK
+func decodeInts(values ...string) ([]*big.Int, error) {
+	ints := make([]*big.Int, len(values))
+	for i, value := range values {
+		if value == "" {
+			return nil, errors.New("missing key parameter")
+		}
+		data, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		ints[i] = new(big.Int).SetBytes(data)
+	}
+	return ints, nil
+}
@@ -0,0 +1,392 @@
+package connection
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/qlik-oss/gopherciser/action"
+	"github.com/qlik-oss/gopherciser/helpers"
+	"github.com/qlik-oss/gopherciser/logger"
+	"github.com/qlik-oss/gopherciser/session"
+	"github.com/qlik-oss/gopherciser/users"
+)
+
+// testKeys generated private keys by name
+func testKeys(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ec256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ec384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]crypto.Signer{"RS512": rsaKey, "ES256": ec256Key, "ES384": ec384Key, "EdDSA": edKey}
+}
+
+// testState session state of user
+func testState(ctx context.Context, userName string) *session.State {
+	state := session.New(ctx, "", 0, &users.User{UserName: userName, Directory: "dir"}, 1, 1, "")
+	state.LogEntry = &logger.LogEntry{
+		Session: &logger.SessionEntry{},
+		Action:  &logger.ActionEntry{},
+	}
+	return state
+}
+
+// verifyToken verify signature, alg and kid of signed token
+func verifyToken(t *testing.T, signed string, key crypto.Signer, alg, kid string) {
+	t.Helper()
+	token, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+		return key.Public(), nil
+	})
+	if err != nil {
+		t.Fatalf("alg<%s>: %v", alg, err)
+	}
+	if token.Method.Alg() != alg {
+		t.Errorf("expected alg<%s> got<%s>", alg, token.Method.Alg())
+	}
+	if tokenKid, _ := token.Header["kid"].(string); tokenKid != kid {
+		t.Errorf("alg<%s>: expected kid<%s> got<%s>", alg, kid, tokenKid)
+	}
+}
+
+func TestSigningKeyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwtkeys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	claims, err := session.NewSyncedTemplate(`{"user":"{{.UserName}}"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := testState(context.Background(), "user_1")
+
+	for alg, key := range testKeys(t) {
+		// EC keys in SEC 1 format, others in PKCS8
+		block := &pem.Block{Type: "PRIVATE KEY"}
+		if ecKey, ok := key.(*ecdsa.PrivateKey); ok {
+			block.Type = "EC PRIVATE KEY"
+			block.Bytes, err = x509.MarshalECPrivateKey(ecKey)
+		} else {
+			block.Bytes, err = x509.MarshalPKCS8PrivateKey(key)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyPath := filepath.Join(dir, alg+".pem")
+		if err := ioutil.WriteFile(keyPath, pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		settings := ConnectJWTSettings{KeyPath: keyPath, Claims: *claims}
+		if err := settings.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		token, err := settings.signToken(state)
+		if err != nil {
+			t.Fatalf("alg<%s>: %v", alg, err)
+		}
+		verifyToken(t, token.token, key, alg, "")
+	}
+}
+
+// jwk private JSON Web Key of key
+func jwk(t *testing.T, kid string, key crypto.Signer) map[string]string {
+	t.Helper()
+	encode := func(i *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(i.Bytes())
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return map[string]string{"kty": "RSA", "kid": kid, "n": encode(k.N), "e": encode(big.NewInt(int64(k.E))),
+			"d": encode(k.D), "p": encode(k.Primes[0]), "q": encode(k.Primes[1])}
+	case *ecdsa.PrivateKey:
+		return map[string]string{"kty": "EC", "kid": kid, "crv": k.Curve.Params().Name, "x": encode(k.X),
+			"y": encode(k.Y), "d": encode(k.D)}
+	case ed25519.PrivateKey:
+		return map[string]string{"kty": "OKP", "kid": kid, "crv": "Ed25519",
+			"x": base64.RawURLEncoding.EncodeToString(k.Public().(ed25519.PublicKey)),
+			"d": base64.RawURLEncoding.EncodeToString(k.Seed())}
+	}
+	t.Fatalf("unexpected key type<%T>", key)
+	return nil
+}
+
+func TestJWKS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	keys := testKeys(t)
+	set := map[string][]map[string]string{"keys": nil}
+	for alg, key := range keys {
+		set["keys"] = append(set["keys"], jwk(t, "key-"+alg, key))
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwksPath := filepath.Join(dir, "jwks.json")
+	if err := ioutil.WriteFile(jwksPath, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	kid, err := session.NewSyncedTemplate("key-{{.UserName}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := ConnectJWTSettings{JwksPath: jwksPath, Kid: *kid}
+	if err := settings.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for alg, key := range keys {
+		token, err := settings.signToken(testState(context.Background(), alg))
+		if err != nil {
+			t.Fatalf("alg<%s>: %v", alg, err)
+		}
+		verifyToken(t, token.token, key, alg, "key-"+alg)
+	}
+
+	if _, err := settings.signToken(testState(context.Background(), "unknown")); err == nil {
+		t.Error("expected error signing with unknown kid")
+	}
+}
+
+func TestJWTCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwtcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(dir, "key.pem")
+	if err := ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		lifetime time.Duration
+		cached   bool
+	}{
+		{time.Hour, true},
+		{30 * time.Second, false}, // expires within default refresh time
+	} {
+		claims, err := session.NewSyncedTemplate(fmt.Sprintf(`{"user":"{{.UserName}}","jti":"{{uuid}}","exp":{{(now.Add %d).Unix}}}`, test.lifetime))
+		if err != nil {
+			t.Fatal(err)
+		}
+		settings := ConnectJWTSettings{KeyPath: keyPath, Claims: *claims, CacheToken: true}
+
+		first, err := settings.getToken(testState(context.Background(), "user_1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := settings.getToken(testState(context.Background(), "user_1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		other, err := settings.getToken(testState(context.Background(), "user_2"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if (first.token == second.token) != test.cached {
+			t.Errorf("lifetime<%v>: expected token cached<%v>", test.lifetime, test.cached)
+		}
+		if other.token == first.token {
+			t.Errorf("lifetime<%v>: token of other user re-used", test.lifetime)
+		}
+		if until := time.Until(first.expires); until > test.lifetime || until < test.lifetime-5*time.Second {
+			t.Errorf("lifetime<%v>: unexpected expiry<%v>", test.lifetime, first.expires)
+		}
+	}
+}
+
+// refreshJWTSettings settings signing tokens expiring in 3s, refreshed 1s before expiry, with key written to dir
+func refreshJWTSettings(t *testing.T, dir string) *ConnectJWTSettings {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(dir, "key.pem")
+	if err := ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := session.NewSyncedTemplate(`{"jti":"{{uuid}}","exp":{{(now.Add 3000000000).Unix}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &ConnectJWTSettings{
+		KeyPath:       keyPath,
+		Claims:        *claims,
+		RefreshBefore: helpers.TimeDuration(time.Second),
+	}
+}
+
+func TestJWTRefresh(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwtrefresh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	connectionSettings := ConnectionSettings{
+		Mode:        JWT,
+		Server:      "myhost",
+		JwtSettings: refreshJWTSettings(t, dir),
+		Headers:     map[string]string{"X-Custom": "custom"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	state := testState(ctx, "user_1")
+	header, err := connectionSettings.GetHeaders(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, expires := state.HeaderJar.GetHeaderWithExpiry("myhost")
+	if expires.IsZero() {
+		t.Fatal("expiry of JWT not stored with header")
+	}
+
+	cached, err := connectionSettings.GetHeaders(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.Get("Authorization") != header.Get("Authorization") {
+		t.Error("token refreshed before refresh time")
+	}
+
+	// wait until within refresh time of expiry
+	time.Sleep(time.Until(expires) - time.Second + 100*time.Millisecond)
+	refreshed, err := connectionSettings.GetHeaders(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.Get("Authorization") == header.Get("Authorization") {
+		t.Error("token not refreshed before expiry")
+	}
+	if !strings.HasPrefix(refreshed.Get("Authorization"), "Bearer ") || refreshed.Get("X-Custom") != "custom" {
+		t.Errorf("unexpected refreshed header<%v>", refreshed)
+	}
+	if stored := state.HeaderJar.GetHeader("myhost"); stored.Get("Authorization") != refreshed.Get("Authorization") {
+		t.Error("refreshed header not stored in header jar")
+	}
+}
+
+func TestJWTRefreshRest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwtrefreshrest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	var (
+		authorizations []string
+		mu             sync.Mutex
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	connectionSettings := ConnectionSettings{
+		Mode:        JWT,
+		Server:      "127.0.0.1",
+		JwtSettings: refreshJWTSettings(t, dir),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	state := testState(ctx, "user_1")
+	state.SetLogEntry(state.LogEntry)
+	header, err := connectionSettings.GetHeaders(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, expires := state.HeaderJar.GetHeaderWithExpiry("127.0.0.1")
+
+	get := func() {
+		t.Helper()
+		actionState := &action.State{}
+		request := &session.RestRequest{Method: session.GET, Destination: server.URL}
+		state.Rest.QueueRequest(actionState, true, request, state.LogEntry)
+		state.Rest.WaitForPending()
+		if actionState.Errors() != nil {
+			t.Fatal(actionState.Errors())
+		}
+	}
+
+	get()
+	// wait until within refresh time of expiry
+	time.Sleep(time.Until(expires) - time.Second + 100*time.Millisecond)
+	get()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(authorizations) != 2 {
+		t.Fatalf("expected 2 requests, got<%d>", len(authorizations))
+	}
+	if authorizations[0] != header.Get("Authorization") {
+		t.Error("first request not using token of session")
+	}
+	if authorizations[1] == authorizations[0] || !strings.HasPrefix(authorizations[1], "Bearer ") {
+		t.Errorf("token of request within refresh time not refreshed, got<%s>", authorizations[1])
+	}
+	if stored := state.HeaderJar.GetHeader("127.0.0.1"); stored.Get("Authorization") != authorizations[1] {
+		t.Error("refreshed header not stored in header jar")
+	}
+}
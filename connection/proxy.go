@@ -0,0 +1,144 @@
+package connection
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// ProxySettings proxy used for websocket and REST traffic
+	ProxySettings struct {
+		// URL of proxy, scheme http or https for HTTP CONNECT proxy and socks5 for SOCKS5 proxy
+		URL string `json:"url,omitempty" doc-key:"config.connectionSettings.proxy.url"`
+		// User proxy authentication user, overrides user of URL
+		User string `json:"user,omitempty" doc-key:"config.connectionSettings.proxy.user"`
+		// Password proxy authentication password
+		Password string `json:"password,omitempty" doc-key:"config.connectionSettings.proxy.password"`
+		// NoProxy hosts, domains, IP addresses or CIDR ranges connected to directly
+		NoProxy []string `json:"noproxy,omitempty" doc-key:"config.connectionSettings.proxy.noproxy"`
+		// FromEnvironment use proxy from HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables instead of URL
+		FromEnvironment bool `json:"fromenvironment,omitempty" doc-key:"config.connectionSettings.proxy.fromenvironment"`
+	}
+)
+
+// ProxyURL proxy to use for target, nil for direct connection. Implements session.ConnectionSettings interface.
+func (connectionSettings *ConnectionSettings) ProxyURL(target *url.URL) (*url.URL, error) {
+	if connectionSettings.Proxy == nil {
+		// without proxy settings REST requests use proxy from environment, websocket connects directly
+		if target.Scheme == "http" || target.Scheme == "https" {
+			return http.ProxyFromEnvironment(&http.Request{URL: target})
+		}
+		return nil, nil
+	}
+	return connectionSettings.Proxy.proxyURL(target)
+}
+
+// websocketProxy proxy to use for websocket URL, nil for direct connection
+func (connectionSettings *ConnectionSettings) websocketProxy(wsURL string) (*url.URL, error) {
+	target, err := url.Parse(wsURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse url<%s>", wsURL)
+	}
+	return connectionSettings.ProxyURL(target)
+}
+
+// Validate proxy settings
+func (proxy *ProxySettings) Validate() error {
+	if proxy.FromEnvironment {
+		if proxy.URL != "" {
+			return errors.New("proxy url can not be combined with fromenvironment")
+		}
+	} else if _, err := proxy.parseURL(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, entry := range proxy.NoProxy {
+		if strings.Contains(entry, "/") {
+			if _, _, err := net.ParseCIDR(strings.TrimSpace(entry)); err != nil {
+				return errors.Wrapf(err, "invalid noproxy entry<%s>", entry)
+			}
+		}
+	}
+	return nil
+}
+
+func (proxy *ProxySettings) proxyURL(target *url.URL) (*url.URL, error) {
+	if proxy.noProxy(target.Hostname()) {
+		return nil, nil
+	}
+
+	if !proxy.FromEnvironment {
+		proxyURL, err := proxy.parseURL()
+		return proxyURL, errors.WithStack(err)
+	}
+
+	// environment variables are defined per http or https scheme
+	envTarget := *target
+	switch target.Scheme {
+	case "ws":
+		envTarget.Scheme = "http"
+	case "wss":
+		envTarget.Scheme = "https"
+	}
+	proxyURL, err := http.ProxyFromEnvironment(&http.Request{URL: &envTarget})
+	if err != nil || proxyURL == nil {
+		return nil, errors.Wrap(err, "failed to get proxy from environment")
+	}
+	proxy.setUser(proxyURL)
+	return proxyURL, nil
+}
+
+// parseURL parse and validate proxy URL, with authentication user set
+func (proxy *ProxySettings) parseURL() (*url.URL, error) {
+	if proxy.URL == "" {
+		return nil, errors.New("proxy requires url or fromenvironment")
+	}
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse proxy url<%s>", proxy.URL)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, errors.Errorf("unsupported proxy scheme<%s>, must be http, https or socks5", proxyURL.Scheme)
+	}
+	if proxyURL.Hostname() == "" {
+		return nil, errors.Errorf("proxy url<%s> has no host", proxy.URL)
+	}
+	proxy.setUser(proxyURL)
+	return proxyURL, nil
+}
+
+func (proxy *ProxySettings) setUser(proxyURL *url.URL) {
+	if proxy.User != "" {
+		proxyURL.User = url.UserPassword(proxy.User, proxy.Password)
+	}
+}
+
+// noProxy host matches no proxy list, entries match host itself and its sub domains
+func (proxy *ProxySettings) noProxy(host string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+	for _, entry := range proxy.NoProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+		case entry == "*":
+			return true
+		case strings.Contains(entry, "/"):
+			if _, network, err := net.ParseCIDR(entry); err == nil && ip != nil && network.Contains(ip) {
+				return true
+			}
+		default:
+			entry = strings.TrimPrefix(strings.TrimPrefix(entry, "*"), ".")
+			if host == entry || strings.HasSuffix(host, "."+entry) {
+				return true
+			}
+		}
+	}
+	return false
+}
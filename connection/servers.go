@@ -0,0 +1,222 @@
+package connection
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/enummap"
+	"github.com/qlik-oss/gopherciser/randomizer"
+	"github.com/qlik-oss/gopherciser/users"
+)
+
+type (
+	// ServerStrategy strategy for selecting server node of session
+	ServerStrategy int
+
+	// ServerNode server of a multi-node deployment
+	ServerNode struct {
+		// Name of node used in summary and metrics, defaults to server
+		Name string `json:"name,omitempty" doc-key:"config.connectionSettings.servers.name"`
+		// Server remote host of node
+		Server string `json:"server" doc-key:"config.connectionSettings.servers.server"`
+		// Weight of node, defaults to 1
+		Weight int `json:"weight,omitempty" doc-key:"config.connectionSettings.servers.weight"`
+		// Port of node, defaults to port of connection settings
+		Port int `json:"port,omitempty" doc-key:"config.connectionSettings.servers.port"`
+		// VirtualProxy of node, defaults to virtual proxy of connection settings. By making this a pointer it can
+		// explicitly be set to an empty string.
+		VirtualProxy *string `json:"virtualproxy,omitempty" doc-key:"config.connectionSettings.servers.virtualproxy"`
+	}
+
+	// serverNodes connection settings of each server node
+	serverNodes struct {
+		settings []*ConnectionSettings
+		weights  []int
+		total    uint64
+		counter  uint64
+	}
+)
+
+const (
+	// RoundRobin new sessions connect to nodes in turn, in proportion to node weights
+	RoundRobin ServerStrategy = iota
+	// WeightedRandom new sessions connect to a random node using node weights
+	WeightedRandom
+	// StickyUser sessions of a user always connect to the same node, users are distributed using node weights
+	StickyUser
+)
+
+func (value ServerStrategy) GetEnumMap() *enummap.EnumMap {
+	enumMap, _ := enummap.NewEnumMap(map[string]int{
+		"roundrobin": int(RoundRobin),
+		"weighted":   int(WeightedRandom),
+		"sticky":     int(StickyUser),
+	})
+	return enumMap
+}
+
+// UnmarshalJSON unmarshal ServerStrategy
+func (value *ServerStrategy) UnmarshalJSON(arg []byte) error {
+	i, err := value.GetEnumMap().UnMarshal(arg)
+	if err != nil {
+		return errors.Wrap(err, "Failed to unmarshal ServerStrategy")
+	}
+
+	*value = ServerStrategy(i)
+	return nil
+}
+
+// MarshalJSON marshal ServerStrategy type
+func (value ServerStrategy) MarshalJSON() ([]byte, error) {
+	str, err := value.GetEnumMap().String(int(value))
+	if err != nil {
+		return nil, errors.Errorf("Unknown ServerStrategy<%d>", value)
+	}
+	return []byte(fmt.Sprintf(`"%s"`, str)), nil
+}
+
+// NodeName name of server node, server host including port when port is set and no name is defined
+func (node *ServerNode) NodeName() string {
+	if node.Name != "" {
+		return node.Name
+	}
+	if node.Port > 0 {
+		return node.Server + ":" + strconv.Itoa(node.Port)
+	}
+	return node.Server
+}
+
+func (node *ServerNode) weight() int {
+	if node.Weight < 1 {
+		return 1
+	}
+	return node.Weight
+}
+
+// Node name of server node of connection settings returned by SessionSettings, empty when using a single server
+func (connectionSettings *ConnectionSettings) Node() string {
+	return connectionSettings.node
+}
+
+// SessionSettings connection settings of server node selected for a new session according to server strategy.
+// Without servers defined the connection settings themselves are returned.
+func (connectionSettings *ConnectionSettings) SessionSettings(user *users.User, instance, session uint64) (*ConnectionSettings, error) {
+	if len(connectionSettings.Servers) < 1 {
+		return connectionSettings, nil
+	}
+
+	nodes := connectionSettings.serverNodes()
+
+	var pos uint64
+	switch connectionSettings.ServerStrategy {
+	case RoundRobin:
+		pos = (atomic.AddUint64(&nodes.counter, 1) - 1) % nodes.total
+	case WeightedRandom:
+		rnd := randomizer.NewSeededRandomizer(randomizer.GetPredictableSeedUInt64(instance, session))
+		i, err := rnd.RandWeightedInt(nodes.weights)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return nodes.settings[i], nil
+	case StickyUser:
+		hash := fnv.New64a()
+		if user != nil {
+			_, _ = hash.Write([]byte(user.Directory + "\\" + user.UserName))
+		}
+		pos = hash.Sum64() % nodes.total
+	default:
+		return nil, errors.Errorf("Unknown server strategy<%d>", connectionSettings.ServerStrategy)
+	}
+
+	for i, weight := range nodes.weights {
+		if pos < uint64(weight) {
+			return nodes.settings[i], nil
+		}
+		pos -= uint64(weight)
+	}
+	return nil, errors.Errorf("failed to select server node at position<%d>", pos)
+}
+
+// NodeSettings connection settings of each server node, or the connection settings themselves without servers defined
+func (connectionSettings *ConnectionSettings) NodeSettings() []*ConnectionSettings {
+	if len(connectionSettings.Servers) < 1 {
+		return []*ConnectionSettings{connectionSettings}
+	}
+	return connectionSettings.serverNodes().settings
+}
+
+// serverNodes create connection settings of server nodes once
+func (connectionSettings *ConnectionSettings) serverNodes() *serverNodes {
+	connectionSettings.loadNodes.Do(func() {
+		nodes := &serverNodes{
+			settings: make([]*ConnectionSettings, 0, len(connectionSettings.Servers)),
+			weights:  make([]int, 0, len(connectionSettings.Servers)),
+		}
+		for i := range connectionSettings.Servers {
+			node := &connectionSettings.Servers[i]
+			nodes.settings = append(nodes.settings, connectionSettings.withNode(node))
+			nodes.weights = append(nodes.weights, node.weight())
+			nodes.total += uint64(node.weight())
+		}
+		connectionSettings.nodes = nodes
+	})
+	return connectionSettings.nodes
+}
+
+// withNode copy of connection settings connecting to server node
+func (connectionSettings *ConnectionSettings) withNode(node *ServerNode) *ConnectionSettings {
+	nodeSettings := &ConnectionSettings{}
+
+	// copy exported settings, unexported fields are caches and synchronization specific to each instance
+	src := reflect.ValueOf(connectionSettings).Elem()
+	dst := reflect.ValueOf(nodeSettings).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		if src.Type().Field(i).PkgPath == "" {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+
+	nodeSettings.Servers = nil
+	nodeSettings.Server = node.Server
+	if node.Port > 0 {
+		nodeSettings.Port = node.Port
+	}
+	if node.VirtualProxy != nil {
+		nodeSettings.VirtualProxy = *node.VirtualProxy
+	}
+	nodeSettings.node = node.NodeName()
+
+	return nodeSettings
+}
+
+// validateServers validate server nodes
+func (connectionSettings *ConnectionSettings) validateServers() error {
+	if len(connectionSettings.Servers) < 1 {
+		return nil
+	}
+	if connectionSettings.Server != "" {
+		return errors.New("server and servers can not be combined")
+	}
+	if _, err := connectionSettings.ServerStrategy.GetEnumMap().String(int(connectionSettings.ServerStrategy)); err != nil {
+		return errors.Errorf("Unknown server strategy<%d>", connectionSettings.ServerStrategy)
+	}
+
+	names := make(map[string]struct{}, len(connectionSettings.Servers))
+	for i, node := range connectionSettings.Servers {
+		if node.Server == "" {
+			return errors.Errorf("server node<%d> has no server", i)
+		}
+		if node.Weight < 0 {
+			return errors.Errorf("server node<%s> has negative weight", node.NodeName())
+		}
+		if _, exists := names[node.NodeName()]; exists {
+			return errors.Errorf("server node name<%s> used more than once", node.NodeName())
+		}
+		names[node.NodeName()] = struct{}{}
+	}
+	return nil
+}
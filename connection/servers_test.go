@@ -0,0 +1,126 @@
+package connection
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/qlik-oss/gopherciser/users"
+)
+
+func TestSessionSettings(t *testing.T) {
+	raw := `{
+		"mode" : "ws",
+		"virtualproxy" : "vp",
+		"security" : true,
+		"headers" : {
+			"X-Test" : "test"
+		},
+		"servers" : [
+			{ "server" : "node1.example.com", "weight" : 2 },
+			{ "server" : "node2.example.com", "port" : 4747, "virtualproxy" : "" },
+			{ "name" : "node3", "server" : "node3.example.com", "virtualproxy" : "other" }
+		]
+	}`
+
+	var connection ConnectionSettings
+	if err := jsonit.Unmarshal([]byte(raw), &connection); err != nil {
+		t.Fatal("failed to unmarshal connectionsettings:", err)
+	}
+	if err := connection.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// round robin in proportion to weight
+	expected := []string{"node1.example.com", "node1.example.com", "node2.example.com:4747", "node3", "node1.example.com"}
+	for i, node := range expected {
+		settings, err := connection.SessionSettings(nil, 1, uint64(i+1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if settings.Node() != node {
+			t.Errorf("session<%d> connected to node<%s>, expected<%s>", i+1, settings.Node(), node)
+		}
+	}
+
+	nodes := connection.NodeSettings()
+	if len(nodes) != 3 {
+		t.Fatalf("unexpected amount of node settings<%d>", len(nodes))
+	}
+	for i, test := range []struct {
+		server, virtualProxy string
+		port                 int
+	}{
+		{"node1.example.com", "vp", 0},
+		{"node2.example.com", "", 4747},
+		{"node3.example.com", "other", 0},
+	} {
+		if nodes[i].Server != test.server || nodes[i].VirtualProxy != test.virtualProxy || nodes[i].Port != test.port {
+			t.Errorf("unexpected node<%d> settings server<%s> virtualproxy<%s> port<%d>", i, nodes[i].Server,
+				nodes[i].VirtualProxy, nodes[i].Port)
+		}
+		if !nodes[i].Security || nodes[i].Headers["X-Test"] != "test" || len(nodes[i].Servers) != 0 {
+			t.Errorf("node<%d> settings not copied from connection settings", i)
+		}
+	}
+
+	// sessions of the same user connect to the same node
+	connection.ServerStrategy = StickyUser
+	connected := make(map[string]int)
+	for i := 0; i < 30; i++ {
+		user := &users.User{UserName: fmt.Sprintf("user%d", i%10)}
+		first, err := connection.SessionSettings(user, 1, uint64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := connection.SessionSettings(user, 1, uint64(i+100))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first != second {
+			t.Errorf("user<%s> connected to node<%s> and node<%s>", user.UserName, first.Node(), second.Node())
+		}
+		connected[first.Node()]++
+	}
+	if len(connected) < 2 {
+		t.Errorf("expected users to be spread over nodes, connected<%v>", connected)
+	}
+
+	// weighted random is predictable per instance and session
+	connection.ServerStrategy = WeightedRandom
+	for i := uint64(1); i < 10; i++ {
+		first, err := connection.SessionSettings(nil, 1, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := connection.SessionSettings(nil, 1, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first != second {
+			t.Errorf("session<%d> connected to node<%s> and node<%s>", i, first.Node(), second.Node())
+		}
+	}
+
+	// single server
+	single := &ConnectionSettings{Server: "myhost.com"}
+	settings, err := single.SessionSettings(nil, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings != single || settings.Node() != "" {
+		t.Error("expected connection settings without servers to be used as is")
+	}
+
+	invalid := []ConnectionSettings{
+		{Mode: WS, Server: "myhost.com", Servers: []ServerNode{{Server: "node1"}}},
+		{Mode: WS, Servers: []ServerNode{{Server: ""}}},
+		{Mode: WS, Servers: []ServerNode{{Server: "node1", Weight: -1}}},
+		{Mode: WS, Servers: []ServerNode{{Server: "node1"}, {Server: "node1"}}},
+		{Mode: WS, Servers: []ServerNode{{Server: "node1"}}, ServerStrategy: ServerStrategy(10)},
+	}
+	for i := range invalid {
+		if err := invalid[i].Validate(); err == nil {
+			t.Errorf("expected validation error of servers<%+v>", invalid[i].Servers)
+		}
+	}
+}
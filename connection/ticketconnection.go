@@ -0,0 +1,285 @@
+package connection
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/session"
+)
+
+type (
+	// ConnectTicketSettings app and server settings using a ticket from the Qlik Sense Proxy Service ticket API
+	ConnectTicketSettings struct {
+		// CertPath path to client certificate used to authenticate towards the ticket API
+		CertPath string `json:"certpath" doc-key:"config.connectionSettings.ticketsettings.certpath"`
+		// KeyPath path to private key of client certificate
+		KeyPath string `json:"keypath" doc-key:"config.connectionSettings.ticketsettings.keypath"`
+		// RootCAPath path to root certificate used to verify the proxy, connection CA bundle or system root
+		// certificates are used if omitted
+		RootCAPath string `json:"rootcapath,omitempty" doc-key:"config.connectionSettings.ticketsettings.rootcapath"`
+		// ProxyPort port of ticket API, defaults to 4243
+		ProxyPort int `json:"proxyport,omitempty" doc-key:"config.connectionSettings.ticketsettings.proxyport"`
+		// Path which the ticket is redeemed at, relative to virtual proxy, defaults to "hub/"
+		Path string `json:"path,omitempty" doc-key:"config.connectionSettings.ticketsettings.path"`
+
+		// client certificate and root certificates
+		tlsConfig *tls.Config
+		loadCerts sync.Once
+		certsErr  error
+
+		// ticket API client
+		client     *http.Client
+		loadClient sync.Once
+	}
+
+	ticketRequest struct {
+		UserDirectory string              `json:"UserDirectory"`
+		UserID        string              `json:"UserId"`
+		Attributes    []map[string]string `json:"Attributes"`
+	}
+
+	ticketResponse struct {
+		Ticket string `json:"Ticket"`
+	}
+)
+
+const (
+	// DefaultTicketProxyPort default port of Qlik Sense Proxy Service ticket API
+	DefaultTicketProxyPort = 4243
+	// DefaultTicketPath default path to redeem ticket at
+	DefaultTicketPath = "hub/"
+
+	xrfKeyChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// GetConnectFunc connect websocket using session cookie from redeemed ticket
+func (connectTicket *ConnectTicketSettings) GetConnectFunc(sessionState *session.State, connection *ConnectionSettings, appGUID string, header http.Header) func() (string, error) {
+	return (&ConnectWsSettings{}).GetConnectFunc(sessionState, connection, appGUID, header)
+}
+
+// Validate ticket settings and load client certificate
+func (connectTicket *ConnectTicketSettings) Validate() error {
+	if connectTicket == nil || connectTicket.CertPath == "" || connectTicket.KeyPath == "" {
+		return errors.New("ticket mode requires certpath and keypath")
+	}
+	if connectTicket.ProxyPort < 0 {
+		return errors.Errorf("invalid proxyport<%d>", connectTicket.ProxyPort)
+	}
+	_, err := connectTicket.getTLSConfig()
+	return errors.WithStack(err)
+}
+
+// Login request ticket for session user and redeem it, setting session cookie in session cookie jar
+func (connectTicket *ConnectTicketSettings) Login(sessionState *session.State, connection *ConnectionSettings) error {
+	if sessionState.User == nil {
+		return errors.New("ticket mode requires a user")
+	}
+
+	tlsConfig, err := connectTicket.getTLSConfig()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	rootCAs, err := connection.getRootCAs()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	connectTicket.loadClient.Do(func() {
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.InsecureSkipVerify = connection.Allowuntrusted
+		if tlsConfig.RootCAs == nil {
+			tlsConfig.RootCAs = rootCAs
+		}
+		connectTicket.client = &http.Client{
+			Transport: &http.Transport{
+				Proxy: func(req *http.Request) (*url.URL, error) {
+					return connection.ProxyURL(req.URL)
+				},
+				TLSClientConfig: tlsConfig,
+				IdleConnTimeout: 90 * time.Second,
+			},
+		}
+	})
+
+	ticket, err := connectTicket.requestTicket(sessionState, connection)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(connectTicket.redeemTicket(sessionState, connection, ticket))
+}
+
+// requestTicket from ticket API for session user
+func (connectTicket *ConnectTicketSettings) requestTicket(sessionState *session.State, connection *ConnectionSettings) (string, error) {
+	host, err := connection.GetHost()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	xrfKey := newXrfKey(sessionState)
+	ticketURL := fmt.Sprintf("https://%s:%d/qps/%sticket?xrfkey=%s", host, connectTicket.proxyPort(),
+		virtualProxyPath(connection.VirtualProxy), xrfKey)
+
+	body, err := jsonit.Marshal(ticketRequest{
+		UserDirectory: sessionState.User.Directory,
+		UserID:        sessionState.User.UserName,
+		Attributes:    []map[string]string{},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal ticket request")
+	}
+
+	ctx, cancel := sessionState.ContextWithTimeout(sessionState.BaseContext())
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodPost, ticketURL, bytes.NewReader(body))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create ticket request to<%s>", ticketURL)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Qlik-Xrfkey", xrfKey)
+
+	resp, err := connectTicket.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", errors.Wrapf(err, "ticket request to<%s> failed", ticketURL)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read ticket response")
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("unexpected status code<%d> from ticket API for user<%s\\%s>", resp.StatusCode,
+			sessionState.User.Directory, sessionState.User.UserName)
+	}
+
+	var ticket ticketResponse
+	if err := jsonit.Unmarshal(respBody, &ticket); err != nil {
+		return "", errors.Wrapf(err, "failed to unmarshal ticket response<%s>", respBody)
+	}
+	if ticket.Ticket == "" {
+		return "", errors.Errorf("no ticket in ticket response<%s>", respBody)
+	}
+	return ticket.Ticket, nil
+}
+
+// redeemTicket attach ticket to hub URL, session cookie is set in session cookie jar
+func (connectTicket *ConnectTicketSettings) redeemTicket(sessionState *session.State, connection *ConnectionSettings, ticket string) error {
+	if sessionState.Cookies == nil {
+		var err error
+		sessionState.Cookies, err = cookiejar.New(nil)
+		if err != nil {
+			return errors.Wrap(err, "failed creating cookie jar")
+		}
+	}
+
+	client, err := session.DefaultClient(connection, sessionState)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	restURL, err := connection.GetRestUrl()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	redeemURL := fmt.Sprintf("%s/%s%s?qlikTicket=%s", restURL, virtualProxyPath(connection.VirtualProxy),
+		strings.TrimPrefix(connectTicket.path(), "/"), url.QueryEscape(ticket))
+
+	ctx, cancel := sessionState.ContextWithTimeout(sessionState.BaseContext())
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, redeemURL, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create request to<%s>", redeemURL)
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "failed to redeem ticket")
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if _, err := ioutil.ReadAll(resp.Body); err != nil {
+		return errors.Wrap(err, "failed to read response of redeemed ticket")
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("unexpected status code<%d> redeeming ticket", resp.StatusCode)
+	}
+	return nil
+}
+
+// getTLSConfig load client certificate and root certificates once
+func (connectTicket *ConnectTicketSettings) getTLSConfig() (*tls.Config, error) {
+	connectTicket.loadCerts.Do(func() {
+		connectTicket.tlsConfig, connectTicket.certsErr = connectTicket.loadTLSConfig()
+	})
+	return connectTicket.tlsConfig, connectTicket.certsErr
+}
+
+func (connectTicket *ConnectTicketSettings) loadTLSConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(connectTicket.CertPath, connectTicket.KeyPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load client certificate<%s> with key<%s>", connectTicket.CertPath,
+			connectTicket.KeyPath)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+	if connectTicket.RootCAPath != "" {
+		rootCA, err := ioutil.ReadFile(connectTicket.RootCAPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read root certificate<%s>", connectTicket.RootCAPath)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(rootCA) {
+			return nil, errors.Errorf("no certificate found in root certificate<%s>", connectTicket.RootCAPath)
+		}
+	}
+	return tlsConfig, nil
+}
+
+func (connectTicket *ConnectTicketSettings) proxyPort() int {
+	if connectTicket.ProxyPort < 1 {
+		return DefaultTicketProxyPort
+	}
+	return connectTicket.ProxyPort
+}
+
+func (connectTicket *ConnectTicketSettings) path() string {
+	if connectTicket.Path == "" {
+		return DefaultTicketPath
+	}
+	return connectTicket.Path
+}
+
+// virtualProxyPath virtual proxy with trailing slash, or empty string without virtual proxy
+func virtualProxyPath(virtualProxy string) string {
+	virtualProxy = strings.Trim(virtualProxy, "/")
+	if virtualProxy == "" {
+		return ""
+	}
+	return virtualProxy + "/"
+}
+
+// newXrfKey random 16 character cross-site request forgery key
+func newXrfKey(sessionState *session.State) string {
+	rnd := sessionState.Randomizer()
+	key := make([]byte, 16)
+	for i := range key {
+		key[i] = xrfKeyChars[rnd.Rand(len(xrfKeyChars))]
+	}
+	return string(key)
+}
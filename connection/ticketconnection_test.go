@@ -0,0 +1,150 @@
+package connection
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qlik-oss/gopherciser/logger"
+	"github.com/qlik-oss/gopherciser/session"
+	"github.com/qlik-oss/gopherciser/users"
+)
+
+// writeTestClientCert self-signed client certificate and key, written as <name>.pem and <name>_key.pem
+func writeTestClientCert(t *testing.T, dir, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPath := filepath.Join(dir, name+".pem")
+	keyPath := filepath.Join(dir, name+"_key.pem")
+	if err := ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+func TestTicketConnection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ticket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	certPath, keyPath := writeTestClientCert(t, dir, "client")
+
+	// stand-in proxy service ticket API, requiring client certificate
+	qps := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ticketRequest
+		if err := jsonit.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.URL.Path != "/qps/vp/ticket" || r.URL.Query().Get("xrfkey") != r.Header.Get("X-Qlik-Xrfkey") ||
+			len(r.Header.Get("X-Qlik-Xrfkey")) != 16 || len(r.TLS.PeerCertificates) < 1 {
+			http.Error(w, "invalid ticket request", http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, `{"UserDirectory":"%s","UserId":"%s","Ticket":"ticket-%s"}`, req.UserDirectory, req.UserID, req.UserID)
+	}))
+	qps.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	qps.StartTLS()
+	defer qps.Close()
+
+	// stand-in proxy, setting session cookie for valid ticket
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/vp/hub/" || r.URL.Query().Get("qlikTicket") != "ticket-user1" {
+			http.Error(w, "invalid ticket", http.StatusForbidden)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "X-Qlik-Session-vp", Value: "mysession", Path: "/"})
+	}))
+	defer proxy.Close()
+
+	raw := fmt.Sprintf(`{
+		"server" : "127.0.0.1",
+		"port" : %d,
+		"mode" : "ticket",
+		"virtualproxy" : "vp",
+		"allowuntrusted" : true,
+		"ticketsettings" : {
+			"certpath" : "%s",
+			"keypath" : "%s",
+			"proxyport" : %d
+		}
+	}`, proxy.Listener.Addr().(*net.TCPAddr).Port, filepath.ToSlash(certPath), filepath.ToSlash(keyPath),
+		qps.Listener.Addr().(*net.TCPAddr).Port)
+
+	var connection ConnectionSettings
+	if err := jsonit.Unmarshal([]byte(raw), &connection); err != nil {
+		t.Fatal("failed to unmarshal connectionsettings:", err)
+	}
+	if err := connection.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	user := &users.User{UserName: "user1", Directory: "mydir"}
+	state := session.New(context.Background(), "", time.Minute, user, 1, 1, "vp")
+	state.SetLogEntry(logger.NewLogEntry(&logger.Log{}))
+	state.LogEntry.Session = &logger.SessionEntry{}
+
+	if _, err := connection.GetHeaders(state); err != nil {
+		t.Fatal(err)
+	}
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	cookies := state.Cookies.Cookies(proxyURL)
+	if len(cookies) != 1 || cookies[0].Value != "mysession" {
+		t.Errorf("session cookie not set after redeeming ticket, cookies<%v>", cookies)
+	}
+
+	// invalid ticket fails login
+	state = session.New(context.Background(), "", time.Minute, &users.User{UserName: "user2"}, 2, 1, "vp")
+	state.SetLogEntry(logger.NewLogEntry(&logger.Log{}))
+	state.LogEntry.Session = &logger.SessionEntry{}
+	if _, err := connection.GetHeaders(state); err == nil {
+		t.Error("expected ticket login to fail")
+	}
+
+	connection.TicketSettings = &ConnectTicketSettings{CertPath: filepath.Join(dir, "missing.pem"), KeyPath: keyPath}
+	if err := connection.Validate(); err == nil {
+		t.Error("expected validation error on missing certificate")
+	}
+}
@@ -0,0 +1,127 @@
+package connection
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/session"
+)
+
+type (
+	// clientCertificates client certificates loaded per certificate and key path
+	clientCertificates struct {
+		mu           sync.Mutex
+		certificates map[clientCertificateKey]*clientCertificateEntry
+	}
+
+	clientCertificateKey struct {
+		certPath string
+		keyPath  string
+	}
+
+	clientCertificateEntry struct {
+		load sync.Once
+		cert tls.Certificate
+		err  error
+	}
+)
+
+// TLSClientConfig TLS configuration of websocket and REST connections for session user, with client certificate and
+// CA bundle when configured. Implements session.ConnectionSettings interface.
+func (connectionSettings *ConnectionSettings) TLSClientConfig(state *session.State) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: connectionSettings.Allowuntrusted,
+	}
+
+	rootCAs, err := connectionSettings.getRootCAs()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	tlsConfig.RootCAs = rootCAs
+
+	if connectionSettings.ClientCertPath.String() == "" {
+		return tlsConfig, nil
+	}
+
+	certPath, err := state.ReplaceSessionVariables(&connectionSettings.ClientCertPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to execute client certificate path template")
+	}
+	keyPath, err := state.ReplaceSessionVariables(&connectionSettings.ClientKeyPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to execute client key path template")
+	}
+
+	cert, err := connectionSettings.clientCerts.get(certPath, keyPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	tlsConfig.Certificates = []tls.Certificate{cert}
+
+	return tlsConfig, nil
+}
+
+// validateTLS validate client certificate settings and read CA bundle
+func (connectionSettings *ConnectionSettings) validateTLS() error {
+	if (connectionSettings.ClientCertPath.String() == "") != (connectionSettings.ClientKeyPath.String() == "") {
+		return errors.New("clientcertpath and clientkeypath need to be set together")
+	}
+	_, err := connectionSettings.getRootCAs()
+	return errors.WithStack(err)
+}
+
+// getRootCAs system root certificates and certificates of CA bundle, nil uses system root certificates
+func (connectionSettings *ConnectionSettings) getRootCAs() (*x509.CertPool, error) {
+	if connectionSettings.CAPath == "" {
+		return nil, nil
+	}
+
+	connectionSettings.loadRootCAs.Do(func() {
+		var bundle []byte
+		bundle, connectionSettings.rootCAsErr = ioutil.ReadFile(connectionSettings.CAPath)
+		if connectionSettings.rootCAsErr != nil {
+			connectionSettings.rootCAsErr = errors.Wrapf(connectionSettings.rootCAsErr, "failed to read CA bundle<%s>",
+				connectionSettings.CAPath)
+			return
+		}
+
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil || rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(bundle) {
+			connectionSettings.rootCAsErr = errors.Errorf("no certificates found in CA bundle<%s>",
+				connectionSettings.CAPath)
+			return
+		}
+		connectionSettings.rootCAs = rootCAs
+	})
+
+	return connectionSettings.rootCAs, connectionSettings.rootCAsErr
+}
+
+// get client certificate, loaded once per certificate and key path
+func (certs *clientCertificates) get(certPath, keyPath string) (tls.Certificate, error) {
+	certs.mu.Lock()
+	if certs.certificates == nil {
+		certs.certificates = make(map[clientCertificateKey]*clientCertificateEntry)
+	}
+	key := clientCertificateKey{certPath, keyPath}
+	entry, ok := certs.certificates[key]
+	if !ok {
+		entry = &clientCertificateEntry{}
+		certs.certificates[key] = entry
+	}
+	certs.mu.Unlock()
+
+	entry.load.Do(func() {
+		entry.cert, entry.err = tls.LoadX509KeyPair(certPath, keyPath)
+		if entry.err != nil {
+			entry.err = errors.Wrapf(entry.err, "failed to load client certificate<%s> with key<%s>", certPath, keyPath)
+		}
+	})
+	return entry.cert, entry.err
+}
@@ -0,0 +1,95 @@
+package connection
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qlik-oss/gopherciser/logger"
+	"github.com/qlik-oss/gopherciser/session"
+	"github.com/qlik-oss/gopherciser/users"
+)
+
+func TestTLSClientConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	writeTestClientCert(t, dir, "user1")
+
+	// server requiring client certificate, with common name of client certificate as response
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	// private CA bundle with the self-signed server certificate
+	caPath := filepath.Join(dir, "ca.pem")
+	if err := ioutil.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := fmt.Sprintf(`{
+		"server" : "127.0.0.1",
+		"mode" : "ws",
+		"security" : true,
+		"clientcertpath" : "%[1]s/{{.UserName}}.pem",
+		"clientkeypath" : "%[1]s/{{.UserName}}_key.pem",
+		"capath" : "%[2]s"
+	}`, filepath.ToSlash(dir), filepath.ToSlash(caPath))
+
+	var connection ConnectionSettings
+	if err := jsonit.Unmarshal([]byte(raw), &connection); err != nil {
+		t.Fatal("failed to unmarshal connectionsettings:", err)
+	}
+	if err := connection.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	newState := func(userName string) *session.State {
+		state := session.New(context.Background(), "", time.Minute, &users.User{UserName: userName}, 1, 1, "")
+		state.SetLogEntry(logger.NewLogEntry(&logger.Log{}))
+		state.LogEntry.Session = &logger.SessionEntry{}
+		return state
+	}
+
+	client, err := session.DefaultClient(&connection, newState("user1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "user1" {
+		t.Errorf("unexpected client certificate<%s>, expected<user1>", body)
+	}
+
+	// user without certificate
+	if _, err := session.DefaultClient(&connection, newState("user2")); err == nil {
+		t.Error("expected error on missing client certificate of user2")
+	}
+
+	connection.ClientKeyPath = session.SyncedTemplate{}
+	if err := connection.Validate(); err == nil {
+		t.Error("expected validation error with certificate path but no key path")
+	}
+}
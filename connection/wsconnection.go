@@ -43,7 +43,23 @@ func (connectWs *ConnectWsSettings) GetConnectFunc(sessionState *session.State,
 			}
 		}
 
-		if err := sense.Connect(sessionState.BaseContext(), url, header, sessionState.Cookies, connection.Allowuntrusted, sessionState.Timeout); err != nil {
+		tlsConfig, err := connection.TLSClientConfig(sessionState)
+		if err != nil {
+			return appGUID, errors.WithStack(err)
+		}
+		proxyURL, err := connection.websocketProxy(url)
+		if err != nil {
+			return appGUID, errors.WithStack(err)
+		}
+
+		if err := sense.ConnectWithOptions(sessionState.BaseContext(), url, header, sessionState.Cookies, sessionState.Timeout, enigmahandlers.ConnectOptions{
+			TLSConfig:        tlsConfig,
+			ProxyURL:         proxyURL,
+			Network:          sessionState.Network,
+			Compression:      connection.Compression,
+			CompressionLevel: connection.CompressionLevel,
+			Reconnect:        connection.Reconnect,
+		}); err != nil {
 			return appGUID, errors.Wrap(err, "Failed connecting to sense server")
 		}
 		return appGUID, nil
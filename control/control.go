@@ -0,0 +1,223 @@
+package control
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/globals"
+)
+
+type (
+	// UsersController scheduler able to change its concurrent users while executing
+	UsersController interface {
+		// ConcurrentUsers current target of concurrent users
+		ConcurrentUsers() int
+		// SetConcurrentUsers change target of concurrent users, users are added or removed in between iterations
+		SetConcurrentUsers(users int)
+	}
+
+	// Status of execution
+	Status struct {
+		// State one of StateRunning, StatePaused or StateStopping
+		State string `json:"state"`
+		// ConcurrentUsers target of concurrent users, omitted when scheduler doesn't support changing it
+		ConcurrentUsers *int   `json:"concurrentusers,omitempty"`
+		ActiveUsers     uint64 `json:"activeusers"`
+		Threads         uint64 `json:"threads"`
+		Sessions        uint64 `json:"sessions"`
+		Errors          uint64 `json:"errors"`
+		Warnings        uint64 `json:"warnings"`
+		Actions         uint64 `json:"actions"`
+		Requests        uint64 `json:"requests"`
+	}
+
+	state struct {
+		mu       sync.Mutex
+		paused   bool
+		resume   chan struct{}
+		stopping bool
+		stop     chan struct{}
+		users    UsersController
+	}
+)
+
+// Execution states
+const (
+	StateRunning  = "running"
+	StatePaused   = "paused"
+	StateStopping = "stopping"
+)
+
+// ErrUsersNotSupported scheduler does not support changing concurrent users
+var ErrUsersNotSupported = errors.New("scheduler does not support changing concurrent users")
+
+var current = newState()
+
+func newState() *state {
+	return &state{
+		resume: make(chan struct{}),
+		stop:   make(chan struct{}),
+	}
+}
+
+// Reset control state to running, used e.g. by tests
+func Reset() {
+	current.mu.Lock()
+	defer current.mu.Unlock()
+
+	if current.paused {
+		close(current.resume)
+	}
+	current.paused = false
+	current.resume = make(chan struct{})
+	current.stopping = false
+	current.stop = make(chan struct{})
+	current.users = nil
+}
+
+// Pause starting new iterations, ongoing iterations are finished
+func Pause() {
+	current.mu.Lock()
+	defer current.mu.Unlock()
+
+	if current.stopping {
+		return
+	}
+	current.paused = true
+}
+
+// Resume starting new iterations
+func Resume() {
+	current.mu.Lock()
+	defer current.mu.Unlock()
+
+	if !current.paused {
+		return
+	}
+	current.paused = false
+	close(current.resume)
+	current.resume = make(chan struct{})
+}
+
+// Stop execution gracefully, no new iterations are started and ongoing iterations are finished
+func Stop() {
+	current.mu.Lock()
+	defer current.mu.Unlock()
+
+	if current.stopping {
+		return
+	}
+	current.stopping = true
+	close(current.stop)
+	if current.paused {
+		current.paused = false
+		close(current.resume)
+		current.resume = make(chan struct{})
+	}
+}
+
+// Stopping reports if a graceful stop has been requested
+func Stopping() bool {
+	current.mu.Lock()
+	defer current.mu.Unlock()
+	return current.stopping
+}
+
+// WaitWhilePaused blocks while execution is paused, until resumed, stopped or ctx is done. Returns true if execution
+// was paused.
+func WaitWhilePaused(ctx context.Context) bool {
+	current.mu.Lock()
+	paused := current.paused
+	resume := current.resume
+	current.mu.Unlock()
+
+	if !paused {
+		return false
+	}
+
+	select {
+	case <-resume:
+	case <-ctx.Done():
+	}
+	return true
+}
+
+// WithStop context which is done when ctx is done or a graceful stop is requested. Intended for waits in between
+// starting users, ongoing iterations should keep using ctx to be allowed to finish.
+func WithStop(ctx context.Context) (context.Context, context.CancelFunc) {
+	current.mu.Lock()
+	stop := current.stop
+	current.mu.Unlock()
+
+	stopCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-stopCtx.Done():
+		}
+	}()
+	return stopCtx, cancel
+}
+
+// SetUsersController set controller of concurrent users of executing scheduler, returns function removing it
+func SetUsersController(users UsersController) (remove func()) {
+	current.mu.Lock()
+	defer current.mu.Unlock()
+
+	current.users = users
+	return func() {
+		current.mu.Lock()
+		defer current.mu.Unlock()
+		if current.users == users {
+			current.users = nil
+		}
+	}
+}
+
+// SetConcurrentUsers change target of concurrent users of executing scheduler
+func SetConcurrentUsers(users int) error {
+	if users < 0 {
+		return errors.Errorf("invalid concurrent users<%d>", users)
+	}
+
+	current.mu.Lock()
+	controller := current.users
+	current.mu.Unlock()
+
+	if controller == nil {
+		return ErrUsersNotSupported
+	}
+	controller.SetConcurrentUsers(users)
+	return nil
+}
+
+// GetStatus current status of execution
+func GetStatus() Status {
+	current.mu.Lock()
+	status := Status{State: StateRunning}
+	switch {
+	case current.stopping:
+		status.State = StateStopping
+	case current.paused:
+		status.State = StatePaused
+	}
+	controller := current.users
+	current.mu.Unlock()
+
+	if controller != nil {
+		users := controller.ConcurrentUsers()
+		status.ConcurrentUsers = &users
+	}
+
+	status.ActiveUsers = globals.ActiveUsers.Current()
+	status.Threads = globals.Threads.Current()
+	status.Sessions = globals.Sessions.Current()
+	status.Errors = globals.Errors.Current()
+	status.Warnings = globals.Warnings.Current()
+	status.Actions = globals.ActionID.Current()
+	status.Requests = globals.Requests.Current()
+
+	return status
+}
@@ -0,0 +1,146 @@
+package control
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testUsers struct {
+	users int
+}
+
+func (users *testUsers) ConcurrentUsers() int {
+	return users.users
+}
+
+func (users *testUsers) SetConcurrentUsers(n int) {
+	users.users = n
+}
+
+func TestPauseResume(t *testing.T) {
+	defer Reset()
+
+	if WaitWhilePaused(context.Background()) {
+		t.Error("unexpected pause")
+	}
+
+	Pause()
+	waitDone := make(chan bool)
+	go func() {
+		waitDone <- WaitWhilePaused(context.Background())
+	}()
+
+	select {
+	case <-waitDone:
+		t.Fatal("wait returned while paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	Resume()
+	select {
+	case paused := <-waitDone:
+		if !paused {
+			t.Error("expected wait to report pause")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wait not done after resume")
+	}
+}
+
+func TestStop(t *testing.T) {
+	defer Reset()
+
+	ctx, cancel := WithStop(context.Background())
+	defer cancel()
+
+	Pause()
+	Stop()
+	if !Stopping() {
+		t.Error("expected stopping")
+	}
+	if WaitWhilePaused(context.Background()) {
+		t.Error("expected stop to resume paused execution")
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("stop context not done")
+	}
+
+	Pause()
+	if status := GetStatus(); status.State != StateStopping {
+		t.Errorf("expected state<%s> got<%s>", StateStopping, status.State)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	defer Reset()
+
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	request := func(method, path, body string) (int, Status) {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		raw, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var status Status
+		if resp.StatusCode == http.StatusOK {
+			if err := jsonit.Unmarshal(raw, &status); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode, status
+	}
+
+	if code, status := request(http.MethodGet, PathStatus, ""); code != http.StatusOK || status.State != StateRunning || status.ConcurrentUsers != nil {
+		t.Errorf("unexpected status code<%d> status<%+v>", code, status)
+	}
+	if code, _ := request(http.MethodGet, PathPause, ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("expected GET pause to fail, got code<%d>", code)
+	}
+	if code, status := request(http.MethodPost, PathPause, ""); code != http.StatusOK || status.State != StatePaused {
+		t.Errorf("unexpected pause code<%d> status<%+v>", code, status)
+	}
+	if code, status := request(http.MethodPost, PathResume, ""); code != http.StatusOK || status.State != StateRunning {
+		t.Errorf("unexpected resume code<%d> status<%+v>", code, status)
+	}
+
+	if code, _ := request(http.MethodPost, PathUsers, `{"users":5}`); code != http.StatusConflict {
+		t.Errorf("expected users to conflict without users controller, got code<%d>", code)
+	}
+
+	users := &testUsers{users: 2}
+	remove := SetUsersController(users)
+	if code, _ := request(http.MethodPost, PathUsers, `{"users":-1}`); code != http.StatusBadRequest {
+		t.Errorf("expected negative users to fail, got code<%d>", code)
+	}
+	code, status := request(http.MethodPost, PathUsers, `{"users":5}`)
+	if code != http.StatusOK || status.ConcurrentUsers == nil || *status.ConcurrentUsers != 5 || users.users != 5 {
+		t.Errorf("unexpected users code<%d> status<%+v>", code, status)
+	}
+	remove()
+
+	if code, status := request(http.MethodPost, PathStop, ""); code != http.StatusOK || status.State != StateStopping {
+		t.Errorf("unexpected stop code<%d> status<%+v>", code, status)
+	}
+}
@@ -0,0 +1,125 @@
+package control
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Endpoints of control API, all endpoints respond with Status
+const (
+	// PathStatus GET status of execution
+	PathStatus = "/control/status"
+	// PathPause POST pause starting new iterations
+	PathPause = "/control/pause"
+	// PathResume POST resume starting new iterations
+	PathResume = "/control/resume"
+	// PathStop POST stop execution gracefully
+	PathStop = "/control/stop"
+	// PathUsers POST change concurrent users, body UsersRequest
+	PathUsers = "/control/users"
+)
+
+type (
+	// UsersRequest body of request changing concurrent users
+	UsersRequest struct {
+		Users int `json:"users"`
+	}
+)
+
+var jsonit = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Register control API endpoints on mux
+func Register(mux *http.ServeMux) {
+	mux.HandleFunc(PathStatus, handleStatus)
+	mux.HandleFunc(PathPause, handleCommand(Pause))
+	mux.HandleFunc(PathResume, handleCommand(Resume))
+	mux.HandleFunc(PathStop, handleCommand(Stop))
+	mux.HandleFunc(PathUsers, handleUsers)
+}
+
+// Handler serving control API
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	Register(mux)
+	return mux
+}
+
+// Serve control API on port until ctx is done
+func Serve(ctx context.Context, port int) {
+	srv := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: Handler()}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			_, _ = fmt.Fprintf(os.Stderr, "Control API: ListenAndServe() error: %s\n", err)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		if err := srv.Shutdown(context.Background()); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Control API: Shutdown() error: %s\n", err)
+		}
+	}()
+}
+
+func handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeStatus(w)
+}
+
+func handleCommand(command func()) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		command()
+		writeStatus(w)
+	}
+}
+
+func handleUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request: %v", err), http.StatusBadRequest)
+		return
+	}
+	var request UsersRequest
+	if err := jsonit.Unmarshal(body, &request); err != nil {
+		http.Error(w, fmt.Sprintf("failed to unmarshal request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := SetConcurrentUsers(request.Users); err != nil {
+		status := http.StatusBadRequest
+		if err == ErrUsersNotSupported {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeStatus(w)
+}
+
+func writeStatus(w http.ResponseWriter) {
+	raw, err := jsonit.Marshal(GetStatus())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to marshal status: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(raw)
+}
@@ -0,0 +1,382 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/config"
+	"github.com/qlik-oss/gopherciser/helpers"
+	"github.com/qlik-oss/gopherciser/logger"
+	"github.com/qlik-oss/gopherciser/scheduler"
+	"github.com/qlik-oss/gopherciser/statistics"
+)
+
+type (
+	// Controller splits the load of a config across registered workers and merges statistics reported by workers
+	// into the global statistics of this process.
+	Controller struct {
+		// StartDelay time in between last worker registered and start of execution
+		StartDelay time.Duration
+		// StopTimeout max time to wait for workers to report result after controller was stopped
+		StopTimeout time.Duration
+
+		assignments []Assignment
+
+		mu            sync.Mutex
+		workers       []*workerState
+		startAt       time.Time
+		allRegistered chan struct{}
+		allDone       chan struct{}
+		stop          chan struct{}
+		stopOnce      sync.Once
+
+		logEntry *logger.LogEntry
+	}
+
+	workerState struct {
+		hostname string
+		stats    Stats
+		result   *Result
+	}
+)
+
+const (
+	// DefaultStartDelay default time in between last worker registered and start of execution
+	DefaultStartDelay = 2 * time.Second
+	// DefaultStopTimeout default max time to wait for workers to report result after controller was stopped
+	DefaultStopTimeout = time.Minute
+)
+
+// NewController controller splitting the scheduler of cfg into parts for workers. rawConfig is the config JSON as read
+// from file, it's sent as is to workers as marshaling cfg would mask passwords. templateData is used to expand the
+// log filename, which gets the worker instance number added to not collide when running workers on the same host.
+// Stop conditions on action results are evaluated by the workers and removed from cfg, which keeps the stop
+// conditions evaluated by the controller.
+func NewController(cfg *config.Config, rawConfig []byte, workers int, configName string, templateData interface{}) (*Controller, error) {
+	if workers < 1 {
+		return nil, errors.Errorf("invalid amount of workers<%d>", workers)
+	}
+
+	distributable, ok := cfg.Scheduler.(scheduler.DistributableScheduler)
+	if !ok {
+		return nil, errors.Errorf("scheduler<%T> does not support distributed execution", cfg.Scheduler)
+	}
+	parts, err := distributable.Distribute(workers)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	logFile, err := cfg.Settings.LogSettings.FileName.ReplaceWithoutSessionVariables(templateData)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to expand log filename")
+	}
+
+	_, cfg.Settings.StopConditions = splitStopConditions(cfg.Settings.StopConditions)
+
+	controller := &Controller{
+		StartDelay:    DefaultStartDelay,
+		StopTimeout:   DefaultStopTimeout,
+		assignments:   make([]Assignment, 0, len(parts)),
+		allRegistered: make(chan struct{}),
+		allDone:       make(chan struct{}),
+		stop:          make(chan struct{}),
+	}
+
+	for i, part := range parts {
+		rawScheduler, err := jsonit.Marshal(part)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal scheduler for worker<%d>", i)
+		}
+		instance := scheduler.InstanceNumber(part)
+		controller.assignments = append(controller.assignments, Assignment{
+			Instance:   instance,
+			Config:     rawConfig,
+			Scheduler:  rawScheduler,
+			ConfigName: configName,
+			LogFile:    workerLogFile(logFile, instance),
+		})
+	}
+
+	return controller, nil
+}
+
+// workerLogFile add instance to log filename, e.g. "logs/test.tsv" becomes "logs/test-worker2.tsv"
+func workerLogFile(filename string, instance uint64) string {
+	if filename == "" {
+		return ""
+	}
+	ext := filepath.Ext(filename)
+	return fmt.Sprintf("%s-worker%d%s", strings.TrimSuffix(filename, ext), instance, ext)
+}
+
+// Handler serving requests from workers
+func (controller *Controller) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathRegister, controller.handleRegister)
+	mux.HandleFunc(PathAssignment, controller.handleAssignment)
+	mux.HandleFunc(PathStats, controller.handleStats)
+	mux.HandleFunc(PathDone, controller.handleDone)
+	return mux
+}
+
+// Run wait for all workers to register, start execution and wait for all workers to report result. Statistics
+// reported by workers are added to global counters and statistics while running. Run is compatible with
+// config.ExecuteWith.
+func (controller *Controller) Run(ctx context.Context, log *logger.Log) error {
+	controller.mu.Lock()
+	controller.logEntry = log.NewLogEntry()
+	controller.mu.Unlock()
+
+	select {
+	case <-controller.allRegistered:
+	case <-ctx.Done():
+		controller.Stop()
+		return errors.Errorf("controller stopped while waiting for workers to register, registered<%d> expected<%d>",
+			controller.registered(), len(controller.assignments))
+	}
+
+	select {
+	case <-controller.allDone:
+	case <-ctx.Done():
+		controller.Stop()
+		select {
+		case <-controller.allDone:
+		case <-time.After(controller.StopTimeout):
+			return errors.Errorf("workers<%s> did not report result within %v of controller being stopped",
+				strings.Join(controller.pendingWorkers(), ","), controller.StopTimeout)
+		}
+	}
+
+	controller.mu.Lock()
+	defer controller.mu.Unlock()
+
+	// a worker stopped by a stop condition stopped all workers
+	for i, worker := range controller.workers {
+		if worker.result.StopCondition {
+			return config.StopConditionError(fmt.Sprintf("worker<%d> host<%s> instance<%d>: %s",
+				i, worker.hostname, controller.assignments[i].Instance, worker.result.Error))
+		}
+	}
+
+	var mErr *multierror.Error
+	for i, worker := range controller.workers {
+		if worker.result.Error != "" {
+			mErr = multierror.Append(mErr, errors.Errorf("worker<%d> host<%s> instance<%d>: %s",
+				i, worker.hostname, controller.assignments[i].Instance, worker.result.Error))
+		}
+	}
+	return errors.WithStack(helpers.FlattenMultiError(mErr))
+}
+
+// Stop request workers to stop execution
+func (controller *Controller) Stop() {
+	controller.stopOnce.Do(func() {
+		close(controller.stop)
+	})
+}
+
+func (controller *Controller) stopped() bool {
+	select {
+	case <-controller.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (controller *Controller) registered() int {
+	controller.mu.Lock()
+	defer controller.mu.Unlock()
+	return len(controller.workers)
+}
+
+func (controller *Controller) pendingWorkers() []string {
+	controller.mu.Lock()
+	defer controller.mu.Unlock()
+
+	pending := make([]string, 0, len(controller.workers))
+	for i, worker := range controller.workers {
+		if worker.result == nil {
+			pending = append(pending, fmt.Sprintf("%d(%s)", i, worker.hostname))
+		}
+	}
+	return pending
+}
+
+func (controller *Controller) worker(id int) (*workerState, error) {
+	if id < 0 || id >= len(controller.workers) {
+		return nil, errors.Errorf("unknown worker<%d>", id)
+	}
+	return controller.workers[id], nil
+}
+
+// logInfo should only be called while holding controller.mu
+func (controller *Controller) logInfo(infoType, format string, args ...interface{}) {
+	if controller.logEntry == nil {
+		return
+	}
+	controller.logEntry.LogInfo(infoType, fmt.Sprintf(format, args...))
+}
+
+func (controller *Controller) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var registration Registration
+	if !readRequest(w, r, &registration) {
+		return
+	}
+
+	controller.mu.Lock()
+	defer controller.mu.Unlock()
+
+	if len(controller.workers) >= len(controller.assignments) {
+		http.Error(w, "all workers already registered", http.StatusConflict)
+		return
+	}
+	id := len(controller.workers)
+	controller.workers = append(controller.workers, &workerState{hostname: registration.Hostname})
+	controller.logInfo("WorkerRegistered", "worker<%d> host<%s> registered", id, registration.Hostname)
+
+	if len(controller.workers) == len(controller.assignments) {
+		controller.startAt = time.Now().Add(controller.StartDelay)
+		controller.logInfo("WorkersStart", "all workers<%d> registered, starting at %s", len(controller.workers),
+			controller.startAt.Format(time.RFC3339))
+		close(controller.allRegistered)
+	}
+
+	writeResponse(w, WorkerID{ID: id})
+}
+
+func (controller *Controller) handleAssignment(w http.ResponseWriter, r *http.Request) {
+	var workerID WorkerID
+	if !readRequest(w, r, &workerID) {
+		return
+	}
+
+	select {
+	case <-controller.allRegistered:
+	case <-r.Context().Done():
+		return
+	}
+
+	controller.mu.Lock()
+	_, err := controller.worker(workerID.ID)
+	startAt := controller.startAt
+	controller.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	assignment := controller.assignments[workerID.ID]
+	assignment.StartIn = helpers.TimeDuration(time.Until(startAt))
+	writeResponse(w, assignment)
+}
+
+func (controller *Controller) handleStats(w http.ResponseWriter, r *http.Request) {
+	var stats Stats
+	if !readRequest(w, r, &stats) {
+		return
+	}
+
+	if err := controller.updateStats(stats); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeResponse(w, StatsResponse{Stop: controller.stopped()})
+}
+
+func (controller *Controller) handleDone(w http.ResponseWriter, r *http.Request) {
+	var result Result
+	if !readRequest(w, r, &result) {
+		return
+	}
+
+	if err := controller.updateStats(result.Stats); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	controller.mu.Lock()
+	defer controller.mu.Unlock()
+
+	worker, _ := controller.worker(result.ID) // existence checked by updateStats
+	if worker.result == nil {
+		worker.result = &result
+		controller.logInfo("WorkerDone", "worker<%d> host<%s> done", result.ID, worker.hostname)
+		if result.StopCondition {
+			controller.logInfo("StopCondition", "worker<%d> host<%s> stopped by stop condition, stopping all workers: %s",
+				result.ID, worker.hostname, result.Error)
+			controller.Stop()
+		}
+
+		done := 0
+		for _, w := range controller.workers {
+			if w.result != nil {
+				done++
+			}
+		}
+		if done == len(controller.assignments) {
+			close(controller.allDone)
+		}
+	}
+
+	writeResponse(w, StatsResponse{Stop: controller.stopped()})
+}
+
+// updateStats add difference since previous report of worker to global counters and statistics
+func (controller *Controller) updateStats(stats Stats) error {
+	controller.mu.Lock()
+	defer controller.mu.Unlock()
+
+	worker, err := controller.worker(stats.ID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if worker.result != nil {
+		// statistics already final
+		return nil
+	}
+
+	stats.Totals.addToGlobals(worker.stats.Totals)
+	statistics.MergeGlobal(stats.Statistics.Sub(worker.stats.Statistics))
+	worker.stats = stats
+
+	return nil
+}
+
+// readRequest unmarshal JSON body of POST request into v, responds with error and returns false on failure
+func readRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request: %v", err), http.StatusBadRequest)
+		return false
+	}
+	if err := jsonit.Unmarshal(body, v); err != nil {
+		http.Error(w, fmt.Sprintf("failed to unmarshal request: %v", err), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeResponse(w http.ResponseWriter, v interface{}) {
+	raw, err := jsonit.Marshal(v)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to marshal response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(raw)
+}
@@ -0,0 +1,313 @@
+package distributed
+
+import (
+	"context"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/config"
+	"github.com/qlik-oss/gopherciser/logger"
+	"github.com/qlik-oss/gopherciser/scheduler"
+	"github.com/qlik-oss/gopherciser/statistics"
+)
+
+const testConfig = `{
+	"settings" : {
+		"timeout" : 300,
+		"logs" : { "filename" : "logs/{{.ConfigFile}}.tsv" }
+	},
+	"connectionSettings" : {
+		"mode" : "ws",
+		"server" : "localhost"
+	},
+	"loginSettings" : {
+		"type" : "prefix",
+		"settings" : { "prefix" : "gopher" }
+	},
+	"scheduler" : {
+		"type" : "simple",
+		"settings" : {
+			"executionTime" : -1,
+			"iterations" : 2,
+			"rampupDelay" : 1.0,
+			"concurrentUsers" : 7
+		}
+	},
+	"scenario" : [
+		{ "action" : "thinktime", "settings" : { "type" : "static", "delay" : 1 } }
+	]
+}`
+
+func TestDistributed(t *testing.T) {
+	var cfg config.Config
+	if err := jsonit.Unmarshal([]byte(testConfig), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	controller, err := NewController(&cfg, []byte(testConfig), 3, "test", WorkerTemplateData{ConfigFile: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller.StartDelay = 50 * time.Millisecond
+
+	server := httptest.NewServer(controller.Handler())
+	defer server.Close()
+
+	statistics.SetGlobalLevel(statistics.StatsLevelOn)
+	defer statistics.DestroyGlobalCollector()
+	before := GlobalTotals()
+
+	var (
+		mu        sync.Mutex
+		users     []int
+		instances []int
+		logFiles  []string
+	)
+
+	workerErrs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		worker := NewWorker(server.URL)
+		worker.StatsInterval = 10 * time.Millisecond
+		worker.Execute = func(ctx context.Context, cfg *config.Config, templateData interface{}) error {
+			simple, ok := cfg.Scheduler.(*scheduler.SimpleScheduler)
+			if !ok {
+				return errors.Errorf("unexpected scheduler type<%T>", cfg.Scheduler)
+			}
+			logFile, err := cfg.Settings.LogSettings.FileName.ReplaceWithoutSessionVariables(templateData)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			users = append(users, simple.Settings.ConcurrentUsers)
+			instances = append(instances, int(simple.InstanceNumber))
+			logFiles = append(logFiles, logFile)
+			mu.Unlock()
+
+			time.Sleep(30 * time.Millisecond)
+			if simple.InstanceNumber == 3 {
+				return errors.New("worker failed")
+			}
+			return nil
+		}
+		var reports uint64
+		worker.CollectStats = func() Stats {
+			reports++
+			snapshot := &statistics.Snapshot{
+				Actions: []statistics.ActionStatsSnapshot{{
+					Name:     "thinktime",
+					RespAvg:  statistics.SampleSnapshot{Average: 10, Count: float64(reports)},
+					Requests: reports,
+				}},
+			}
+			return Stats{Totals: Totals{Actions: reports, Errors: 1}, Statistics: snapshot}
+		}
+		go func() {
+			workerErrs <- worker.Run(context.Background())
+		}()
+	}
+
+	log := logger.NewLog(logger.LogSettings{})
+	log.AddLoggers(logger.CreateDummyLogger())
+	log.StartLogger(context.Background())
+	defer func() {
+		_ = log.Close()
+	}()
+
+	runErr := controller.Run(context.Background(), log)
+	if runErr == nil {
+		t.Error("expected error from failing worker")
+	}
+
+	var failedWorkers int
+	for i := 0; i < 3; i++ {
+		if err := <-workerErrs; err != nil {
+			failedWorkers++
+		}
+	}
+	if failedWorkers != 1 {
+		t.Errorf("expected 1 failed worker, got<%d>", failedWorkers)
+	}
+
+	sort.Ints(users)
+	sort.Ints(instances)
+	sort.Strings(logFiles)
+	if len(users) != 3 || users[0] != 2 || users[1] != 2 || users[2] != 3 {
+		t.Errorf("unexpected split of users<%v>", users)
+	}
+	if len(instances) != 3 || instances[0] != 1 || instances[1] != 2 || instances[2] != 3 {
+		t.Errorf("unexpected instances<%v>", instances)
+	}
+	if len(logFiles) != 3 || logFiles[0] != "logs/test-worker1.tsv" || logFiles[2] != "logs/test-worker3.tsv" {
+		t.Errorf("unexpected log files<%v>", logFiles)
+	}
+
+	// each worker reports cumulative statistics, merged result should be sum of final reports
+	after := GlobalTotals()
+	if errs := after.Errors - before.Errors; errs != 3 {
+		t.Errorf("expected 3 merged errors, got<%d>", errs)
+	}
+
+	var requests uint64
+	var count float64
+	statistics.ForEachAction(func(stats *statistics.ActionStats) {
+		requests += stats.Requests.Current()
+		_, samples := stats.RespAvg.Average()
+		count += samples
+	})
+	if actions := after.Actions - before.Actions; actions != requests || float64(actions) != count {
+		t.Errorf("merged actions<%d> differ from merged statistics requests<%d> samples<%f>", actions, requests, count)
+	}
+}
+
+func TestControllerStop(t *testing.T) {
+	var cfg config.Config
+	if err := jsonit.Unmarshal([]byte(testConfig), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	controller, err := NewController(&cfg, []byte(testConfig), 1, "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller.StartDelay = 0
+
+	server := httptest.NewServer(controller.Handler())
+	defer server.Close()
+
+	worker := NewWorker(server.URL)
+	worker.StatsInterval = 10 * time.Millisecond
+	worker.Execute = func(ctx context.Context, cfg *config.Config, templateData interface{}) error {
+		<-ctx.Done()
+		return nil
+	}
+	worker.CollectStats = func() Stats { return Stats{} }
+
+	workerErr := make(chan error, 1)
+	go func() {
+		workerErr <- worker.Run(context.Background())
+	}()
+
+	log := logger.NewLog(logger.LogSettings{})
+	log.AddLoggers(logger.CreateDummyLogger())
+	log.StartLogger(context.Background())
+	defer func() {
+		_ = log.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := controller.Run(ctx, log); err != nil {
+		t.Error(err)
+	}
+
+	select {
+	case err := <-workerErr:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Error("worker did not stop")
+	}
+}
+
+func TestControllerStopCondition(t *testing.T) {
+	var cfg config.Config
+	if err := jsonit.Unmarshal([]byte(testConfig), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	controller, err := NewController(&cfg, []byte(testConfig), 2, "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller.StartDelay = 0
+
+	server := httptest.NewServer(controller.Handler())
+	defer server.Close()
+
+	workerErrs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		worker := NewWorker(server.URL)
+		worker.StatsInterval = 10 * time.Millisecond
+		worker.Execute = func(ctx context.Context, cfg *config.Config, templateData interface{}) error {
+			if cfg.Scheduler.(*scheduler.SimpleScheduler).InstanceNumber == 1 {
+				return errors.WithStack(config.StopConditionError("execution stopped: error rate"))
+			}
+			<-ctx.Done()
+			return nil
+		}
+		worker.CollectStats = func() Stats { return Stats{} }
+		go func() {
+			workerErrs <- worker.Run(context.Background())
+		}()
+	}
+
+	log := logger.NewLog(logger.LogSettings{})
+	log.AddLoggers(logger.CreateDummyLogger())
+	log.StartLogger(context.Background())
+	defer func() {
+		_ = log.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	runErr := controller.Run(ctx, log)
+	if _, ok := errors.Cause(runErr).(config.StopConditionError); !ok {
+		t.Errorf("expected StopConditionError, got<%v>", runErr)
+	}
+	if ctx.Err() != nil {
+		t.Error("workers not stopped by stop condition of worker")
+	}
+
+	for i := 0; i < 2; i++ {
+		<-workerErrs
+	}
+}
+
+func TestSplitStopConditions(t *testing.T) {
+	raw := `{
+		"settings" : {
+			"timeout" : 300,
+			"stopconditions" : [
+				{ "type" : "errorrate", "threshold" : 50, "window" : 10 },
+				{ "type" : "noactiveusers" },
+				{ "type" : "responsetime", "threshold" : 2000, "window" : 10 }
+			]
+		},
+		"connectionSettings" : { "mode" : "ws", "server" : "localhost" },
+		"loginSettings" : { "type" : "prefix", "settings" : { "prefix" : "gopher" } },
+		"scheduler" : {
+			"type" : "simple",
+			"settings" : { "executionTime" : -1, "iterations" : 1, "rampupDelay" : 1.0, "concurrentUsers" : 2 }
+		},
+		"scenario" : [
+			{ "action" : "thinktime", "settings" : { "type" : "static", "delay" : 1 } }
+		]
+	}`
+	var cfg config.Config
+	if err := jsonit.Unmarshal([]byte(raw), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	controller, err := NewController(&cfg, []byte(raw), 2, "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Settings.StopConditions) != 1 || cfg.Settings.StopConditions[0].Type != config.StopOnNoActiveUsers {
+		t.Errorf("unexpected controller stop conditions<%+v>", cfg.Settings.StopConditions)
+	}
+
+	workerCfg, err := controller.assignments[0].config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conditions := workerCfg.Settings.StopConditions
+	if len(conditions) != 2 || conditions[0].Type != config.StopOnErrorRate || conditions[1].Type != config.StopOnResponseTime {
+		t.Errorf("unexpected worker stop conditions<%+v>", conditions)
+	}
+}
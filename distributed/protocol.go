@@ -0,0 +1,136 @@
+package distributed
+
+import (
+	"encoding/json"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/qlik-oss/gopherciser/atomichandlers"
+	"github.com/qlik-oss/gopherciser/config"
+	"github.com/qlik-oss/gopherciser/globals"
+	"github.com/qlik-oss/gopherciser/helpers"
+	"github.com/qlik-oss/gopherciser/statistics"
+)
+
+// Endpoints served by controller, all requests are POST requests with a JSON body
+const (
+	// PathRegister worker registers with controller, body Registration, response WorkerID
+	PathRegister = "/register"
+	// PathAssignment worker waits for assignment, body WorkerID, response Assignment. The response is sent once all
+	// workers are registered.
+	PathAssignment = "/assignment"
+	// PathStats worker reports statistics, body Stats, response StatsResponse
+	PathStats = "/stats"
+	// PathDone worker reports result of execution, body Result
+	PathDone = "/done"
+)
+
+type (
+	// Registration sent by worker when registering with controller
+	Registration struct {
+		Hostname string `json:"hostname"`
+	}
+
+	// WorkerID id assigned to worker by controller
+	WorkerID struct {
+		ID int `json:"id"`
+	}
+
+	// Assignment work assigned to worker by controller
+	Assignment struct {
+		// Instance number of the worker scheduler
+		Instance uint64 `json:"instance"`
+		// Config as read by controller
+		Config json.RawMessage `json:"config"`
+		// Scheduler replacing the scheduler of Config, with load split between workers
+		Scheduler json.RawMessage `json:"scheduler"`
+		// ConfigName name of config file, used for templates
+		ConfigName string `json:"configname"`
+		// LogFile replacing log filename of Config
+		LogFile string `json:"logfile"`
+		// StartIn time until all workers start execution
+		StartIn helpers.TimeDuration `json:"startin"`
+	}
+
+	// Totals global counters of worker
+	Totals struct {
+		Threads     uint64 `json:"threads"`
+		Sessions    uint64 `json:"sessions"`
+		Users       uint64 `json:"users"`
+		Errors      uint64 `json:"errors"`
+		Warnings    uint64 `json:"warnings"`
+		Actions     uint64 `json:"actions"`
+		Requests    uint64 `json:"requests"`
+		ActiveUsers uint64 `json:"activeusers"`
+	}
+
+	// Stats statistics reported by worker, all values are cumulative since start of execution
+	Stats struct {
+		WorkerID
+		Totals     Totals               `json:"totals"`
+		Statistics *statistics.Snapshot `json:"statistics,omitempty"`
+	}
+
+	// StatsResponse controller response to reported statistics
+	StatsResponse struct {
+		// Stop execution, e.g. when controller was interrupted
+		Stop bool `json:"stop"`
+	}
+
+	// Result of worker execution
+	Result struct {
+		Stats
+		Error string `json:"error,omitempty"`
+		// StopCondition execution of worker was stopped by a stop condition
+		StopCondition bool `json:"stopcondition,omitempty"`
+	}
+)
+
+var jsonit = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// splitStopConditions split conditions into conditions evaluated by workers and by controller. Conditions on action
+// results are evaluated by each worker, as the controller only receives merged statistics, while conditions on
+// active users are evaluated by the controller on the merged counters.
+func splitStopConditions(conditions []config.StopCondition) (workerConditions, controllerConditions []config.StopCondition) {
+	for _, condition := range conditions {
+		if condition.Type == config.StopOnNoActiveUsers {
+			controllerConditions = append(controllerConditions, condition)
+		} else {
+			workerConditions = append(workerConditions, condition)
+		}
+	}
+	return workerConditions, controllerConditions
+}
+
+// GlobalTotals current values of global counters
+func GlobalTotals() Totals {
+	return Totals{
+		Threads:     globals.Threads.Current(),
+		Sessions:    globals.Sessions.Current(),
+		Users:       globals.Users.Current(),
+		Errors:      globals.Errors.Current(),
+		Warnings:    globals.Warnings.Current(),
+		Actions:     globals.ActionID.Current(),
+		Requests:    globals.Requests.Current(),
+		ActiveUsers: globals.ActiveUsers.Current(),
+	}
+}
+
+// addToGlobals add difference between totals and prev to global counters
+func (totals Totals) addToGlobals(prev Totals) {
+	for _, counter := range []struct {
+		global    *atomichandlers.AtomicCounter
+		cur, prev uint64
+	}{
+		{&globals.Threads, totals.Threads, prev.Threads},
+		{&globals.Sessions, totals.Sessions, prev.Sessions},
+		{&globals.Users, totals.Users, prev.Users},
+		{&globals.Errors, totals.Errors, prev.Errors},
+		{&globals.Warnings, totals.Warnings, prev.Warnings},
+		{&globals.ActionID, totals.Actions, prev.Actions},
+		{&globals.Requests, totals.Requests, prev.Requests},
+		{&globals.ActiveUsers, totals.ActiveUsers, prev.ActiveUsers},
+	} {
+		// unsigned overflow makes adding the difference work also when a counter decreased, e.g. active users
+		counter.global.Add(counter.cur - counter.prev)
+	}
+}
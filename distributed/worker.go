@@ -0,0 +1,205 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/config"
+	"github.com/qlik-oss/gopherciser/helpers"
+	"github.com/qlik-oss/gopherciser/scheduler"
+	"github.com/qlik-oss/gopherciser/session"
+	"github.com/qlik-oss/gopherciser/statistics"
+)
+
+type (
+	// Worker executes the part of a config assigned by a controller and reports statistics to the controller
+	Worker struct {
+		// Controller URL of controller, e.g. http://localhost:9090
+		Controller string
+		// StatsInterval time in between statistics reports to controller
+		StatsInterval time.Duration
+		// Client used for requests to controller
+		Client *http.Client
+		// Execute assigned config, defaults to executing config
+		Execute func(ctx context.Context, cfg *config.Config, templateData interface{}) error
+		// CollectStats current statistics to report to controller, defaults to global counters and statistics
+		CollectStats func() Stats
+
+		id int
+	}
+
+	// WorkerTemplateData data for templates used by worker, e.g. log filename
+	WorkerTemplateData struct {
+		ConfigFile string
+	}
+)
+
+// DefaultStatsInterval default time in between statistics reports to controller
+const DefaultStatsInterval = 5 * time.Second
+
+// NewWorker worker connecting to controller
+func NewWorker(controller string) *Worker {
+	return &Worker{
+		Controller:    strings.TrimSuffix(controller, "/"),
+		StatsInterval: DefaultStatsInterval,
+		Client:        http.DefaultClient,
+		Execute: func(ctx context.Context, cfg *config.Config, templateData interface{}) error {
+			return cfg.Execute(ctx, templateData)
+		},
+		CollectStats: func() Stats {
+			return Stats{
+				Totals:     GlobalTotals(),
+				Statistics: statistics.GlobalSnapshot(),
+			}
+		},
+	}
+}
+
+// Run register with controller, wait for assignment and execute it
+func (worker *Worker) Run(ctx context.Context) error {
+	hostname, _ := os.Hostname()
+	var workerID WorkerID
+	if err := worker.post(ctx, PathRegister, Registration{Hostname: hostname}, &workerID); err != nil {
+		return errors.Wrap(err, "failed to register with controller")
+	}
+	worker.id = workerID.ID
+
+	var assignment Assignment
+	if err := worker.post(ctx, PathAssignment, workerID, &assignment); err != nil {
+		return errors.Wrap(err, "failed to get assignment from controller")
+	}
+
+	cfg, err := assignment.config()
+	if err != nil {
+		execErr := errors.Wrap(err, "invalid assignment")
+		_ = worker.done(execErr)
+		return execErr
+	}
+
+	helpers.WaitFor(ctx, time.Duration(assignment.StartIn))
+	if helpers.IsContextTriggered(ctx) {
+		execErr := errors.New("worker stopped before start of execution")
+		_ = worker.done(execErr)
+		return execErr
+	}
+
+	execCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	execDone := make(chan error, 1)
+	go func() {
+		execDone <- worker.Execute(execCtx, cfg, WorkerTemplateData{ConfigFile: assignment.ConfigName})
+	}()
+
+	ticker := time.NewTicker(worker.StatsInterval)
+	defer ticker.Stop()
+
+	var execErr error
+ExecLoop:
+	for {
+		select {
+		case execErr = <-execDone:
+			break ExecLoop
+		case <-ticker.C:
+			var response StatsResponse
+			if err := worker.post(ctx, PathStats, worker.stats(), &response); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "failed to report statistics to controller: %v\n", err)
+				continue
+			}
+			if response.Stop {
+				cancel()
+			}
+		}
+	}
+
+	if err := worker.done(execErr); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "failed to report result to controller: %v\n", err)
+	}
+	return errors.WithStack(execErr)
+}
+
+func (worker *Worker) stats() Stats {
+	stats := worker.CollectStats()
+	stats.ID = worker.id
+	return stats
+}
+
+// done report result of execution to controller
+func (worker *Worker) done(execErr error) error {
+	result := Result{Stats: worker.stats()}
+	if execErr != nil {
+		result.Error = execErr.Error()
+		_, result.StopCondition = errors.Cause(execErr).(config.StopConditionError)
+	}
+	// result should be reported also when worker was stopped
+	return errors.WithStack(worker.post(context.Background(), PathDone, result, &StatsResponse{}))
+}
+
+// post v as JSON to controller endpoint and unmarshal response into response
+func (worker *Worker) post(ctx context.Context, path string, v, response interface{}) error {
+	body, err := jsonit.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal request")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, worker.Controller+path, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := worker.Client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("controller responded to <%s> with status<%d>: %s", path, resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+
+	return errors.Wrap(jsonit.Unmarshal(raw, response), "failed to unmarshal response")
+}
+
+// config of assignment with the assigned scheduler, log filename and stop conditions evaluated by worker
+func (assignment *Assignment) config() (*config.Config, error) {
+	var cfg config.Config
+	if err := jsonit.Unmarshal(assignment.Config, &cfg); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal config")
+	}
+
+	sched, err := scheduler.UnmarshalScheduler(assignment.Scheduler)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	cfg.Scheduler = sched
+	cfg.Settings.StopConditions, _ = splitStopConditions(cfg.Settings.StopConditions)
+
+	if assignment.LogFile != "" {
+		logFile, err := session.NewSyncedTemplate(assignment.LogFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to set log filename")
+		}
+		cfg.Settings.LogSettings.FileName = *logFile
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &cfg, nil
+}
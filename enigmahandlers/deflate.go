@@ -0,0 +1,124 @@
+package enigmahandlers
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"io/ioutil"
+
+	"github.com/gobwas/httphead"
+	"github.com/pkg/errors"
+)
+
+type (
+	// permessageDeflate state of negotiated permessage-deflate websocket extension (RFC 7692)
+	permessageDeflate struct {
+		// clientNoContextTakeover compress each sent message without using previous messages
+		clientNoContextTakeover bool
+		// serverNoContextTakeover received messages are compressed without using previous messages
+		serverNoContextTakeover bool
+		// level compression level of sent messages
+		level int
+
+		writer   *flate.Writer
+		writeBuf bytes.Buffer
+
+		reader io.ReadCloser
+		// window last received uncompressed data, used as dictionary when server uses context takeover
+		window []byte
+	}
+)
+
+const (
+	permessageDeflateName   = "permessage-deflate"
+	clientNoContextTakeover = "client_no_context_takeover"
+	serverNoContextTakeover = "server_no_context_takeover"
+	deflateWindowSize       = 32 * 1024
+)
+
+var (
+	// deflateTail removed from end of compressed messages
+	deflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+	// deflateEnd tail of compressed messages followed by final empty block
+	deflateEnd = []byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff}
+)
+
+// deflateOffers permessage-deflate extension offers. Extensions accepted by server must equal one of the offers,
+// context takeover parameters the server is allowed to add on its own are therefore offered as well.
+func deflateOffers() []httphead.Option {
+	return []httphead.Option{
+		httphead.NewOption(permessageDeflateName, nil),
+		httphead.NewOption(permessageDeflateName, map[string]string{clientNoContextTakeover: ""}),
+		httphead.NewOption(permessageDeflateName, map[string]string{serverNoContextTakeover: ""}),
+		httphead.NewOption(permessageDeflateName, map[string]string{clientNoContextTakeover: "", serverNoContextTakeover: ""}),
+	}
+}
+
+// newPermessageDeflate from extensions negotiated during handshake, nil when permessage-deflate wasn't negotiated.
+// Level 0 uses default compression level.
+func newPermessageDeflate(extensions []httphead.Option, level int) *permessageDeflate {
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+	for _, extension := range extensions {
+		if string(extension.Name) != permessageDeflateName {
+			continue
+		}
+		_, clientNoContext := extension.Parameters.Get(clientNoContextTakeover)
+		_, serverNoContext := extension.Parameters.Get(serverNoContextTakeover)
+		return &permessageDeflate{
+			clientNoContextTakeover: clientNoContext,
+			serverNoContextTakeover: serverNoContext,
+			level:                   level,
+		}
+	}
+	return nil
+}
+
+// compress message payload to be sent, returned data is valid until next call to compress
+func (deflate *permessageDeflate) compress(p []byte) ([]byte, error) {
+	deflate.writeBuf.Reset()
+	if deflate.writer == nil {
+		var err error
+		if deflate.writer, err = flate.NewWriter(&deflate.writeBuf, deflate.level); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	} else if deflate.clientNoContextTakeover {
+		deflate.writer.Reset(&deflate.writeBuf)
+	}
+
+	if _, err := deflate.writer.Write(p); err != nil {
+		return nil, errors.Wrap(err, "failed to compress message")
+	}
+	if err := deflate.writer.Flush(); err != nil {
+		return nil, errors.Wrap(err, "failed to compress message")
+	}
+
+	return bytes.TrimSuffix(deflate.writeBuf.Bytes(), deflateTail), nil
+}
+
+// decompress received message payload
+func (deflate *permessageDeflate) decompress(p []byte) ([]byte, error) {
+	src := io.MultiReader(bytes.NewReader(p), bytes.NewReader(deflateEnd))
+	if deflate.reader == nil {
+		deflate.reader = flate.NewReaderDict(src, deflate.window)
+	} else if err := deflate.reader.(flate.Resetter).Reset(src, deflate.window); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	data, err := ioutil.ReadAll(deflate.reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decompress message")
+	}
+
+	if !deflate.serverNoContextTakeover {
+		window := append(deflate.window, data...)
+		if len(window) > deflateWindowSize {
+			window = window[len(window)-deflateWindowSize:]
+		}
+		// copy to not keep references to previous windows or data
+		deflate.window = append(make([]byte, 0, len(window)), window...)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,149 @@
+package enigmahandlers
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/gobwas/httphead"
+	"github.com/gobwas/ws"
+	"github.com/qlik-oss/enigma-go"
+)
+
+func TestPermessageDeflate(t *testing.T) {
+	for _, noContextTakeover := range []bool{false, true} {
+		client := &permessageDeflate{clientNoContextTakeover: noContextTakeover, level: flate.BestCompression}
+		server := &permessageDeflate{serverNoContextTakeover: noContextTakeover}
+
+		for i := 0; i < 50; i++ {
+			message := []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"GetLayout","handle":1,"params":[]}`, i))
+			compressed, err := client.compress(message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i > 0 && !noContextTakeover && len(compressed) >= len(message)/2 {
+				t.Errorf("message<%d> not compressed, size<%d> compressed<%d>", i, len(message), len(compressed))
+			}
+
+			decompressed, err := server.decompress(compressed)
+			if err != nil {
+				t.Fatalf("failed to decompress message<%d> without context takeover<%v>: %v", i, noContextTakeover, err)
+			}
+			if !bytes.Equal(message, decompressed) {
+				t.Fatalf("decompressed message<%s> differs from message<%s>", decompressed, message)
+			}
+		}
+	}
+}
+
+// newEchoServer websocket server echoing frames, accepting permessage-deflate extension with parameters when
+// extension is not empty
+func newEchoServer(t *testing.T, extension string) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upgrader := ws.Upgrader{
+		ExtensionCustom: func(header []byte, selected []httphead.Option) ([]httphead.Option, bool) {
+			if extension == "" {
+				return selected, true
+			}
+			options, ok := httphead.ParseOptions([]byte(extension), selected)
+			return options, ok
+		},
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				if _, err := upgrader.Upgrade(conn); err != nil {
+					t.Error(err)
+					return
+				}
+				for {
+					frame, err := ws.ReadFrame(conn)
+					if err != nil || frame.Header.OpCode == ws.OpClose {
+						return
+					}
+					ws.Cipher(frame.Payload, frame.Header.Mask, 0)
+					frame.Header.Masked = false
+					if err := ws.WriteFrame(conn, frame); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return listener
+}
+
+func TestCompressedSocket(t *testing.T) {
+	for _, test := range []struct {
+		extension  string
+		compressed bool
+	}{
+		{"", false},
+		{"permessage-deflate", true},
+		{"permessage-deflate; client_no_context_takeover", true},
+	} {
+		listener := newEchoServer(t, test.extension)
+
+		var sent, received, compressedSent, compressedReceived int
+		dialer := enigma.Dialer{}
+		setupDialer(&dialer, ConnectOptions{Compression: true, CompressionLevel: flate.BestCompression}, 0, func(isSent bool, size, compressedSize int) {
+			if isSent {
+				sent += size
+				compressedSent += compressedSize
+			} else {
+				received += size
+				compressedReceived += compressedSize
+			}
+		})
+
+		socket, err := dialer.CreateSocket(context.Background(), "ws://"+listener.Addr().String(), http.Header{})
+		if err != nil {
+			_ = listener.Close()
+			t.Fatal(err)
+		}
+
+		message := []byte(`{"jsonrpc":"2.0","id":1,"method":"GetLayout","handle":1,"params":[],"padding":"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}`)
+		for i := 0; i < 3; i++ {
+			if err := socket.WriteMessage(int(ws.OpText), message); err != nil {
+				t.Fatal(err)
+			}
+			_, data, err := socket.ReadMessage()
+			if err != nil {
+				t.Fatalf("extension<%s>: %v", test.extension, err)
+			}
+			if !bytes.Equal(data, message) {
+				t.Errorf("extension<%s>: received<%s> expected<%s>", test.extension, data, message)
+			}
+		}
+
+		if test.compressed {
+			if sent != 3*len(message) || received != sent {
+				t.Errorf("extension<%s>: unexpected uncompressed sizes sent<%d> received<%d>", test.extension, sent, received)
+			}
+			if compressedSent < 1 || compressedSent >= sent || compressedReceived != compressedSent {
+				t.Errorf("extension<%s>: unexpected compressed sizes sent<%d> received<%d>", test.extension,
+					compressedSent, compressedReceived)
+			}
+		} else if sent != 0 || received != 0 {
+			t.Errorf("extension<%s>: unexpected compressed messages", test.extension)
+		}
+
+		_ = socket.Close()
+		_ = listener.Close()
+	}
+}
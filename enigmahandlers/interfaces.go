@@ -9,4 +9,10 @@ type (
 		RequestCount() uint64
 		ResetRequestCount()
 	}
+
+	// ICompressionLogger optionally implemented by traffic logger to log sizes of compressed messages
+	ICompressionLogger interface {
+		SentCompressed(size, compressedSize int)
+		ReceivedCompressed(size, compressedSize int)
+	}
 )
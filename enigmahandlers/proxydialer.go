@@ -0,0 +1,254 @@
+package enigmahandlers
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/netemulation"
+)
+
+type (
+	// bufferedConn connection with data read ahead by proxy or websocket handshake
+	bufferedConn struct {
+		net.Conn
+		reader *bufio.Reader
+	}
+)
+
+const (
+	socksVersion5         = 0x05
+	socksAuthNone         = 0x00
+	socksAuthUserPassword = 0x02
+	socksAuthNoAcceptable = 0xff
+	socksCmdConnect       = 0x01
+	socksAddrIPv4         = 0x01
+	socksAddrDomain       = 0x03
+	socksAddrIPv6         = 0x04
+)
+
+// Read from read ahead buffer before connection
+func (conn *bufferedConn) Read(b []byte) (int, error) {
+	return conn.reader.Read(b)
+}
+
+// proxyDialContext dial function tunneling connections through HTTP CONNECT (http, https) or SOCKS5 (socks5) proxy,
+// connection to proxy is made using dial
+func proxyDialContext(proxyURL *neturl.URL, tlsConfig *tls.Config, dial netemulation.DialFunc) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		proxyAddr := proxyURL.Host
+		if proxyURL.Port() == "" {
+			proxyAddr = net.JoinHostPort(proxyURL.Hostname(), defaultProxyPort(proxyURL.Scheme))
+		}
+
+		conn, err := dial(ctx, network, proxyAddr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to connect to proxy<%s>", proxyAddr)
+		}
+
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+
+		switch proxyURL.Scheme {
+		case "https":
+			var config *tls.Config
+			if tlsConfig != nil {
+				config = tlsConfig.Clone()
+				config.Certificates = nil
+			} else {
+				config = &tls.Config{}
+			}
+			config.ServerName = proxyURL.Hostname()
+			conn = tls.Client(conn, config)
+			fallthrough
+		case "http":
+			var tunnel net.Conn
+			if tunnel, err = httpConnect(conn, proxyURL, addr); err == nil {
+				conn = tunnel
+			}
+		case "socks5":
+			err = socksConnect(conn, proxyURL, addr)
+		default:
+			err = errors.Errorf("unsupported proxy scheme<%s>", proxyURL.Scheme)
+		}
+		if err != nil {
+			_ = conn.Close()
+			return nil, errors.Wrapf(err, "failed to connect to<%s> through proxy<%s>", addr, proxyAddr)
+		}
+
+		_ = conn.SetDeadline(time.Time{})
+		return conn, nil
+	}
+}
+
+func defaultProxyPort(scheme string) string {
+	switch scheme {
+	case "https":
+		return "443"
+	case "socks5":
+		return "1080"
+	default:
+		return "80"
+	}
+}
+
+// httpConnect open tunnel to addr using HTTP CONNECT
+func httpConnect(conn net.Conn, proxyURL *neturl.URL, addr string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &neturl.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, errors.Wrap(err, "failed to send CONNECT request")
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read CONNECT response")
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("proxy responded with status<%s> to CONNECT", resp.Status)
+	}
+
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// socksConnect open tunnel to addr using SOCKS5, with username and password authentication if proxy URL has user
+func socksConnect(conn net.Conn, proxyURL *neturl.URL, addr string) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return errors.Wrapf(err, "invalid address<%s>", addr)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return errors.Wrapf(err, "invalid port<%s>", portStr)
+	}
+
+	methods := []byte{socksAuthNone}
+	if proxyURL.User != nil {
+		methods = append(methods, socksAuthUserPassword)
+	}
+	if _, err := conn.Write(append([]byte{socksVersion5, byte(len(methods))}, methods...)); err != nil {
+		return errors.Wrap(err, "failed to send SOCKS5 greeting")
+	}
+
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return errors.Wrap(err, "failed to read SOCKS5 greeting reply")
+	}
+	if reply[0] != socksVersion5 {
+		return errors.Errorf("unexpected SOCKS version<%d>", reply[0])
+	}
+	switch reply[1] {
+	case socksAuthNone:
+	case socksAuthUserPassword:
+		if proxyURL.User == nil {
+			return errors.New("SOCKS5 proxy requires authentication")
+		}
+		if err := socksAuthenticate(conn, proxyURL.User); err != nil {
+			return errors.WithStack(err)
+		}
+	case socksAuthNoAcceptable:
+		return errors.New("SOCKS5 proxy accepted no authentication method")
+	default:
+		return errors.Errorf("unsupported SOCKS5 authentication method<%d>", reply[1])
+	}
+
+	req := []byte{socksVersion5, socksCmdConnect, 0x00}
+	if ip := net.ParseIP(host); ip == nil {
+		if len(host) > 255 {
+			return errors.Errorf("host name<%s> too long", host)
+		}
+		req = append(req, socksAddrDomain, byte(len(host)))
+		req = append(req, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		req = append(req, socksAddrIPv4)
+		req = append(req, ip4...)
+	} else {
+		req = append(req, socksAddrIPv6)
+		req = append(req, ip.To16()...)
+	}
+	req = append(req, 0, 0)
+	binary.BigEndian.PutUint16(req[len(req)-2:], uint16(port))
+	if _, err := conn.Write(req); err != nil {
+		return errors.Wrap(err, "failed to send SOCKS5 connect request")
+	}
+
+	// version, reply, reserved and address type
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return errors.Wrap(err, "failed to read SOCKS5 connect reply")
+	}
+	if header[1] != 0x00 {
+		return errors.Errorf("SOCKS5 connect failed with reply<%d>", header[1])
+	}
+
+	var addrLen int
+	switch header[3] {
+	case socksAddrIPv4:
+		addrLen = net.IPv4len
+	case socksAddrIPv6:
+		addrLen = net.IPv6len
+	case socksAddrDomain:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return errors.Wrap(err, "failed to read SOCKS5 bound address")
+		}
+		addrLen = int(length[0])
+	default:
+		return errors.Errorf("unexpected SOCKS5 address type<%d>", header[3])
+	}
+	// bound address and port
+	if _, err := io.ReadFull(conn, make([]byte, addrLen+2)); err != nil {
+		return errors.Wrap(err, "failed to read SOCKS5 bound address")
+	}
+	return nil
+}
+
+// socksAuthenticate SOCKS5 username and password authentication (RFC 1929)
+func socksAuthenticate(conn net.Conn, user *neturl.Userinfo) error {
+	username := user.Username()
+	password, _ := user.Password()
+	if len(username) > 255 || len(password) > 255 {
+		return errors.New("SOCKS5 username or password too long")
+	}
+
+	req := []byte{0x01, byte(len(username))}
+	req = append(req, username...)
+	req = append(req, byte(len(password)))
+	req = append(req, password...)
+	if _, err := conn.Write(req); err != nil {
+		return errors.Wrap(err, "failed to send SOCKS5 authentication")
+	}
+
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return errors.Wrap(err, "failed to read SOCKS5 authentication reply")
+	}
+	if reply[1] != 0x00 {
+		return errors.New("SOCKS5 authentication failed")
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package enigmahandlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newConnectProxy HTTP CONNECT proxy requiring basic authentication user:pass
+func newConnectProxy(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Proxy-Authorization") != "Basic dXNlcjpwYXNz" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if _, err := fmt.Fprint(conn, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+			t.Error(err)
+			return
+		}
+		pipe(conn, target)
+	}))
+}
+
+// newSocksProxy SOCKS5 proxy requiring authentication user:pass, closing connections failing handshake
+func newSocksProxy(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				target, err := socksHandshake(conn)
+				if err != nil {
+					_ = conn.Close()
+					return
+				}
+				pipe(conn, target)
+			}()
+		}
+	}()
+	return listener
+}
+
+func socksHandshake(conn net.Conn) (net.Conn, error) {
+	reader := bufio.NewReader(conn)
+	greeting := make([]byte, 2)
+	if _, err := io.ReadFull(reader, greeting); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(reader, make([]byte, greeting[1])); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write([]byte{socksVersion5, socksAuthUserPassword}); err != nil {
+		return nil, err
+	}
+
+	readString := func() (string, error) {
+		length, err := reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		buf := make([]byte, length)
+		_, err = io.ReadFull(reader, buf)
+		return string(buf), err
+	}
+	if _, err := reader.ReadByte(); err != nil {
+		return nil, err
+	}
+	user, err := readString()
+	if err != nil {
+		return nil, err
+	}
+	password, err := readString()
+	if err != nil {
+		return nil, err
+	}
+	if user != "user" || password != "pass" {
+		_, _ = conn.Write([]byte{0x01, 0x01})
+		return nil, fmt.Errorf("unexpected credentials<%s:%s>", user, password)
+	}
+	if _, err := conn.Write([]byte{0x01, 0x00}); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+	var host string
+	switch header[3] {
+	case socksAddrIPv4:
+		ip := make([]byte, net.IPv4len)
+		if _, err := io.ReadFull(reader, ip); err != nil {
+			return nil, err
+		}
+		host = net.IP(ip).String()
+	case socksAddrDomain:
+		if host, err = readString(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unexpected address type<%d>", header[3])
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(reader, port); err != nil {
+		return nil, err
+	}
+
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write([]byte{socksVersion5, 0x00, 0x00, socksAddrIPv4, 0, 0, 0, 0, 0, 0}); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+func pipe(a, b net.Conn) {
+	go func() {
+		_, _ = io.Copy(a, b)
+		_ = a.Close()
+	}()
+	_, _ = io.Copy(b, a)
+	_ = b.Close()
+}
+
+func TestProxyDialContext(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "hello")
+	}))
+	defer target.Close()
+
+	connectProxy := newConnectProxy(t)
+	defer connectProxy.Close()
+	socksProxy := newSocksProxy(t)
+	defer func() {
+		_ = socksProxy.Close()
+	}()
+
+	tests := []struct {
+		name     string
+		proxyURL string
+		success  bool
+	}{
+		{"connect", fmt.Sprintf("http://user:pass@%s", connectProxy.Listener.Addr()), true},
+		{"connect unauthorized", fmt.Sprintf("http://%s", connectProxy.Listener.Addr()), false},
+		{"socks5", fmt.Sprintf("socks5://user:pass@%s", socksProxy.Addr()), true},
+		{"socks5 unauthorized", fmt.Sprintf("socks5://user:wrong@%s", socksProxy.Addr()), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			proxyURL, err := neturl.Parse(test.proxyURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &http.Client{
+				Transport: &http.Transport{DialContext: proxyDialContext(proxyURL, nil, (&net.Dialer{}).DialContext)},
+				Timeout:   5 * time.Second,
+			}
+			resp, err := client.Get(target.URL)
+			if !test.success {
+				if err == nil {
+					_ = resp.Body.Close()
+					t.Error("expected proxy to refuse connection")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := ioutil.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != "hello" {
+				t.Errorf("unexpected body<%s>", body)
+			}
+		})
+	}
+}
+
+func TestConnectProxyDialContextTimeout(t *testing.T) {
+	// proxy accepting connections but never responding
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	proxyURL := &neturl.URL{Scheme: "http", Host: listener.Addr().String()}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := proxyDialContext(proxyURL, nil, (&net.Dialer{}).DialContext)(ctx, "tcp", "127.0.0.1:1"); err == nil {
+		t.Error("expected timeout connecting through unresponsive proxy")
+	}
+}
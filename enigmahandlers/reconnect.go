@@ -0,0 +1,775 @@
+package enigmahandlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/enigma-go"
+	"github.com/qlik-oss/gopherciser/globals/constant"
+	"github.com/qlik-oss/gopherciser/helpers"
+)
+
+type (
+	// ReconnectSettings reconnect policy of websocket closed unexpectedly
+	ReconnectSettings struct {
+		// Attempts max reconnect attempts, defaults to DefaultReconnectAttempts
+		Attempts int `json:"attempts,omitempty" doc-key:"config.connectionSettings.reconnect.attempts"`
+		// Backoff delay between reconnect attempts
+		Backoff helpers.TimeDuration `json:"backoff,omitempty" doc-key:"config.connectionSettings.reconnect.backoff"`
+	}
+
+	// ReconnectResult result of a websocket reconnect
+	ReconnectResult struct {
+		// SessionState engine session state of new websocket, empty when reconnect failed
+		SessionState string
+		// Attempts reconnect attempts made
+		Attempts int
+		// Duration time from websocket closing until reconnected and session restored
+		Duration time.Duration
+		// Restored objects re-created on a new engine session
+		Restored int
+		// Lost requests pending when websocket closed and objects which couldn't be re-created
+		Lost int
+		// Err reconnect error, nil when reconnected
+		Err error
+	}
+
+	// rpcRequest request sent to engine
+	rpcRequest struct {
+		JSONRPC string          `json:"jsonrpc"`
+		Delta   bool            `json:"delta"`
+		Method  string          `json:"method"`
+		Handle  int             `json:"handle"`
+		ID      int             `json:"id"`
+		Params  json.RawMessage `json:"params"`
+	}
+
+	// rpcMessage response or notification received from engine
+	rpcMessage struct {
+		ID     int             `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+		Change []int           `json:"change"`
+		Close  []int           `json:"close"`
+	}
+
+	// objectResult result of request returning an object
+	objectResult struct {
+		Return *struct {
+			Handle *int `json:"qHandle"`
+		} `json:"qReturn"`
+	}
+
+	// creator request which created an object, replayed when engine session has to be re-created
+	creator struct {
+		// method and params of request
+		method string
+		params json.RawMessage
+		// parent client handle of object request was sent to
+		parent int
+		// handle client handle of created object
+		handle int
+	}
+
+	// handleMap translation between handles known by client and handles of current engine session
+	handleMap struct {
+		client map[int]int // client to engine handle
+		engine map[int]int // engine to client handle
+		// lost client handles of objects which couldn't be re-created
+		lost map[int]struct{}
+	}
+
+	// changeMessage notification of changed objects
+	changeMessage struct {
+		JSONRPC string `json:"jsonrpc"`
+		Change  []int  `json:"change"`
+	}
+
+	// attachment websocket reconnected to engine session
+	attachment struct {
+		socket       enigma.Socket
+		sessionState string
+		// pushes notifications received while attaching
+		pushes [][]byte
+		// handles translation of handles of re-created session, nil when session was attached
+		handles  *handleMap
+		restored []*creator
+	}
+
+	// reconnectSocket websocket reconnecting to engine on unexpected close. Requests are translated to
+	// handles of re-created engine sessions keeping enigma objects of client valid.
+	reconnectSocket struct {
+		dial        func(ctx context.Context) (enigma.Socket, error)
+		settings    ReconnectSettings
+		timeout     time.Duration
+		onReconnect func(result ReconnectResult)
+
+		ctx      context.Context
+		cancel   context.CancelFunc
+		messages chan []byte
+		done     chan struct{}
+		err      error
+
+		reconnectMu sync.Mutex
+		writeMu     sync.Mutex
+
+		mu         sync.Mutex
+		socket     enigma.Socket
+		generation int
+		closed     bool
+		// established engine session has been established, websockets closed before that aren't reconnected
+		established bool
+		pending     map[int]*rpcRequest
+		creators    []*creator
+		// handles nil until engine session has been re-created
+		handles *handleMap
+	}
+)
+
+const (
+	// DefaultReconnectAttempts reconnect attempts used when not set in reconnect settings
+	DefaultReconnectAttempts = 3
+
+	// replayRequestID first request ID of replayed requests, kept apart from request IDs of enigma
+	replayRequestID = 1 << 30
+)
+
+var (
+	// ErrSocketClosed websocket closed by client
+	ErrSocketClosed = errors.New("websocket closed")
+)
+
+// Validate reconnect settings
+func (settings *ReconnectSettings) Validate() error {
+	if settings == nil {
+		return nil
+	}
+	if settings.Attempts < 0 {
+		return errors.Errorf("reconnect attempts<%d> can't be negative", settings.Attempts)
+	}
+	if settings.Backoff < 0 {
+		return errors.Errorf("reconnect backoff<%v> can't be negative", settings.Backoff)
+	}
+	return nil
+}
+
+// setupReconnect wrap sockets created by dialer into sockets reconnecting according to settings, onReconnect is
+// called with result of every reconnect. Reconnects are aborted when ctx is done.
+func setupReconnect(ctx context.Context, dialer *enigma.Dialer, settings ReconnectSettings, timeout time.Duration,
+	onReconnect func(result ReconnectResult)) {
+	if timeout.Nanoseconds() < 1 {
+		timeout = 30 * time.Second
+	}
+	createSocket := dialer.CreateSocket
+	dialer.CreateSocket = func(dialCtx context.Context, url string, httpHeader http.Header) (enigma.Socket, error) {
+		// header is cloned as cookies from jar are added to it on each dial
+		dial := func(ctx context.Context) (enigma.Socket, error) {
+			return createSocket(ctx, url, httpHeader.Clone())
+		}
+		socket, err := dial(dialCtx)
+		if err != nil {
+			return nil, err
+		}
+		return newReconnectSocket(ctx, socket, dial, settings, timeout, onReconnect), nil
+	}
+}
+
+func newReconnectSocket(ctx context.Context, socket enigma.Socket, dial func(ctx context.Context) (enigma.Socket, error),
+	settings ReconnectSettings, timeout time.Duration, onReconnect func(result ReconnectResult)) *reconnectSocket {
+	if settings.Attempts < 1 {
+		settings.Attempts = DefaultReconnectAttempts
+	}
+	cCtx, cancel := context.WithCancel(ctx)
+	reconnect := &reconnectSocket{
+		dial:        dial,
+		settings:    settings,
+		timeout:     timeout,
+		onReconnect: onReconnect,
+		ctx:         cCtx,
+		cancel:      cancel,
+		messages:    make(chan []byte, 16),
+		done:        make(chan struct{}),
+		socket:      socket,
+		pending:     make(map[int]*rpcRequest),
+	}
+	go reconnect.readLoop()
+	return reconnect
+}
+
+// WriteMessage to engine, handle of request is translated when engine session has been re-created
+func (socket *reconnectSocket) WriteMessage(messageType int, data []byte) error {
+	socket.writeMu.Lock()
+	current, generation, data, response, err := socket.prepareWrite(data)
+	if err == nil && response == nil {
+		err = current.WriteMessage(messageType, data)
+	}
+	socket.writeMu.Unlock()
+
+	if response != nil {
+		socket.inject(response)
+		return nil
+	}
+	if err == nil {
+		return nil
+	}
+	return socket.handleError(generation, err)
+}
+
+// ReadMessage received from engine, with handles of re-created engine session translated to client handles
+func (socket *reconnectSocket) ReadMessage() (int, []byte, error) {
+	select {
+	case message := <-socket.messages:
+		return 1, message, nil
+	default:
+	}
+
+	select {
+	case message := <-socket.messages:
+		return 1, message, nil
+	case <-socket.done:
+		return 0, nil, socket.err
+	}
+}
+
+// Close websocket without reconnecting
+func (socket *reconnectSocket) Close() error {
+	socket.mu.Lock()
+	socket.closed = true
+	current := socket.socket
+	socket.mu.Unlock()
+
+	socket.cancel()
+	return current.Close()
+}
+
+// readLoop read messages from current websocket until reconnect fails or socket is closed
+func (socket *reconnectSocket) readLoop() {
+	for {
+		socket.mu.Lock()
+		current, generation := socket.socket, socket.generation
+		socket.mu.Unlock()
+
+		_, data, err := current.ReadMessage()
+		if err != nil {
+			if err = socket.handleError(generation, err); err != nil {
+				socket.err = err
+				close(socket.done)
+				return
+			}
+			continue
+		}
+
+		if !socket.inject(socket.received(data)) {
+			socket.err = ErrSocketClosed
+			close(socket.done)
+			return
+		}
+	}
+}
+
+// inject messages to be read by client, returns false if socket was closed before all were injected
+func (socket *reconnectSocket) inject(messages ...[]byte) bool {
+	for _, message := range messages {
+		select {
+		case socket.messages <- message:
+		case <-socket.ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// handleError reconnect websocket unless closed by client, err is returned if reconnect fails
+func (socket *reconnectSocket) handleError(generation int, err error) error {
+	socket.mu.Lock()
+	reconnect := socket.established && !socket.closed
+	socket.mu.Unlock()
+	if !reconnect {
+		return err
+	}
+
+	if reconnectErr := socket.reconnect(generation); reconnectErr != nil {
+		return errors.Wrapf(err, "reconnect failed: %v", reconnectErr)
+	}
+	return nil
+}
+
+// prepareWrite register request as pending and translate its handle. A response is returned instead when request
+// is sent to an object which couldn't be re-created.
+func (socket *reconnectSocket) prepareWrite(data []byte) (enigma.Socket, int, []byte, []byte, error) {
+	socket.mu.Lock()
+	defer socket.mu.Unlock()
+
+	if socket.closed {
+		return socket.socket, socket.generation, data, nil, ErrSocketClosed
+	}
+
+	var request rpcRequest
+	if err := jsonit.Unmarshal(data, &request); err != nil || request.ID == 0 {
+		return socket.socket, socket.generation, data, nil, nil
+	}
+
+	if socket.handles.isLost(request.Handle) {
+		return socket.socket, socket.generation, data, errorResponse(request.ID, "object lost on websocket reconnect"), nil
+	}
+
+	socket.pending[request.ID] = &request
+	if engineHandle := socket.handles.toEngine(request.Handle); engineHandle != request.Handle {
+		translated := request
+		translated.Handle = engineHandle
+		var err error
+		if data, err = jsonit.Marshal(translated); err != nil {
+			return socket.socket, socket.generation, data, nil, errors.WithStack(err)
+		}
+	}
+
+	return socket.socket, socket.generation, data, nil, nil
+}
+
+// received keep track of created and closed objects, translating handles of message when needed
+func (socket *reconnectSocket) received(data []byte) []byte {
+	var message rpcMessage
+	if err := jsonit.Unmarshal(data, &message); err != nil {
+		return data
+	}
+
+	socket.mu.Lock()
+	defer socket.mu.Unlock()
+
+	if message.Method == constant.EventTopicOnConnected {
+		var onConnected OnConnected
+		if err := jsonit.Unmarshal(message.Params, &onConnected); err == nil {
+			switch onConnected.SessionState {
+			case constant.OnConnectedSessionCreated, constant.OnConnectedSessionAttached:
+				socket.established = true
+			}
+		}
+	}
+
+	translated := false
+	var resultHandle *int
+	if message.ID != 0 && message.Method == "" {
+		request := socket.pending[message.ID]
+		delete(socket.pending, message.ID)
+		if engineHandle := objectHandle(message.Result); request != nil && engineHandle != nil {
+			handle := socket.handles.toClient(*engineHandle)
+			socket.addCreator(request, handle)
+			if handle != *engineHandle {
+				resultHandle = &handle
+				translated = true
+			}
+		}
+	}
+
+	for i, engineHandle := range message.Change {
+		if message.Change[i] = socket.handles.toClient(engineHandle); message.Change[i] != engineHandle {
+			translated = true
+		}
+	}
+	for i, engineHandle := range message.Close {
+		if message.Close[i] = socket.handles.toClient(engineHandle); message.Close[i] != engineHandle {
+			translated = true
+		}
+		socket.removeCreator(message.Close[i])
+		socket.handles.remove(engineHandle)
+	}
+
+	if !translated {
+		return data
+	}
+	if translatedData, err := translateMessage(data, message, resultHandle); err == nil {
+		return translatedData
+	}
+	return data
+}
+
+// addCreator of object unless object is already known
+func (socket *reconnectSocket) addCreator(request *rpcRequest, handle int) {
+	for _, c := range socket.creators {
+		if c.handle == handle {
+			return
+		}
+	}
+	socket.creators = append(socket.creators, &creator{
+		method: request.Method,
+		params: request.Params,
+		parent: request.Handle,
+		handle: handle,
+	})
+}
+
+// removeCreator of closed object
+func (socket *reconnectSocket) removeCreator(handle int) {
+	for i, c := range socket.creators {
+		if c.handle == handle {
+			socket.creators = append(socket.creators[:i], socket.creators[i+1:]...)
+			return
+		}
+	}
+}
+
+// reconnect websocket, closed with generation, unless already reconnected
+func (socket *reconnectSocket) reconnect(generation int) error {
+	socket.reconnectMu.Lock()
+	defer socket.reconnectMu.Unlock()
+
+	socket.mu.Lock()
+	if socket.closed {
+		socket.mu.Unlock()
+		return ErrSocketClosed
+	}
+	if generation != socket.generation {
+		socket.mu.Unlock()
+		return nil
+	}
+	old := socket.socket
+	socket.mu.Unlock()
+
+	// close before locking writes, unblocking any write in progress
+	_ = old.Close()
+	socket.writeMu.Lock()
+	defer socket.writeMu.Unlock()
+
+	socket.mu.Lock()
+	creators := append([]*creator(nil), socket.creators...)
+	socket.mu.Unlock()
+
+	start := time.Now()
+	var result ReconnectResult
+	var attached *attachment
+	var err error
+	for result.Attempts < socket.settings.Attempts {
+		if result.Attempts > 0 {
+			helpers.WaitFor(socket.ctx, time.Duration(socket.settings.Backoff))
+			if err = socket.ctx.Err(); err != nil {
+				break
+			}
+		}
+		result.Attempts++
+		if attached, err = socket.attach(creators); err == nil {
+			break
+		}
+	}
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Err = err
+		socket.reportReconnect(result)
+		return err
+	}
+
+	socket.mu.Lock()
+	socket.socket = attached.socket
+	socket.generation++
+
+	// no responses will be received to requests sent on closed websocket
+	ids := make([]int, 0, len(socket.pending))
+	for id := range socket.pending {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	messages := attached.pushes
+	for _, id := range ids {
+		messages = append(messages, errorResponse(id, "request lost on websocket reconnect"))
+	}
+	socket.pending = make(map[int]*rpcRequest)
+
+	result.Lost = len(ids)
+	if attached.handles != nil {
+		// objects lost on previous reconnects stay lost
+		if socket.handles != nil {
+			for handle := range socket.handles.lost {
+				attached.handles.lost[handle] = struct{}{}
+			}
+		}
+		for _, c := range creators {
+			if _, restored := attached.handles.client[c.handle]; !restored {
+				attached.handles.lost[c.handle] = struct{}{}
+				result.Lost++
+			}
+		}
+		socket.handles = attached.handles
+		socket.creators = attached.restored
+		result.Restored = len(attached.restored)
+	}
+	result.SessionState = attached.sessionState
+
+	// objects might have changed while disconnected, signal change of all objects to have them re-fetched
+	if len(socket.creators) > 0 {
+		change := make([]int, 0, len(socket.creators))
+		for _, c := range socket.creators {
+			change = append(change, c.handle)
+		}
+		if message, err := jsonit.Marshal(changeMessage{JSONRPC: "2.0", Change: change}); err == nil {
+			messages = append(messages, message)
+		}
+	}
+	socket.mu.Unlock()
+
+	socket.reportReconnect(result)
+	if !socket.inject(messages...) {
+		return ErrSocketClosed
+	}
+	return nil
+}
+
+func (socket *reconnectSocket) reportReconnect(result ReconnectResult) {
+	if socket.onReconnect != nil {
+		socket.onReconnect(result)
+	}
+}
+
+// attach connect new websocket and wait for engine session state, re-creating objects when a new engine session
+// was created
+func (socket *reconnectSocket) attach(creators []*creator) (*attachment, error) {
+	conn, err := socket.dial(socket.ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// abort attach when engine doesn't respond in time
+	timer := time.AfterFunc(socket.timeout, func() { _ = conn.Close() })
+	attached := &attachment{socket: conn}
+	if err := socket.awaitSessionState(attached); err != nil {
+		timer.Stop()
+		_ = conn.Close()
+		return nil, errors.WithStack(err)
+	}
+
+	switch attached.sessionState {
+	case constant.OnConnectedSessionAttached:
+	case constant.OnConnectedSessionCreated:
+		if err := socket.restore(attached, creators, timer); err != nil {
+			timer.Stop()
+			_ = conn.Close()
+			return nil, errors.WithStack(err)
+		}
+	default:
+		timer.Stop()
+		_ = conn.Close()
+		return nil, errors.Errorf("error connecting to engine: %s", attached.sessionState)
+	}
+
+	if !timer.Stop() {
+		return nil, errors.New("timeout reconnecting to engine")
+	}
+	return attached, nil
+}
+
+// awaitSessionState read messages until OnConnected notification is received
+func (socket *reconnectSocket) awaitSessionState(attached *attachment) error {
+	for {
+		_, data, err := attached.socket.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		var message rpcMessage
+		if err := jsonit.Unmarshal(data, &message); err != nil || message.Method == "" {
+			continue
+		}
+		attached.pushes = append(attached.pushes, data)
+		if message.Method != constant.EventTopicOnConnected {
+			continue
+		}
+
+		var onConnected OnConnected
+		if err := jsonit.Unmarshal(message.Params, &onConnected); err != nil {
+			return errors.Wrap(err, "failed to unmarshal OnConnected message")
+		}
+		attached.sessionState = onConnected.SessionState
+		return nil
+	}
+}
+
+// restore objects on new engine session by replaying the requests which created them
+func (socket *reconnectSocket) restore(attached *attachment, creators []*creator, timer *time.Timer) error {
+	attached.handles = &handleMap{client: make(map[int]int), engine: make(map[int]int), lost: make(map[int]struct{})}
+	id := replayRequestID
+	for _, c := range creators {
+		parent := c.parent
+		if parent != -1 {
+			var ok bool
+			if parent, ok = attached.handles.client[c.parent]; !ok {
+				continue // parent couldn't be re-created
+			}
+		}
+
+		id++
+		request, err := jsonit.Marshal(rpcRequest{JSONRPC: "2.0", Method: c.method, Handle: parent, ID: id, Params: c.params})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		timer.Reset(socket.timeout)
+		if err := attached.socket.WriteMessage(1, request); err != nil {
+			return err
+		}
+
+		response, err := socket.awaitResponse(attached, id)
+		if err != nil {
+			return err
+		}
+		if engineHandle := objectHandle(response.Result); len(response.Error) == 0 && engineHandle != nil {
+			attached.handles.client[c.handle] = *engineHandle
+			attached.handles.engine[*engineHandle] = c.handle
+			attached.restored = append(attached.restored, c)
+		}
+	}
+	return nil
+}
+
+// awaitResponse read messages until response to request with id is received
+func (socket *reconnectSocket) awaitResponse(attached *attachment, id int) (*rpcMessage, error) {
+	for {
+		_, data, err := attached.socket.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+
+		var message rpcMessage
+		if err := jsonit.Unmarshal(data, &message); err != nil {
+			continue
+		}
+		if message.Method != "" {
+			attached.pushes = append(attached.pushes, data)
+			continue
+		}
+		if message.ID == id {
+			return &message, nil
+		}
+	}
+}
+
+// toEngine translate client handle to handle of engine session
+func (handles *handleMap) toEngine(handle int) int {
+	if handles == nil {
+		return handle
+	}
+	if engineHandle, ok := handles.client[handle]; ok {
+		return engineHandle
+	}
+	return handle
+}
+
+// toClient translate engine handle to client handle, new engine handles are kept unless client handle is in use
+func (handles *handleMap) toClient(handle int) int {
+	if handles == nil || handle == -1 {
+		return handle
+	}
+	if clientHandle, ok := handles.engine[handle]; ok {
+		return clientHandle
+	}
+
+	clientHandle := handle
+	if handles.inUse(clientHandle) {
+		for used := range handles.client {
+			if used >= clientHandle {
+				clientHandle = used + 1
+			}
+		}
+		for lost := range handles.lost {
+			if lost >= clientHandle {
+				clientHandle = lost + 1
+			}
+		}
+	}
+	handles.client[clientHandle] = handle
+	handles.engine[handle] = clientHandle
+	return clientHandle
+}
+
+// inUse client handle is used by an object, existing or lost
+func (handles *handleMap) inUse(handle int) bool {
+	if _, used := handles.client[handle]; used {
+		return true
+	}
+	return handles.isLost(handle)
+}
+
+// isLost object with client handle couldn't be re-created
+func (handles *handleMap) isLost(handle int) bool {
+	if handles == nil {
+		return false
+	}
+	_, lost := handles.lost[handle]
+	return lost
+}
+
+// remove handle of closed engine object
+func (handles *handleMap) remove(handle int) {
+	if handles == nil {
+		return
+	}
+	if clientHandle, ok := handles.engine[handle]; ok {
+		delete(handles.client, clientHandle)
+		delete(handles.engine, handle)
+	}
+}
+
+// objectHandle engine handle of object returned in result, nil when result doesn't contain an object
+func objectHandle(result json.RawMessage) *int {
+	if !bytes.Contains(result, []byte(`"qHandle"`)) {
+		return nil
+	}
+	var object objectResult
+	if err := jsonit.Unmarshal(result, &object); err != nil || object.Return == nil {
+		return nil
+	}
+	return object.Return.Handle
+}
+
+// errorResponse engine error response to request id
+func errorResponse(id int, msg string) []byte {
+	return []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"error":{"code":%d,"parameter":"","message":"%s"}}`,
+		id, constant.LocerrGenericConnectionLost, msg))
+}
+
+// translateMessage replace handles in message
+func translateMessage(data []byte, message rpcMessage, resultHandle *int) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := jsonit.Unmarshal(data, &fields); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var err error
+	if message.Change != nil {
+		if fields["change"], err = jsonit.Marshal(message.Change); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if message.Close != nil {
+		if fields["close"], err = jsonit.Marshal(message.Close); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if resultHandle != nil {
+		var result map[string]json.RawMessage
+		if err := jsonit.Unmarshal(message.Result, &result); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		var qReturn map[string]json.RawMessage
+		if err := jsonit.Unmarshal(result["qReturn"], &qReturn); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if qReturn["qHandle"], err = jsonit.Marshal(*resultHandle); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if result["qReturn"], err = jsonit.Marshal(qReturn); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if fields["result"], err = jsonit.Marshal(result); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	translated, err := jsonit.Marshal(fields)
+	return translated, errors.WithStack(err)
+}
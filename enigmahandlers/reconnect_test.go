@@ -0,0 +1,245 @@
+package enigmahandlers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/qlik-oss/enigma-go"
+	"github.com/qlik-oss/gopherciser/globals/constant"
+)
+
+// newFakeEngine websocket server responding to OpenDoc, GetObject, GetLayout and EngineVersion. Each connection
+// reports session state of states in order, EngineVersion drops first connection. Engine handles of connections
+// after the first are offset with 10 when session is created.
+func newFakeEngine(t *testing.T, states ...string) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var connections int
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			connection := connections
+			connections++
+			mu.Unlock()
+
+			go func() {
+				defer func() { _ = conn.Close() }()
+				if _, err := ws.Upgrade(conn); err != nil {
+					t.Error(err)
+					return
+				}
+
+				state := states[len(states)-1]
+				if connection < len(states) {
+					state = states[connection]
+				}
+				if err := wsutil.WriteServerText(conn, []byte(fmt.Sprintf(
+					`{"jsonrpc":"2.0","method":"OnConnected","params":{"qSessionState":"%s"}}`, state))); err != nil {
+					return
+				}
+
+				offset := 0
+				if connection > 0 && state == constant.OnConnectedSessionCreated {
+					offset = 10
+				}
+
+				for {
+					data, err := wsutil.ReadClientText(conn)
+					if err != nil {
+						return
+					}
+					var request rpcRequest
+					if err := jsonit.Unmarshal(data, &request); err != nil {
+						t.Error(err)
+						return
+					}
+
+					var result string
+					switch request.Method {
+					case "OpenDoc":
+						result = fmt.Sprintf(`{"qReturn":{"qType":"Doc","qHandle":%d,"qGenericId":"app"}}`, offset+1)
+					case "GetObject":
+						result = fmt.Sprintf(`{"qReturn":{"qType":"GenericObject","qHandle":%d,"qGenericId":"obj"}}`, offset+2)
+					case "GetLayout":
+						result = fmt.Sprintf(`{"qLayout":{"qInfo":{"qId":"obj"},"handle":%d}}`, request.Handle)
+					case "EngineVersion":
+						if connection == 0 {
+							return // drop connection
+						}
+						result = `{"qVersion":{"qComponentVersion":"1.0"}}`
+					default:
+						t.Errorf("unexpected method<%s>", request.Method)
+						return
+					}
+
+					if err := wsutil.WriteServerText(conn, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":%s}`,
+						request.ID, result))); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return listener
+}
+
+// dialFakeEngine open object on fake engine and drop connection, returning object and reconnect result
+func dialFakeEngine(t *testing.T, ctx context.Context, url string) (*enigma.Global, *enigma.GenericObject, chan struct{}, *ReconnectResult) {
+	t.Helper()
+
+	results := make(chan ReconnectResult, 1)
+	dialer := enigma.Dialer{}
+	setupDialer(&dialer, ConnectOptions{}, 0, nil)
+	setupReconnect(ctx, &dialer, ReconnectSettings{Attempts: 2}, time.Second, func(result ReconnectResult) {
+		results <- result
+	})
+
+	global, err := dialer.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := global.OpenDoc(ctx, "app", "", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object, err := doc.GetObject(ctx, "obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := object.ChangedChannel()
+
+	if _, err := global.EngineVersion(ctx); err == nil {
+		t.Error("expected request sent on dropped connection to fail")
+	}
+
+	select {
+	case result := <-results:
+		return global, object, changed, &result
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reconnect")
+	}
+	return nil, nil, nil, nil
+}
+
+func TestReconnect(t *testing.T) {
+	for _, test := range []struct {
+		state          string
+		restored       int
+		engineHandle   int
+		reconnectError bool
+	}{
+		{constant.OnConnectedSessionCreated, 2, 12, false},
+		{constant.OnConnectedSessionAttached, 0, 2, false},
+		{constant.OnConnectedSessionErrorNoLicense, 0, 0, true},
+	} {
+		listener := newFakeEngine(t, constant.OnConnectedSessionCreated, test.state)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+
+		global, object, changed, result := dialFakeEngine(t, ctx, "ws://"+listener.Addr().String())
+		if test.reconnectError {
+			if result.Err == nil || result.Attempts != 2 {
+				t.Errorf("state<%s>: expected reconnect to fail after 2 attempts, got result<%+v>", test.state, result)
+			}
+			select {
+			case <-global.Disconnected():
+			case <-time.After(5 * time.Second):
+				t.Errorf("state<%s>: expected disconnect after failed reconnect", test.state)
+			}
+			cancel()
+			_ = listener.Close()
+			continue
+		}
+
+		if result.Err != nil || result.SessionState != test.state || result.Restored != test.restored || result.Lost != 1 {
+			t.Errorf("state<%s>: unexpected reconnect result<%+v>", test.state, result)
+		}
+
+		select {
+		case <-changed:
+		case <-time.After(5 * time.Second):
+			t.Errorf("state<%s>: object not signaled as changed after reconnect", test.state)
+		}
+
+		layout, err := object.GetLayoutRaw(ctx)
+		if err != nil {
+			t.Fatalf("state<%s>: %v", test.state, err)
+		}
+		if object.Handle != 2 || !strings.Contains(string(layout), fmt.Sprintf(`"handle":%d`, test.engineHandle)) {
+			t.Errorf("state<%s>: request sent with unexpected handle, object handle<%d> layout<%s>", test.state,
+				object.Handle, layout)
+		}
+		if _, err := global.EngineVersion(ctx); err != nil {
+			t.Errorf("state<%s>: %v", test.state, err)
+		}
+
+		global.DisconnectFromServer()
+		cancel()
+		_ = listener.Close()
+	}
+}
+
+func TestReconnectTranslateHandles(t *testing.T) {
+	socket := &reconnectSocket{
+		pending:  map[int]*rpcRequest{5: {Method: "GetObject", Handle: 1, ID: 5, Params: []byte(`["obj2"]`)}},
+		creators: []*creator{{method: "OpenDoc", parent: -1, handle: 1}, {method: "GetObject", parent: 1, handle: 2}},
+		handles: &handleMap{
+			client: map[int]int{1: 11, 2: 12},
+			engine: map[int]int{11: 1, 12: 2},
+			lost:   map[int]struct{}{3: {}},
+		},
+	}
+
+	// new engine handle 2 is used by client, as is lost handle 3
+	received := socket.received([]byte(`{"jsonrpc":"2.0","id":5,"result":{"qReturn":{"qType":"GenericObject","qHandle":2,"qGenericId":"obj2"}},"change":[11],"close":[12]}`))
+
+	var message struct {
+		Result struct {
+			Return struct {
+				Handle int `json:"qHandle"`
+			} `json:"qReturn"`
+		} `json:"result"`
+		Change []int `json:"change"`
+		Close  []int `json:"close"`
+	}
+	if err := jsonit.Unmarshal(received, &message); err != nil {
+		t.Fatal(err)
+	}
+	if message.Result.Return.Handle != 4 || len(message.Change) != 1 || message.Change[0] != 1 ||
+		len(message.Close) != 1 || message.Close[0] != 2 {
+		t.Errorf("unexpected translated message<%s>", received)
+	}
+
+	if engineHandle := socket.handles.toEngine(4); engineHandle != 2 {
+		t.Errorf("client handle<4> translated to engine handle<%d>, expected<2>", engineHandle)
+	}
+	if len(socket.creators) != 2 || socket.creators[1].handle != 4 || socket.creators[1].parent != 1 {
+		t.Errorf("unexpected creators after closing handle<2> and creating handle<4>: %+v %+v", socket.creators[0], socket.creators[1])
+	}
+	if len(socket.pending) != 0 {
+		t.Error("response not removed from pending requests")
+	}
+
+	// requests to lost objects get error response without being sent
+	_, _, _, response, err := socket.prepareWrite([]byte(`{"jsonrpc":"2.0","delta":false,"method":"GetLayout","handle":3,"id":6,"params":[]}`))
+	if err != nil || !strings.Contains(string(response), `"id":6,"error"`) {
+		t.Errorf("expected error response to request sent to lost object, got response<%s> err<%v>", response, err)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	jsoniter "github.com/json-iterator/go"
 	"github.com/pkg/errors"
 	"github.com/qlik-oss/enigma-go"
+	"github.com/qlik-oss/gopherciser/buildmetrics"
 	"github.com/qlik-oss/gopherciser/enigmainterceptors"
 	"github.com/qlik-oss/gopherciser/globals"
 	"github.com/qlik-oss/gopherciser/globals/constant"
@@ -98,6 +99,40 @@ func (connection *SenseConnection) SetSense(uplink *SenseUplink) {
 	connection.SenseUplink = uplink
 }
 
+// logCompressed update metrics and traffic log with size of compressed message
+func (uplink *SenseUplink) logCompressed(sent bool, size, compressedSize int) {
+	if sent {
+		uplink.trafficMetrics.UpdateCompressed(int64(compressedSize), 0)
+	} else {
+		uplink.trafficMetrics.UpdateCompressed(0, int64(compressedSize))
+	}
+
+	compressionLogger, ok := uplink.Traffic.(ICompressionLogger)
+	if !ok {
+		return
+	}
+	if sent {
+		compressionLogger.SentCompressed(size, compressedSize)
+	} else {
+		compressionLogger.ReceivedCompressed(size, compressedSize)
+	}
+}
+
+// logReconnect log result and time of websocket reconnect
+func (uplink *SenseUplink) logReconnect(result ReconnectResult) {
+	if result.Err != nil {
+		uplink.logEntry.LogInfo("WebsocketReconnectFailed", fmt.Sprintf("attempts<%d> time<%v> error<%v>",
+			result.Attempts, result.Duration, result.Err))
+		buildmetrics.ReportReconnect("failure", result.Duration.Seconds())
+		return
+	}
+
+	uplink.logEntry.LogInfo("WebsocketReconnect", fmt.Sprintf("state<%s> attempts<%d> time<%v> restored<%d> lost<%d>",
+		result.SessionState, result.Attempts, result.Duration, result.Restored, result.Lost))
+	uplink.logEntry.LogTrafficMetric(result.Duration.Nanoseconds(), 0, 0, -1, "Reconnect", result.SessionState, "WS")
+	buildmetrics.ReportReconnect(result.SessionState, result.Duration.Seconds())
+}
+
 // Sense implements IConnection interface
 func (connection *SenseConnection) Sense() *SenseUplink {
 	return connection.SenseUplink
@@ -105,6 +140,14 @@ func (connection *SenseConnection) Sense() *SenseUplink {
 
 // Connect connect to sense environment
 func (uplink *SenseUplink) Connect(ctx context.Context, url string, headers http.Header, cookieJar http.CookieJar, allowUntrusted bool, timeout time.Duration) error {
+	return uplink.ConnectWithOptions(ctx, url, headers, cookieJar, timeout, ConnectOptions{
+		TLSConfig: &tls.Config{InsecureSkipVerify: allowUntrusted},
+	})
+}
+
+// ConnectWithOptions connect to sense environment using TLS configuration, e.g. with client certificate, proxy and
+// emulated network conditions
+func (uplink *SenseUplink) ConnectWithOptions(ctx context.Context, url string, headers http.Header, cookieJar http.CookieJar, timeout time.Duration, options ConnectOptions) error {
 	if uplink.Global != nil {
 		uplink.Global.DisconnectFromServer()
 		uplink.Global = nil
@@ -118,16 +161,17 @@ func (uplink *SenseUplink) Connect(ctx context.Context, url string, headers http
 			}).MetricsInterceptor,
 			uplink.retryInterceptor,
 		},
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: allowUntrusted,
-		},
+		TLSClientConfig: options.TLSConfig,
 	}
 	if cookieJar != nil {
 		dialer.Jar = cookieJar
 	}
 	dialer.TrafficLogger = uplink.Traffic
 
-	setupDialer(&dialer, timeout)
+	setupDialer(&dialer, options, timeout, uplink.logCompressed)
+	if options.Reconnect != nil && !uplink.MockMode {
+		setupReconnect(uplink.ctx, &dialer, *options.Reconnect, timeout, uplink.logReconnect)
+	}
 
 	// TODO somehow get better values for connect time
 	startTimestamp := time.Now()
@@ -2,6 +2,9 @@ package enigmahandlers
 
 import (
 	"context"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	neturl "net/url"
@@ -12,20 +15,62 @@ import (
 	"github.com/gobwas/ws/wsutil"
 	"github.com/pkg/errors"
 	"github.com/qlik-oss/enigma-go"
+	"github.com/qlik-oss/gopherciser/netemulation"
 )
 
-// SenseDialer glue between net.Conn and enigma.Socket implementing required methods
-type SenseDialer struct {
-	net.Conn
-}
+type (
+	// SenseDialer glue between net.Conn and enigma.Socket implementing required methods
+	SenseDialer struct {
+		net.Conn
+
+		// deflate negotiated permessage-deflate extension, nil when messages are sent uncompressed
+		deflate *permessageDeflate
+		// onCompressed called with uncompressed and compressed size of messages when using compression
+		onCompressed func(sent bool, size, compressedSize int)
+	}
+
+	// ConnectOptions options of websocket connection
+	ConnectOptions struct {
+		// TLSConfig TLS configuration, e.g. with client certificate
+		TLSConfig *tls.Config
+		// ProxyURL proxy to connect through, nil connects directly
+		ProxyURL *neturl.URL
+		// Network emulated network conditions, nil doesn't emulate network conditions
+		Network *netemulation.Conditions
+		// Compression negotiate permessage-deflate compression of messages
+		Compression bool
+		// CompressionLevel compression level (1-9) of sent messages, 0 uses default level
+		CompressionLevel int
+		// Reconnect reconnect policy on unexpected close of websocket, nil doesn't reconnect
+		Reconnect *ReconnectSettings
+	}
+)
 
 // WriteMessage Write message to a frame on the websocket
 func (dialer *SenseDialer) WriteMessage(messageType int, data []byte) error {
-	return wsutil.WriteClientMessage(dialer, ws.OpCode(messageType), data)
+	if dialer.deflate == nil || ws.OpCode(messageType).IsControl() {
+		return wsutil.WriteClientMessage(dialer, ws.OpCode(messageType), data)
+	}
+
+	compressed, err := dialer.deflate.compress(data)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if dialer.onCompressed != nil {
+		dialer.onCompressed(true, len(data), len(compressed))
+	}
+
+	frame := ws.NewFrame(ws.OpCode(messageType), true, compressed)
+	frame.Header.Rsv = ws.Rsv(true, false, false)
+	return ws.WriteFrame(dialer, ws.MaskFrameInPlace(frame))
 }
 
 // ReadMessage Read one entire message from websocket
 func (dialer *SenseDialer) ReadMessage() (int, []byte, error) {
+	if dialer.deflate != nil {
+		return dialer.readCompressedMessage()
+	}
+
 	var msg []wsutil.Message
 	var err error
 	msg, err = wsutil.ReadServerMessage(dialer, msg)
@@ -43,10 +88,60 @@ func (dialer *SenseDialer) Close() error {
 	return dialer.Conn.Close()
 }
 
-func setupDialer(dialer *enigma.Dialer, timeout time.Duration) {
+// readCompressedMessage read one entire message, decompressing it when compressed by server
+func (dialer *SenseDialer) readCompressedMessage() (int, []byte, error) {
+	var msg []wsutil.Message
+	reader := wsutil.Reader{
+		Source: dialer,
+		State:  ws.StateClientSide | ws.StateExtended,
+		OnIntermediate: func(hdr ws.Header, src io.Reader) error {
+			payload, err := ioutil.ReadAll(src)
+			if err != nil {
+				return err
+			}
+			msg = append(msg, wsutil.Message{OpCode: hdr.OpCode, Payload: payload})
+			return nil
+		},
+	}
+
+	hdr, err := reader.NextFrame()
+	if err != nil {
+		return len(msg), nil, err
+	}
+	payload, err := ioutil.ReadAll(&reader)
+	if err != nil {
+		return len(msg), nil, err
+	}
+	if !hdr.OpCode.IsControl() {
+		compressedSize := len(payload)
+		if hdr.Rsv1() {
+			if payload, err = dialer.deflate.decompress(payload); err != nil {
+				return len(msg), nil, errors.WithStack(err)
+			}
+		}
+		if dialer.onCompressed != nil {
+			dialer.onCompressed(false, len(payload), compressedSize)
+		}
+	}
+	msg = append(msg, wsutil.Message{OpCode: hdr.OpCode, Payload: payload})
+
+	var data []byte
+	for _, m := range msg {
+		data = append(data, m.Payload...)
+	}
+
+	return len(msg), data, nil
+}
+
+func setupDialer(dialer *enigma.Dialer, options ConnectOptions, timeout time.Duration, onCompressed func(sent bool, size, compressedSize int)) {
 	if timeout.Nanoseconds() < 1 {
 		timeout = 30 * time.Second
 	}
+	netDial := options.Network.DialContext((&net.Dialer{}).DialContext)
+	if options.ProxyURL != nil {
+		netDial = proxyDialContext(options.ProxyURL, dialer.TLSClientConfig, netDial)
+	}
+
 	dialer.CreateSocket = func(ctx context.Context, url string, httpHeader http.Header) (enigma.Socket, error) {
 		u, err := neturl.Parse(url)
 		if err != nil {
@@ -89,17 +184,26 @@ func setupDialer(dialer *enigma.Dialer, timeout time.Duration) {
 				}
 				return nil
 			},
-			NetDial: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				return (&net.Dialer{}).DialContext(ctx, network, addr)
-			},
+			NetDial:   netDial,
 			TLSConfig: dialer.TLSClientConfig,
 		}
+		if options.Compression {
+			wsDialer.Extensions = deflateOffers()
+		}
 
-		conn, _ /* br*/, _ /*hs*/, err := wsDialer.Dial(ctx, url)
+		conn, br, hs, err := wsDialer.Dial(ctx, url)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
+		if br != nil {
+			// frames sent directly after handshake, e.g. OnConnected, might have been read into br
+			conn = &bufferedConn{Conn: conn, reader: br}
+		}
 
-		return &SenseDialer{conn}, nil
+		return &SenseDialer{
+			Conn:         conn,
+			deflate:      newPermessageDeflate(hs.Extensions, options.CompressionLevel),
+			onCompressed: onCompressed,
+		}, nil
 	}
 }
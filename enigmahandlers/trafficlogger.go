@@ -1,6 +1,8 @@
 package enigmahandlers
 
 import (
+	"fmt"
+
 	"github.com/qlik-oss/gopherciser/atomichandlers"
 	"github.com/qlik-oss/gopherciser/globals"
 	"github.com/qlik-oss/gopherciser/logger"
@@ -42,6 +44,16 @@ func (tl *TrafficLogger) Received(message []byte) {
 	tl.LogEntry.LogDetail(logger.TrafficLevel, string(message), "Received")
 }
 
+// SentCompressed log size of compressed message sent on socket
+func (tl *TrafficLogger) SentCompressed(size, compressedSize int) {
+	tl.LogEntry.LogDetail(logger.TrafficLevel, fmt.Sprintf("size<%d> compressed<%d>", size, compressedSize), "SentCompressed")
+}
+
+// ReceivedCompressed log size of compressed message received on socket
+func (tl *TrafficLogger) ReceivedCompressed(size, compressedSize int) {
+	tl.LogEntry.LogDetail(logger.TrafficLevel, fmt.Sprintf("size<%d> compressed<%d>", size, compressedSize), "ReceivedCompressed")
+}
+
 // Closed log socket closed
 func (tl *TrafficLogger) Closed() {
 	tl.LogEntry.Log(logger.TrafficLevel, "Socket Closed")
@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/qlik-oss/gopherciser/config"
 	"github.com/qlik-oss/gopherciser/scenario"
+	"github.com/qlik-oss/gopherciser/scheduler"
 )
 
 type (
@@ -67,6 +68,43 @@ func Actions() map[string]interface{} {
 	return actionMap
 }
 
+// SchedulerStrings all registered schedulers
+func SchedulerStrings() []string {
+	return sortedKeys(Schedulers())
+}
+
+// Schedulers all registered schedulers with scheduler settings
+func Schedulers() map[string]interface{} {
+	schedulers := scheduler.RegisteredSchedulers()
+	schedulerMap := make(map[string]interface{}, len(schedulers))
+
+	// fill all schedulers to map with the "settings" part of the scheduler
+	for _, name := range schedulers {
+		sched := scheduler.NewScheduler(name)
+		if sched == nil {
+			continue
+		}
+		schedulerMap[name] = schedulerSettings(sched)
+	}
+
+	return schedulerMap
+}
+
+// schedulerSettings returns the field with json tag "settings" of scheduler, or the scheduler itself if it has no such field
+func schedulerSettings(sched interface{}) interface{} {
+	value := reflect.Indirect(reflect.ValueOf(sched))
+	if value.Kind() != reflect.Struct {
+		return sched
+	}
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if strings.Split(field.Tag.Get("json"), ",")[0] == "settings" && value.Field(i).CanInterface() {
+			return value.Field(i).Interface()
+		}
+	}
+	return sched
+}
+
 // FieldsString config fields sections
 func FieldsString() ([]string, error) {
 	fields, err := Fields()
@@ -105,6 +143,11 @@ func Fields() (map[string]interface{}, error) {
 		handleConfigValue(cfgValue.Type().Field(i), cfgValue.Field(i), configFields)
 	}
 
+	// settings are documented per scheduler, only document fields common to all schedulers in scheduler section
+	if _, ok := configFields["scheduler"]; ok {
+		configFields["scheduler"] = scheduler.Scheduler{}
+	}
+
 	return configFields, nil
 }
 
@@ -28,6 +28,7 @@ const (
 	ExitCodeFailedParseTemplate
 	ExitCodeFailedExecuteTemplate
 	ExitCodeFailedCreateExtra
+	ExitCodeFailedHandleScheduler
 )
 
 type (
@@ -36,6 +37,8 @@ type (
 		Groups       []common.GroupsEntry
 		Actions      []string
 		ActionMap    map[string]common.DocEntry
+		Schedulers   []string
+		SchedulerMap map[string]common.DocEntry
 		ConfigFields []string
 		ConfigMap    map[string]common.DocEntry
 		Extra        []string
@@ -108,6 +111,18 @@ func compile() {
 		data.ActionMap[action] = actionDocEntry
 	}
 
+	// Get all schedulers
+	data.Schedulers = common.SchedulerStrings()
+	data.SchedulerMap = make(map[string]common.DocEntry, len(data.Schedulers))
+	for _, sched := range data.Schedulers {
+		schedulerDocEntry, err := CreateSchedulerDocEntry(sched)
+		if err != nil {
+			common.Exit(err, ExitCodeFailedHandleScheduler)
+		}
+
+		data.SchedulerMap[sched] = schedulerDocEntry
+	}
+
 	// Get all config fields
 	fields, err := common.FieldsString()
 	if err != nil {
@@ -174,6 +189,11 @@ func CreateActionDocEntry(action string) (common.DocEntry, error) {
 	return CreateDocEntry([]string{"actions", action})
 }
 
+// CreateSchedulerDocEntry create DocEntry from schedulers sub directory
+func CreateSchedulerDocEntry(scheduler string) (common.DocEntry, error) {
+	return CreateDocEntry([]string{"schedulers", scheduler})
+}
+
 // CreateConfigDocEntry create DocEntry from config sub directory
 func CreateConfigDocEntry(field string) (common.DocEntry, error) {
 	return CreateDocEntry([]string{"config", field})
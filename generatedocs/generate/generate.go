@@ -18,13 +18,15 @@ import (
 
 type (
 	Data struct {
-		ActionFields map[string]interface{}
-		Actions      map[string]common.DocEntry
-		Params       map[string][]string
-		ConfigFields map[string]interface{}
-		Config       map[string]common.DocEntry
-		Groups       []common.GroupsEntry
-		Extra        map[string]common.DocEntry
+		ActionFields    map[string]interface{}
+		Actions         map[string]common.DocEntry
+		SchedulerFields map[string]interface{}
+		Schedulers      map[string]common.DocEntry
+		Params          map[string][]string
+		ConfigFields    map[string]interface{}
+		Config          map[string]common.DocEntry
+		Groups          []common.GroupsEntry
+		Extra           map[string]common.DocEntry
 	}
 )
 
@@ -40,17 +42,19 @@ const (
 
 var (
 	data = Data{
-		Actions: generated.Actions,
-		Params:  generated.Params,
-		Config:  generated.Config,
-		Groups:  generated.Groups,
-		Extra:   generated.Extra,
+		Actions:    generated.Actions,
+		Schedulers: generated.Schedulers,
+		Params:     generated.Params,
+		Config:     generated.Config,
+		Groups:     generated.Groups,
+		Extra:      generated.Extra,
 	}
 	templatePath, output string
 	funcMap              = template.FuncMap{
 		"join":      strings.Join,
 		"params":    handleParams,
 		"ungrouped": UngroupedActions,
+		"sorted":    SortedKeys,
 	}
 )
 
@@ -93,6 +97,7 @@ func generate() {
 	}
 
 	data.ActionFields = common.Actions()
+	data.SchedulerFields = common.Schedulers()
 
 	buf := bytes.NewBuffer(nil)
 	if err := documentationTemplate.Execute(buf, data); err != nil {
@@ -248,3 +253,13 @@ func UngroupedActions() []string {
 	sort.Strings(actions)
 	return actions
 }
+
+// SortedKeys returns keys of map sorted
+func SortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -38,3 +38,10 @@ const (
 	DataReductionModeClustered = "C"
 	DataReductionModeStacked   = "ST"
 )
+
+// SearchContext
+const (
+	SearchContextCleared           = "Cleared"
+	SearchContextLockedFieldsOnly  = "LockedFieldsOnly"
+	SearchContextCurrentSelections = "CurrentSelections"
+)
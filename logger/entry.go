@@ -41,6 +41,9 @@ type (
 		Details      string
 		InfoType     string
 		RequestsSent uint64
+		// CompressedSent and CompressedReceived size of data on websocket when using compression
+		CompressedSent     uint64
+		CompressedReceived uint64
 	}
 
 	//LogEntry entry used for logging
@@ -133,20 +136,22 @@ func (entry *LogEntry) LogDetail(level LogLevel, msg, detail string) {
 }
 
 // LogResult log result entry
-func (entry *LogEntry) LogResult(success bool, warnings, errors, sent, received, requests uint64, responsetime int64, details string) {
+func (entry *LogEntry) LogResult(success bool, warnings, errors, sent, received, compressedSent, compressedReceived, requests uint64, responsetime int64, details string) {
 	if entry == nil {
 		return
 	}
 
 	entry.log(ResultLevel, "", &ephemeralEntry{
-		Success:      success,
-		Warnings:     warnings,
-		Errors:       errors,
-		Sent:         sent,
-		Received:     received,
-		RequestsSent: requests,
-		ResponseTime: responsetime,
-		Details:      details,
+		Success:            success,
+		Warnings:           warnings,
+		Errors:             errors,
+		Sent:               sent,
+		Received:           received,
+		CompressedSent:     compressedSent,
+		CompressedReceived: compressedReceived,
+		RequestsSent:       requests,
+		ResponseTime:       responsetime,
+		Details:            details,
 	})
 }
 
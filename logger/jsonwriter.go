@@ -92,6 +92,8 @@ func setEphemeralFields(ev *zerolog.Event, e *ephemeralEntry, level LogLevel) {
 	ev.Str(FieldStack, e.Stack)
 	if level == ResultLevel {
 		ev.Bool(FieldSuccess, e.Success)
+		ev.Uint64(FieldCompressedSent, e.CompressedSent)
+		ev.Uint64(FieldCompressedReceived, e.CompressedReceived)
 	}
 	ev.Uint64(FieldWarnings, e.Warnings)
 	ev.Int64(FieldResponseTime, e.ResponseTime)
@@ -43,6 +43,10 @@ const (
 	FieldInfoType = "InfoType"
 	// FieldRequestsSent - request counter
 	FieldRequestsSent = "RequestsSent"
+	// FieldCompressedSent - bytes sent on websocket when using compression
+	FieldCompressedSent = "CompressedSent"
+	// FieldCompressedReceived - bytes received on websocket when using compression
+	FieldCompressedReceived = "CompressedReceived"
 	// FieldTime - logging time
 	FieldTime = "time"
 	// FieldTimestamp - to be used for time without timezone for G3 compliance
@@ -57,5 +61,5 @@ var (
 	//AllFields for logging (i.e. use as headers)
 	AllFields = []string{FieldTime, FieldAction, FieldLabel, FieldActionID, FieldLevel, FieldInfoType, FieldMessage, FieldDetails, FieldSuccess, FieldResponseTime,
 		FieldAppName, FieldAppGUID, FieldAuthUser, FieldThread, FieldSession, FieldSessionName, FieldTick,
-		FieldObjectType, FieldWarnings, FieldErrors, FieldStack, FieldSent, FieldReceived, FieldRequestsSent, FieldTimestamp}
+		FieldObjectType, FieldWarnings, FieldErrors, FieldStack, FieldSent, FieldReceived, FieldRequestsSent, FieldCompressedSent, FieldCompressedReceived, FieldTimestamp}
 )
@@ -125,6 +125,10 @@ func (writer *TSVWriter) WriteMessage(msg *LogChanMsg) error {
 			buf.WriteString(replacer.Replace(msg.InfoType))
 		case FieldRequestsSent:
 			buf.WriteString(strconv.FormatUint(msg.RequestsSent, 10))
+		case FieldCompressedSent:
+			buf.WriteString(strconv.FormatUint(msg.CompressedSent, 10))
+		case FieldCompressedReceived:
+			buf.WriteString(strconv.FormatUint(msg.CompressedReceived, 10))
 		case FieldTime:
 			buf.WriteString(msg.Time.Format(time.RFC3339Nano))
 		case FieldTimestamp:
@@ -63,5 +63,81 @@ var GopherActionLatencyHist = prometheus.NewHistogramVec(
 	[]string{"action"},
 )
 
+// GopherPersonaActions action counter per persona
+var GopherPersonaActions = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "gopherciser_persona_actions_total",
+		Help: "Number of gopherciser actions and their result per persona.",
+	},
+	[]string{"result", "persona"},
+)
+
+// GopherPersonaLatencyHist is a histogram tracking the response times of actions per persona
+var GopherPersonaLatencyHist = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "gopherciser_persona_response_times_seconds",
+		Help:    "latency of actions per persona",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 2, 4},
+	},
+	[]string{"persona"},
+)
+
+// GopherNodeActions action counter per server node
+var GopherNodeActions = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "gopherciser_node_actions_total",
+		Help: "Number of gopherciser actions and their result per server node.",
+	},
+	[]string{"result", "node"},
+)
+
+// GopherNodeLatencyHist is a histogram tracking the response times of actions per server node
+var GopherNodeLatencyHist = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "gopherciser_node_response_times_seconds",
+		Help:    "latency of actions per server node",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 2, 4},
+	},
+	[]string{"node"},
+)
+
+// GopherReconnects websocket reconnect counter per result
+var GopherReconnects = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "gopherciser_reconnects_total",
+		Help: "Number of websocket reconnects per engine session state or failure.",
+	},
+	[]string{"result"},
+)
+
+// GopherReconnectLatencyHist is a histogram tracking the time of websocket reconnects
+var GopherReconnectLatencyHist = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "gopherciser_reconnect_times_seconds",
+		Help:    "time of websocket reconnects",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 2, 4},
+	},
+)
+
+// GopherExportLatencyHist is a histogram tracking the time of object data exports per file type
+var GopherExportLatencyHist = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "gopherciser_export_times_seconds",
+		Help:    "time of object data exports",
+		Buckets: []float64{0.1, 0.5, 1, 5, 15, 60},
+	},
+	[]string{"filetype"},
+)
+
+// GopherExportSizeHist is a histogram tracking the size of exported object data files per file type
+var GopherExportSizeHist = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "gopherciser_export_size_bytes",
+		Help:    "size of exported object data files",
+		Buckets: prometheus.ExponentialBuckets(1024, 4, 10),
+	},
+	[]string{"filetype"},
+)
+
 //GopherRegistry registers the metrics in a registry to be used for prometheus push
 var gopherRegistry = prometheus.NewRegistry()
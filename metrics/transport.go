@@ -22,6 +22,14 @@ func setupMetrics(actions []string) error {
 	prometheus.MustRegister(GopherActiveUsers)
 	prometheus.MustRegister(GopherResponseTimes)
 	prometheus.MustRegister(GopherActionLatencyHist)
+	prometheus.MustRegister(GopherPersonaActions)
+	prometheus.MustRegister(GopherPersonaLatencyHist)
+	prometheus.MustRegister(GopherNodeActions)
+	prometheus.MustRegister(GopherNodeLatencyHist)
+	prometheus.MustRegister(GopherReconnects)
+	prometheus.MustRegister(GopherReconnectLatencyHist)
+	prometheus.MustRegister(GopherExportLatencyHist)
+	prometheus.MustRegister(GopherExportSizeHist)
 
 	err := gopherRegistry.Register(GopherActions)
 	if err != nil {
@@ -51,6 +59,38 @@ func setupMetrics(actions []string) error {
 	if err != nil {
 		return err
 	}
+	err = gopherRegistry.Register(GopherPersonaActions)
+	if err != nil {
+		return err
+	}
+	err = gopherRegistry.Register(GopherPersonaLatencyHist)
+	if err != nil {
+		return err
+	}
+	err = gopherRegistry.Register(GopherNodeActions)
+	if err != nil {
+		return err
+	}
+	err = gopherRegistry.Register(GopherNodeLatencyHist)
+	if err != nil {
+		return err
+	}
+	err = gopherRegistry.Register(GopherReconnects)
+	if err != nil {
+		return err
+	}
+	err = gopherRegistry.Register(GopherReconnectLatencyHist)
+	if err != nil {
+		return err
+	}
+	err = gopherRegistry.Register(GopherExportLatencyHist)
+	if err != nil {
+		return err
+	}
+	err = gopherRegistry.Register(GopherExportSizeHist)
+	if err != nil {
+		return err
+	}
 
 	// Initialize metrics
 	for _, action := range actions {
@@ -0,0 +1,67 @@
+package netemulation
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/helpers"
+)
+
+type (
+	// Conditions emulated network conditions of connections, e.g. of a branch office client on a poor link
+	Conditions struct {
+		// Latency added one-way latency, i.e. added to both sent and received data
+		Latency helpers.TimeDuration `json:"latency,omitempty" doc-key:"config.connectionSettings.network.latency"`
+		// Jitter random variation of latency, latency varies uniformly within +/- jitter
+		Jitter helpers.TimeDuration `json:"jitter,omitempty" doc-key:"config.connectionSettings.network.jitter"`
+		// DownloadKbps bandwidth cap of received data in kbit/s, 0 is unlimited
+		DownloadKbps int `json:"downloadkbps,omitempty" doc-key:"config.connectionSettings.network.downloadkbps"`
+		// UploadKbps bandwidth cap of sent data in kbit/s, 0 is unlimited
+		UploadKbps int `json:"uploadkbps,omitempty" doc-key:"config.connectionSettings.network.uploadkbps"`
+		// DropInterval average time until a connection is dropped, 0 never drops connections
+		DropInterval helpers.TimeDuration `json:"dropinterval,omitempty" doc-key:"config.connectionSettings.network.dropinterval"`
+	}
+
+	// DialFunc dial function as used by net.Dialer and http.Transport
+	DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+)
+
+// Validate network conditions
+func (conditions *Conditions) Validate() error {
+	if conditions.Latency < 0 || conditions.Jitter < 0 || conditions.DropInterval < 0 {
+		return errors.New("network latency, jitter and dropinterval can not be negative")
+	}
+	if conditions.DownloadKbps < 0 || conditions.UploadKbps < 0 {
+		return errors.New("network downloadkbps and uploadkbps can not be negative")
+	}
+	return nil
+}
+
+// DialContext wrap dial function to return connections emulating network conditions. Conditions being nil returns
+// dial function as is.
+func (conditions *Conditions) DialContext(dial DialFunc) DialFunc {
+	if conditions == nil {
+		return dial
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return NewConn(conn, conditions), nil
+	}
+}
+
+// delay of transferred data, latency with jitter applied
+func (conditions *Conditions) delay(jitter func(max time.Duration) time.Duration) time.Duration {
+	delay := time.Duration(conditions.Latency)
+	if conditions.Jitter > 0 {
+		delay += jitter(time.Duration(conditions.Jitter))
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
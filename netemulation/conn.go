@@ -0,0 +1,353 @@
+package netemulation
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/randomizer"
+)
+
+type (
+	// Conn connection emulating network conditions. Received data is read ahead from the underlying connection and
+	// delivered after latency, sent data is queued and written to the underlying connection after latency. Both
+	// directions are paced according to bandwidth caps.
+	Conn struct {
+		net.Conn
+
+		conditions Conditions
+		rnd        *randomizer.Randomizer
+		rndLock    sync.Mutex
+
+		received chan chunk
+		pending  chunk
+		readLock sync.Mutex
+		download *limiter
+
+		sent      chan chunk
+		lastSent  time.Time
+		writeLock sync.Mutex
+		writeErr  atomic.Value
+		upload    *limiter
+
+		deadlineLock  sync.Mutex
+		readDeadline  time.Time
+		writeDeadline time.Time
+
+		closed    chan struct{}
+		closeOnce sync.Once
+		dropped   int32
+		dropTimer *time.Timer
+	}
+
+	// chunk of data, or error, transferred at readyAt
+	chunk struct {
+		data    []byte
+		err     error
+		readyAt time.Time
+	}
+
+	// limiter paces transferred data according to bandwidth
+	limiter struct {
+		bytesPerSecond float64
+		next           time.Time
+		lock           sync.Mutex
+	}
+
+	timeoutError struct{}
+)
+
+const (
+	chunkSize  = 32 * 1024
+	queueSize  = 64
+	bitsInKbit = 1000
+)
+
+var (
+	// ErrConnectionDropped connection was dropped by network emulation
+	ErrConnectionDropped = errors.New("connection dropped by network emulation")
+	// ErrConnectionClosed use of closed connection
+	ErrConnectionClosed = errors.New("use of closed network connection")
+)
+
+// NewConn wrap connection to emulate network conditions
+func NewConn(conn net.Conn, conditions *Conditions) *Conn {
+	emulated := &Conn{
+		Conn:       conn,
+		conditions: *conditions,
+		rnd:        randomizer.NewRandomizer(),
+		received:   make(chan chunk, queueSize),
+		sent:       make(chan chunk, queueSize),
+		download:   newLimiter(conditions.DownloadKbps),
+		upload:     newLimiter(conditions.UploadKbps),
+		closed:     make(chan struct{}),
+	}
+
+	if conditions.DropInterval > 0 {
+		emulated.rndLock.Lock()
+		dropAfter := time.Duration(emulated.rnd.ExpFloat64() * float64(conditions.DropInterval))
+		emulated.rndLock.Unlock()
+		emulated.dropTimer = time.AfterFunc(dropAfter, emulated.drop)
+	}
+
+	go emulated.readLoop()
+	go emulated.writeLoop()
+
+	return emulated
+}
+
+// Read received data once latency has passed
+func (conn *Conn) Read(b []byte) (int, error) {
+	conn.readLock.Lock()
+	defer conn.readLock.Unlock()
+
+	if len(conn.pending.data) < 1 && conn.pending.err == nil {
+		deadline, cancel := conn.deadlineTimer(conn.getReadDeadline())
+		defer cancel()
+		select {
+		case conn.pending = <-conn.received:
+		case <-deadline:
+			return 0, timeoutError{}
+		case <-conn.closed:
+			return 0, ErrConnectionClosed
+		}
+	}
+
+	if err := conn.waitUntil(conn.pending.readyAt, conn.getReadDeadline()); err != nil {
+		return 0, err
+	}
+	if len(conn.pending.data) < 1 {
+		return 0, conn.pending.err
+	}
+
+	n := copy(b, conn.pending.data)
+	conn.pending.data = conn.pending.data[n:]
+	if err := conn.waitUntil(conn.download.reserve(n), time.Time{}); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Write queue data to be sent once latency has passed
+func (conn *Conn) Write(b []byte) (int, error) {
+	if err, ok := conn.writeErr.Load().(error); ok {
+		return 0, err
+	}
+
+	conn.writeLock.Lock()
+	defer conn.writeLock.Unlock()
+
+	// keep order of sent data regardless of jitter
+	readyAt := time.Now().Add(conn.conditions.delay(conn.jitter))
+	if readyAt.Before(conn.lastSent) {
+		readyAt = conn.lastSent
+	}
+	conn.lastSent = readyAt
+
+	data := make([]byte, len(b))
+	copy(data, b)
+
+	deadline, cancel := conn.deadlineTimer(conn.getWriteDeadline())
+	defer cancel()
+	select {
+	case conn.sent <- chunk{data: data, readyAt: readyAt}:
+		return len(b), nil
+	case <-deadline:
+		return 0, timeoutError{}
+	case <-conn.closed:
+		return 0, ErrConnectionClosed
+	}
+}
+
+// Close connection, data queued to be sent is discarded
+func (conn *Conn) Close() error {
+	var err error
+	conn.closeOnce.Do(func() {
+		close(conn.closed)
+		if conn.dropTimer != nil {
+			conn.dropTimer.Stop()
+		}
+		err = conn.Conn.Close()
+	})
+	return err
+}
+
+// SetDeadline set read and write deadlines of emulated connection
+func (conn *Conn) SetDeadline(t time.Time) error {
+	conn.deadlineLock.Lock()
+	defer conn.deadlineLock.Unlock()
+	conn.readDeadline = t
+	conn.writeDeadline = t
+	return nil
+}
+
+// SetReadDeadline set read deadline of emulated connection
+func (conn *Conn) SetReadDeadline(t time.Time) error {
+	conn.deadlineLock.Lock()
+	defer conn.deadlineLock.Unlock()
+	conn.readDeadline = t
+	return nil
+}
+
+// SetWriteDeadline set write deadline of emulated connection
+func (conn *Conn) SetWriteDeadline(t time.Time) error {
+	conn.deadlineLock.Lock()
+	defer conn.deadlineLock.Unlock()
+	conn.writeDeadline = t
+	return nil
+}
+
+// readLoop read ahead from underlying connection, setting time when read data is ready to be received
+func (conn *Conn) readLoop() {
+	var lastReceived time.Time
+	for {
+		buf := make([]byte, chunkSize)
+		n, err := conn.Conn.Read(buf)
+		if n > 0 {
+			readyAt := time.Now().Add(conn.conditions.delay(conn.jitter))
+			if readyAt.Before(lastReceived) {
+				readyAt = lastReceived
+			}
+			lastReceived = readyAt
+
+			if !conn.queue(conn.received, chunk{data: buf[:n], readyAt: readyAt}) {
+				return
+			}
+		}
+		if err != nil {
+			if atomic.LoadInt32(&conn.dropped) == 1 {
+				err = ErrConnectionDropped
+			}
+			conn.queue(conn.received, chunk{err: err, readyAt: lastReceived})
+			return
+		}
+	}
+}
+
+// writeLoop write queued data to underlying connection once ready
+func (conn *Conn) writeLoop() {
+	for {
+		select {
+		case sent := <-conn.sent:
+			if err := conn.waitUntil(sent.readyAt, time.Time{}); err != nil {
+				return
+			}
+			if err := conn.waitUntil(conn.upload.reserve(len(sent.data)), time.Time{}); err != nil {
+				return
+			}
+			if _, err := conn.Conn.Write(sent.data); err != nil {
+				if atomic.LoadInt32(&conn.dropped) == 1 {
+					err = ErrConnectionDropped
+				}
+				conn.writeErr.Store(err)
+				return
+			}
+		case <-conn.closed:
+			return
+		}
+	}
+}
+
+// queue chunk, returns false if connection was closed
+func (conn *Conn) queue(queue chan chunk, c chunk) bool {
+	select {
+	case queue <- c:
+		return true
+	case <-conn.closed:
+		return false
+	}
+}
+
+// drop underlying connection, emulated connection reports ErrConnectionDropped
+func (conn *Conn) drop() {
+	atomic.StoreInt32(&conn.dropped, 1)
+	_ = conn.Conn.Close()
+}
+
+// waitUntil time t, zero deadline means no deadline
+func (conn *Conn) waitUntil(t time.Time, deadline time.Time) error {
+	wait := time.Until(t)
+	if wait <= 0 {
+		return nil
+	}
+	if !deadline.IsZero() && deadline.Before(t) {
+		wait = time.Until(deadline)
+		if wait <= 0 {
+			return timeoutError{}
+		}
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return timeoutError{}
+		case <-conn.closed:
+			return ErrConnectionClosed
+		}
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-conn.closed:
+		return ErrConnectionClosed
+	}
+}
+
+// deadlineTimer channel triggered at deadline, never triggered for zero deadline
+func (conn *Conn) deadlineTimer(deadline time.Time) (<-chan time.Time, func()) {
+	if deadline.IsZero() {
+		return nil, func() {}
+	}
+	timer := time.NewTimer(time.Until(deadline))
+	return timer.C, func() { timer.Stop() }
+}
+
+func (conn *Conn) getReadDeadline() time.Time {
+	conn.deadlineLock.Lock()
+	defer conn.deadlineLock.Unlock()
+	return conn.readDeadline
+}
+
+func (conn *Conn) getWriteDeadline() time.Time {
+	conn.deadlineLock.Lock()
+	defer conn.deadlineLock.Unlock()
+	return conn.writeDeadline
+}
+
+// jitter uniformly distributed within +/- max
+func (conn *Conn) jitter(max time.Duration) time.Duration {
+	conn.rndLock.Lock()
+	defer conn.rndLock.Unlock()
+	return time.Duration(conn.rnd.Rand(int(2*max)+1)) - max
+}
+
+func newLimiter(kbps int) *limiter {
+	if kbps < 1 {
+		return nil
+	}
+	return &limiter{bytesPerSecond: float64(kbps) * bitsInKbit / 8}
+}
+
+// reserve bandwidth for n bytes, returns time when transfer is done
+func (l *limiter) reserve(n int) time.Time {
+	now := time.Now()
+	if l == nil {
+		return now
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(float64(n) / l.bytesPerSecond * float64(time.Second)))
+	return l.next
+}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -0,0 +1,139 @@
+package netemulation
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/qlik-oss/gopherciser/helpers"
+)
+
+// echoServer accepting connections echoing received data
+func echoServer(t *testing.T) net.Listener {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener
+}
+
+// dial echo server emulating network conditions, returned function closes connection and server
+func dial(t *testing.T, conditions *Conditions) (net.Conn, func()) {
+	t.Helper()
+	listener := echoServer(t)
+	conn, err := conditions.DialContext((&net.Dialer{}).DialContext)(context.Background(), "tcp", listener.Addr().String())
+	if err != nil {
+		_ = listener.Close()
+		t.Fatal(err)
+	}
+	return conn, func() {
+		_ = conn.Close()
+		_ = listener.Close()
+	}
+}
+
+func TestLatency(t *testing.T) {
+	conn, closeConn := dial(t, &Conditions{
+		Latency: helpers.TimeDuration(50 * time.Millisecond),
+		Jitter:  helpers.TimeDuration(10 * time.Millisecond),
+	})
+	defer closeConn()
+
+	buf := make([]byte, 4)
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		if _, err := conn.Write([]byte("ping")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			t.Fatal(err)
+		}
+		// latency is added in both directions
+		if rtt := time.Since(start); rtt < 80*time.Millisecond || rtt > time.Second {
+			t.Errorf("unexpected round trip time<%v>", rtt)
+		}
+		if string(buf) != "ping" {
+			t.Errorf("unexpected data<%s> echoed", buf)
+		}
+	}
+}
+
+func TestBandwidth(t *testing.T) {
+	// 800 kbit/s is 100 kB/s
+	conn, closeConn := dial(t, &Conditions{DownloadKbps: 800, UploadKbps: 800})
+	defer closeConn()
+
+	data := make([]byte, 20*1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	start := time.Now()
+	go func() { _, _ = conn.Write(data) }()
+	received := make([]byte, len(data))
+	if _, err := io.ReadFull(conn, received); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("unexpected transfer time<%v> of %d bytes", elapsed, len(data))
+	}
+	for i := range data {
+		if received[i] != data[i] {
+			t.Fatalf("received data differs at byte<%d>", i)
+		}
+	}
+}
+
+func TestDrop(t *testing.T) {
+	conn, closeConn := dial(t, &Conditions{DropInterval: helpers.TimeDuration(10 * time.Millisecond)})
+	defer closeConn()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != ErrConnectionDropped {
+		t.Errorf("expected connection to be dropped, got error<%v>", err)
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	conn, closeConn := dial(t, &Conditions{Latency: helpers.TimeDuration(time.Second)})
+	defer closeConn()
+
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	_, err := conn.Read(make([]byte, 4))
+	if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+		t.Errorf("expected timeout error, got error<%v>", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, conditions := range []Conditions{
+		{Latency: -1},
+		{Jitter: -1},
+		{DropInterval: -1},
+		{DownloadKbps: -1},
+		{UploadKbps: -1},
+	} {
+		if err := conditions.Validate(); err == nil {
+			t.Errorf("expected validation error of conditions<%+v>", conditions)
+		}
+	}
+}
@@ -120,6 +120,17 @@ func (rnd *Randomizer) Rand(max int) int {
 	return rnd.r.Intn(max)
 }
 
+//RandFloat64 returns a uniformly distributed value in [min,max) using current randomizer instance
+func (rnd *Randomizer) RandFloat64(min, max float64) float64 {
+	return min + rnd.r.Float64()*(max-min)
+}
+
+//ExpFloat64 returns result from ExpFloat64 using current randomizer instance, i.e. an exponentially distributed
+//value with rate parameter 1
+func (rnd *Randomizer) ExpFloat64() float64 {
+	return rnd.r.ExpFloat64()
+}
+
 //RandWeightedInt randomize based on weight
 func (rnd *Randomizer) RandWeightedInt(weights []int) (int, error) {
 	sumWeights := 0
@@ -10,12 +10,15 @@ import (
 )
 
 type (
-	// RequestMetrics keep count on data sent and received. First sent and last received message time
+	// RequestMetrics keep count on data sent and received. First sent and last received message time. When using
+	// websocket compression, size of data as sent on websocket is counted separately.
 	RequestMetrics struct {
-		first    atomichandlers.AtomicTimeStamp
-		last     atomichandlers.AtomicTimeStamp
-		sent     atomichandlers.AtomicCounter
-		received atomichandlers.AtomicCounter
+		first              atomichandlers.AtomicTimeStamp
+		last               atomichandlers.AtomicTimeStamp
+		sent               atomichandlers.AtomicCounter
+		received           atomichandlers.AtomicCounter
+		compressedSent     atomichandlers.AtomicCounter
+		compressedReceived atomichandlers.AtomicCounter
 	}
 )
 
@@ -25,6 +28,8 @@ func (resp *RequestMetrics) Reset() {
 	resp.last.Reset()
 	resp.sent.Reset()
 	resp.received.Reset()
+	resp.compressedSent.Reset()
+	resp.compressedReceived.Reset()
 }
 
 // Update action metrics with more data
@@ -66,6 +71,21 @@ func (resp *RequestMetrics) UpdateReceived(receivedTS time.Time, receivedData in
 	return nil
 }
 
+// UpdateCompressed metrics with size of compressed data
+func (resp *RequestMetrics) UpdateCompressed(sentData, receivedData int64) {
+	if sentData > 0 {
+		resp.compressedSent.Add(uint64(sentData))
+	}
+	if receivedData > 0 {
+		resp.compressedReceived.Add(uint64(receivedData))
+	}
+}
+
+// CompressedMetrics get size of compressed data sent and received
+func (resp *RequestMetrics) CompressedMetrics() (uint64, uint64) {
+	return resp.compressedSent.Current(), resp.compressedReceived.Current()
+}
+
 // Metrics get action metrics
 func (resp *RequestMetrics) Metrics() (time.Duration, uint64, uint64) {
 	first := resp.first.Current()
@@ -89,7 +89,12 @@ const (
 	ActionChangeSheet             = "changesheet"
 	ActionStaticSelect            = "staticselect"
 	ActionSelect                  = "select"
+	ActionSearchSelect            = "searchselect"
+	ActionFieldSelect             = "fieldselect"
 	ActionClearAll                = "clearall"
+	ActionSetVariable             = "setvariable"
+	ActionSmartSearch             = "smartsearch"
+	ActionExportData              = "exportdata"
 	ActionIterated                = "iterated"
 	ActionThinkTime               = "thinktime"
 	ActionRandom                  = "randomaction"
@@ -202,7 +207,12 @@ func ResetDefaultActions() {
 		ActionChangeSheet:             ChangeSheetSettings{},
 		ActionStaticSelect:            StaticSelectSettings{},
 		ActionSelect:                  SelectionSettings{},
+		ActionSearchSelect:            SearchSelectSettings{},
+		ActionFieldSelect:             FieldSelectSettings{},
 		ActionClearAll:                ClearAllSettings{},
+		ActionSetVariable:             SetVariableSettings{},
+		ActionSmartSearch:             SmartSearchSettings{},
+		ActionExportData:              ExportDataSettings{},
 		ActionIterated:                IteratedSettings{},
 		ActionThinkTime:               ThinkTimeSettings{},
 		ActionRandom:                  RandomActionSettings{},
@@ -366,7 +376,7 @@ func (act *Action) endAction(sessionState *session.State, actionState *action.St
 }
 
 func logResult(sessionState *session.State, actionState *action.State, details string, containerActionEntry *logger.ActionEntry) error {
-	var sent, received, requests uint64
+	var sent, received, compressedSent, compressedReceived, requests uint64
 	var responsetime int64
 	var actionError error
 
@@ -388,7 +398,7 @@ func logResult(sessionState *session.State, actionState *action.State, details s
 			sessionState.LogEntry.LogError(err)
 		}
 		if !actionState.NoResults {
-			logResults(sessionState, isContainerAction, !actionState.Failed, sent, received, requests, responsetime, details)
+			logResults(sessionState, isContainerAction, !actionState.Failed, sent, received, compressedSent, compressedReceived, requests, responsetime, details)
 		}
 	}()
 
@@ -411,6 +421,7 @@ func logResult(sessionState *session.State, actionState *action.State, details s
 	if !isContainerAction && !actionState.NoResults { // Don't report metrics if container action
 		var resp time.Duration
 		resp, sent, received = sessionState.RequestMetrics.Metrics()
+		compressedSent, compressedReceived = sessionState.RequestMetrics.CompressedMetrics()
 
 		if !actionState.Failed {
 			if resp.Nanoseconds() > 0 {
@@ -418,11 +429,11 @@ func logResult(sessionState *session.State, actionState *action.State, details s
 				if sessionState.LogEntry.Session == nil {
 					sessionState.LogEntry.Log(logger.WarningLevel, "Session entry is nil, unable to add prometheus metric")
 				} else {
-					buildmetrics.ReportSuccess(sessionState.LogEntry.Action.Action, sessionState.LogEntry.Action.Label, resp.Seconds())
+					buildmetrics.ReportSuccess(sessionState.LogEntry.Action.Action, sessionState.LogEntry.Action.Label, sessionState.Persona, sessionState.Node, resp.Seconds())
 				}
 			}
 		} else {
-			buildmetrics.ReportFailure(sessionState.LogEntry.Action.Action, sessionState.LogEntry.Action.Label)
+			buildmetrics.ReportFailure(sessionState.LogEntry.Action.Action, sessionState.LogEntry.Action.Label, sessionState.Persona, sessionState.Node)
 		}
 	}
 
@@ -435,13 +446,13 @@ func logResult(sessionState *session.State, actionState *action.State, details s
 	return actionError
 }
 
-func logResults(sessionState *session.State, isContainerAction, success bool, sent, received, requests uint64, responsetime int64, details string) {
+func logResults(sessionState *session.State, isContainerAction, success bool, sent, received, compressedSent, compressedReceived, requests uint64, responsetime int64, details string) {
 	if isContainerAction {
 		// log info instead of result for a container action
 		sessionState.LogEntry.LogInfo("containeractionend", "")
 	} else {
-		sessionState.LogEntry.LogResult(success, sessionState.EW.Warnings(), sessionState.EW.Errors(), sent, received, requests, responsetime, details)
-		actionStats := statistics.GetOrAddGlobalActionStats(sessionState.LogEntry.Action.Action, sessionState.LogEntry.Action.Label, sessionState.LogEntry.Session.AppGUID)
+		sessionState.LogEntry.LogResult(success, sessionState.EW.Warnings(), sessionState.EW.Errors(), sent, received, compressedSent, compressedReceived, requests, responsetime, details)
+		actionStats := statistics.GetOrAddGlobalNodeActionStats(sessionState.Persona, sessionState.Node, sessionState.LogEntry.Action.Action, sessionState.LogEntry.Action.Label, sessionState.LogEntry.Session.AppGUID)
 		if actionStats != nil {
 			actionStats.WarnCount.Add(sessionState.EW.Warnings())
 			actionStats.ErrCount.Add(sessionState.EW.Errors())
@@ -454,5 +465,13 @@ func logResults(sessionState *session.State, isContainerAction, success bool, se
 				actionStats.Failed.Inc()
 			}
 		}
+		statistics.ReportResult(statistics.ActionResult{
+			Persona:      sessionState.Persona,
+			Node:         sessionState.Node,
+			Name:         sessionState.LogEntry.Action.Action,
+			Label:        sessionState.LogEntry.Action.Label,
+			Success:      success,
+			ResponseTime: time.Duration(responsetime),
+		})
 	}
 }
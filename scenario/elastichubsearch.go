@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"runtime"
+	"strconv"
 
 	"github.com/pkg/errors"
 	"github.com/qlik-oss/gopherciser/action"
@@ -55,6 +56,8 @@ const (
 	QueryString QuerySourceEnum = iota
 	// FromFile queries read from file
 	FromFile
+	// QueryList random query from list
+	QueryList
 )
 
 func (value SearchModeEnum) GetEnumMap() *enummap.EnumMap {
@@ -70,6 +73,7 @@ func (value QuerySourceEnum) GetEnumMap() *enummap.EnumMap {
 	enumMap, _ := enummap.NewEnumMap(map[string]int{
 		"string":   int(QueryString),
 		"fromfile": int(FromFile),
+		"list":     int(QueryList),
 	})
 	return enumMap
 }
@@ -142,8 +146,20 @@ func (value QuerySourceEnum) MarshalJSON() ([]byte, error) {
 	return []byte(fmt.Sprintf(`"%s"`, str)), nil
 }
 
+// String representation of QuerySourceEnum
+func (value QuerySourceEnum) String() string {
+	str, err := value.GetEnumMap().String(int(value))
+	if err != nil {
+		return strconv.Itoa(int(value))
+	}
+	return str
+}
+
 // Validate EfeHubSearch action (Implements ActionSettings interface)
 func (settings ElasticHubSearchSettings) Validate() error {
+	if settings.QuerySource == QueryList {
+		return errors.Errorf("Unsupported query source <%s>", settings.QuerySource)
+	}
 	if settings.QuerySource == FromFile {
 		file, err := os.Open(settings.Filename)
 		if err != nil {
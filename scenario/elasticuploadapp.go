@@ -135,7 +135,11 @@ func (settings ElasticUploadAppSettings) Execute(sessionState *session.State, ac
 		}
 		tusConfig := tus.DefaultConfig()
 		tusConfig.ChunkSize = chunkSize
-		tusConfig.Header = sessionState.HeaderJar.GetHeader(host)
+		tusConfig.Header, err = sessionState.HeaderJar.GetRefreshedHeader(host)
+		if err != nil {
+			actionState.AddErrors(errors.WithStack(err))
+			return
+		}
 		tusConfig.HttpClient = httpClient
 
 		// upload to temporary storage
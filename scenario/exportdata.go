@@ -0,0 +1,328 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/enigma-go"
+	"github.com/qlik-oss/gopherciser/action"
+	"github.com/qlik-oss/gopherciser/buildmetrics"
+	"github.com/qlik-oss/gopherciser/connection"
+	"github.com/qlik-oss/gopherciser/enigmahandlers"
+	"github.com/qlik-oss/gopherciser/enummap"
+	"github.com/qlik-oss/gopherciser/senseobjdef"
+	"github.com/qlik-oss/gopherciser/session"
+)
+
+type (
+	// ExportDataFileType file type of exported data
+	ExportDataFileType int
+
+	// ExportDataState state of exported data
+	ExportDataState int
+
+	// ExportDataSettings export object data settings
+	ExportDataSettings struct {
+		// ID object to export data from, random object on sheet when empty
+		ID string `json:"id" displayname:"Object ID" doc-key:"exportdata.id"`
+		// FileType of exported file
+		FileType ExportDataFileType `json:"filetype" displayname:"File type" doc-key:"exportdata.filetype"`
+		// State of exported data
+		State ExportDataState `json:"state" displayname:"Export state" doc-key:"exportdata.state"`
+		// FileName name of saved file
+		FileName session.SyncedTemplate `json:"filename" displayname:"Export filename" displayelement:"savefile" doc-key:"exportdata.filename"`
+		// SaveToFile save exported file to outputs directory
+		SaveToFile bool `json:"savetofile" displayname:"Save to file" doc-key:"exportdata.savetofile"`
+	}
+)
+
+const (
+	// ExportDataOOXML export as Excel OOXML
+	ExportDataOOXML ExportDataFileType = iota
+	// ExportDataCSVComma export as comma separated CSV
+	ExportDataCSVComma
+	// ExportDataCSVTab export as tab separated CSV
+	ExportDataCSVTab
+)
+
+const (
+	// ExportDataStateAll export all values
+	ExportDataStateAll ExportDataState = iota
+	// ExportDataStatePossible export possible values
+	ExportDataStatePossible
+)
+
+var (
+	exportDataFileTypeEnumMap, _ = enummap.NewEnumMap(map[string]int{
+		"ooxml": int(ExportDataOOXML),
+		"csv_c": int(ExportDataCSVComma),
+		"csv_t": int(ExportDataCSVTab),
+	})
+
+	exportDataStateEnumMap, _ = enummap.NewEnumMap(map[string]int{
+		"all":      int(ExportDataStateAll),
+		"possible": int(ExportDataStatePossible),
+	})
+)
+
+func (value ExportDataFileType) GetEnumMap() *enummap.EnumMap {
+	return exportDataFileTypeEnumMap
+}
+
+// UnmarshalJSON unmarshal ExportDataFileType
+func (value *ExportDataFileType) UnmarshalJSON(arg []byte) error {
+	i, err := value.GetEnumMap().UnMarshal(arg)
+	if err != nil {
+		return errors.Wrap(err, "Failed to unmarshal ExportDataFileType")
+	}
+
+	*value = ExportDataFileType(i)
+	return nil
+}
+
+// MarshalJSON marshal ExportDataFileType
+func (value ExportDataFileType) MarshalJSON() ([]byte, error) {
+	str, err := value.GetEnumMap().String(int(value))
+	if err != nil {
+		return nil, errors.Errorf("Unknown ExportDataFileType<%d>", value)
+	}
+	return []byte(fmt.Sprintf(`"%s"`, str)), nil
+}
+
+// String representation of ExportDataFileType
+func (value ExportDataFileType) String() string {
+	str, err := value.GetEnumMap().String(int(value))
+	if err != nil {
+		return strconv.Itoa(int(value))
+	}
+	return str
+}
+
+// engineFileType file type as used by engine
+func (value ExportDataFileType) engineFileType() string {
+	switch value {
+	case ExportDataCSVComma:
+		return "CSV_C"
+	case ExportDataCSVTab:
+		return "CSV_T"
+	default:
+		return "OOXML"
+	}
+}
+
+// fileExtension extension of exported file
+func (value ExportDataFileType) fileExtension() string {
+	if value == ExportDataOOXML {
+		return ".xlsx"
+	}
+	return ".csv"
+}
+
+func (value ExportDataState) GetEnumMap() *enummap.EnumMap {
+	return exportDataStateEnumMap
+}
+
+// UnmarshalJSON unmarshal ExportDataState
+func (value *ExportDataState) UnmarshalJSON(arg []byte) error {
+	i, err := value.GetEnumMap().UnMarshal(arg)
+	if err != nil {
+		return errors.Wrap(err, "Failed to unmarshal ExportDataState")
+	}
+
+	*value = ExportDataState(i)
+	return nil
+}
+
+// MarshalJSON marshal ExportDataState
+func (value ExportDataState) MarshalJSON() ([]byte, error) {
+	str, err := value.GetEnumMap().String(int(value))
+	if err != nil {
+		return nil, errors.Errorf("Unknown ExportDataState<%d>", value)
+	}
+	return []byte(fmt.Sprintf(`"%s"`, str)), nil
+}
+
+// String representation of ExportDataState
+func (value ExportDataState) String() string {
+	str, err := value.GetEnumMap().String(int(value))
+	if err != nil {
+		return strconv.Itoa(int(value))
+	}
+	return str
+}
+
+// engineState export state as used by engine
+func (value ExportDataState) engineState() string {
+	if value == ExportDataStatePossible {
+		return "P"
+	}
+	return "A"
+}
+
+// Validate ExportDataSettings action (Implements ActionSettings interface)
+func (settings ExportDataSettings) Validate() error {
+	if _, err := settings.FileType.GetEnumMap().String(int(settings.FileType)); err != nil {
+		return errors.Errorf("Unknown file type <%d>", settings.FileType)
+	}
+	if _, err := settings.State.GetEnumMap().String(int(settings.State)); err != nil {
+		return errors.Errorf("Unknown export state <%d>", settings.State)
+	}
+	return nil
+}
+
+// Execute ExportDataSettings action (Implements ActionSettings interface)
+func (settings ExportDataSettings) Execute(sessionState *session.State, actionState *action.State, connectionSettings *connection.ConnectionSettings, label string, reset func()) {
+	if sessionState.Connection == nil || sessionState.Connection.Sense() == nil {
+		actionState.AddErrors(errors.New("Not connected to a Sense environment"))
+		return
+	}
+
+	host, err := connectionSettings.GetRestUrl()
+	if err != nil {
+		actionState.AddErrors(err)
+		return
+	}
+
+	var genObj *enigma.GenericObject
+	var dataPath string
+	if settings.ID == "" {
+		genObj, dataPath, err = randomExportableObjectOnSheet(sessionState)
+	} else {
+		genObj, dataPath, err = exportableObject(sessionState, sessionState.IDMap.Get(settings.ID))
+	}
+	if err != nil {
+		actionState.AddErrors(errors.WithStack(err))
+		return
+	}
+	if genObj == nil {
+		actionState.AddErrors(errors.New("No object with data to export on sheet"))
+		return
+	}
+	actionState.Details = fmt.Sprintf("%s;%s;%s", genObj.GenericId, settings.FileType, settings.State)
+
+	var url string
+	exportStart := time.Now()
+	if err := sessionState.SendRequest(actionState, func(ctx context.Context) error {
+		var err error
+		url, _, err = genObj.ExportData(ctx, settings.FileType.engineFileType(), dataPath, "", settings.State.engineState())
+		return err
+	}); err != nil {
+		actionState.AddErrors(errors.Wrapf(err, "Failed to export data of object<%s>", genObj.GenericId))
+		return
+	}
+	exportTime := time.Since(exportStart)
+	if url == "" {
+		actionState.AddErrors(errors.Errorf("No download URL returned exporting data of object<%s>", genObj.GenericId))
+		return
+	}
+
+	downloadReq := sessionState.Rest.FireOffGet(fmt.Sprintf("%s%s", host, url), actionState, false)
+	if sessionState.Wait(actionState) {
+		return // we had an error
+	}
+	if err := session.CheckResponseStatus(downloadReq, []int{200}); err != nil {
+		actionState.AddErrors(errors.Wrapf(err, "failed to download exported data of object<%s>", genObj.GenericId))
+		return
+	}
+	size := len(downloadReq.ResponseBody)
+
+	sessionState.LogEntry.LogInfo("ExportDataTime", fmt.Sprintf("%v", exportTime))
+	sessionState.LogEntry.LogInfo("ExportDataSize", strconv.Itoa(size))
+	buildmetrics.ReportExport(settings.FileType.String(), exportTime.Seconds(), size)
+
+	if !settings.SaveToFile {
+		return
+	}
+
+	filename := genObj.GenericId
+	if settings.FileName.String() != "" {
+		data := struct {
+			ObjectID string
+		}{ObjectID: genObj.GenericId}
+
+		filename, err = sessionState.ReplaceSessionVariablesWithLocalData(&settings.FileName, data)
+		if err != nil {
+			actionState.AddErrors(errors.WithStack(err))
+			return
+		}
+	}
+
+	if ext := settings.FileType.fileExtension(); !strings.HasSuffix(filename, ext) {
+		filename += ext
+	}
+
+	if err := ioutil.WriteFile(path.Join(sessionState.OutputsDir, filename), downloadReq.ResponseBody, 0644); err != nil {
+		actionState.AddErrors(errors.Wrap(err, "failed writing exported data to file"))
+	}
+}
+
+// exportableObject object with ID and path to its hypercube definition
+func exportableObject(sessionState *session.State, id string) (*enigma.GenericObject, string, error) {
+	uplink := sessionState.Connection.Sense()
+	gob, err := uplink.Objects.GetObjectByID(id)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "Failed getting object<%s> from object list", id)
+	}
+
+	if linkedObjHandle := uplink.Objects.GetObjectLink(gob.Handle); linkedObjHandle != 0 {
+		if gob, err = uplink.Objects.GetObject(linkedObjHandle); err != nil {
+			return nil, "", errors.Wrapf(err, "Failed getting linked object<%d> object<%s>", linkedObjHandle, id)
+		}
+	}
+
+	genObj, ok := gob.EnigmaObject.(*enigma.GenericObject)
+	if !ok {
+		return nil, "", errors.Errorf("Unknown object type<%T>", gob.EnigmaObject)
+	}
+
+	dataPath, ok := exportDataPath(genObj)
+	if !ok {
+		return nil, "", errors.Errorf("object<%s> type<%s> has no hypercube to export", id, genObj.GenericType)
+	}
+	return genObj, dataPath, nil
+}
+
+// randomExportableObjectOnSheet random object with hypercube on current sheet, nil if none found
+func randomExportableObjectOnSheet(sessionState *session.State) (*enigma.GenericObject, string, error) {
+	uplink := sessionState.Connection.Sense()
+	handles := uplink.Objects.GetAllObjectHandles(true, enigmahandlers.ObjTypeSheetObject)
+
+	objects := make([]*enigma.GenericObject, 0, len(handles))
+	paths := make([]string, 0, len(handles))
+	for _, handle := range handles {
+		obj, err := uplink.Objects.GetObject(handle)
+		if err != nil {
+			continue
+		}
+		genObj, ok := obj.EnigmaObject.(*enigma.GenericObject)
+		if !ok {
+			continue
+		}
+		if dataPath, ok := exportDataPath(genObj); ok {
+			objects = append(objects, genObj)
+			paths = append(paths, dataPath)
+		}
+	}
+
+	if len(objects) < 1 {
+		return nil, "", nil
+	}
+	i := sessionState.Randomizer().Rand(len(objects))
+	return objects[i], paths[i], nil
+}
+
+// exportDataPath path to hypercube definition of object, false if object has no hypercube
+func exportDataPath(genObj *enigma.GenericObject) (string, bool) {
+	def, err := senseobjdef.GetObjectDef(genObj.GenericType)
+	if err != nil || def.DataDef.Type != senseobjdef.DataDefHyperCube || def.DataDef.Path == "" {
+		return "", false
+	}
+	// data definitions point to hypercube in layout, export needs the path to the definition in properties
+	return fmt.Sprintf("%sDef", def.DataDef.Path), true
+}
@@ -0,0 +1,64 @@
+package scenario
+
+import (
+	"testing"
+)
+
+func TestExportDataUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	raw := `{
+		"label" : "export table",
+		"action" : "exportdata",
+		"settings": {
+			"id" : "objid1",
+			"filetype" : "csv_t",
+			"state" : "possible",
+			"filename" : "{{.Local.ObjectID}}_{{.UserName}}",
+			"savetofile" : true
+		}
+	}`
+	var item Action
+	if err := jsonit.Unmarshal([]byte(raw), &item); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := item.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	settings, ok := item.Settings.(*ExportDataSettings)
+	if !ok {
+		t.Fatalf("Failed to cast item settings<%T> to *ExportDataSettings", item.Settings)
+	}
+
+	validateString(t, "id", settings.ID, "objid1")
+	validateString(t, "filetype", settings.FileType.String(), "csv_t")
+	validateString(t, "enginefiletype", settings.FileType.engineFileType(), "CSV_T")
+	validateString(t, "state", settings.State.String(), "possible")
+	validateString(t, "enginestate", settings.State.engineState(), "P")
+	validateString(t, "filename", settings.FileName.String(), "{{.Local.ObjectID}}_{{.UserName}}")
+	validateBool(t, "savetofile", settings.SaveToFile, true)
+
+	marshaled, err := jsonit.Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validateString(t, "json", string(marshaled), `{"action":"exportdata","label":"export table","disabled":false,"settings":{"id":"objid1","filetype":"csv_t","state":"possible","filename":"{{.Local.ObjectID}}_{{.UserName}}","savetofile":true}}`)
+}
+
+func TestExportDataValidate(t *testing.T) {
+	t.Parallel()
+
+	settings := ExportDataSettings{}
+	validateError(t, settings.Validate(), "")
+	validateString(t, "enginefiletype", settings.FileType.engineFileType(), "OOXML")
+	validateString(t, "enginestate", settings.State.engineState(), "A")
+
+	settings.FileType = ExportDataFileType(10)
+	validateError(t, settings.Validate(), "Unknown file type <10>")
+
+	settings.FileType = ExportDataCSVComma
+	settings.State = ExportDataState(10)
+	validateError(t, settings.Validate(), "Unknown export state <10>")
+}
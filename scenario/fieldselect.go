@@ -0,0 +1,328 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/enigma-go"
+	"github.com/qlik-oss/gopherciser/action"
+	"github.com/qlik-oss/gopherciser/connection"
+	"github.com/qlik-oss/gopherciser/enummap"
+	"github.com/qlik-oss/gopherciser/helpers"
+	"github.com/qlik-oss/gopherciser/logger"
+	"github.com/qlik-oss/gopherciser/session"
+)
+
+type (
+	// FieldSelectType type of field selection
+	FieldSelectType int
+
+	// FieldSelectSettings field selection settings
+	FieldSelectSettings struct {
+		// Field name of field
+		Field string `json:"field" displayname:"Field name" doc-key:"fieldselect.field"`
+		// Type of selection
+		Type FieldSelectType `json:"type" displayname:"Selection type" doc-key:"fieldselect.type"`
+		// Values to select, or search patterns
+		Values []session.SyncedTemplate `json:"values,omitempty" displayname:"Values" doc-key:"fieldselect.values"`
+		// ValueFile file with values to select, one per line
+		ValueFile helpers.RowFile `json:"valuefile" displayname:"Value file" displayelement:"file" doc-key:"fieldselect.valuefile"`
+		// Numeric select values by numeric value
+		Numeric bool `json:"numeric" displayname:"Numeric values" doc-key:"fieldselect.numeric"`
+		// Toggle toggle selected values with current selection
+		Toggle bool `json:"toggle" displayname:"Toggle selections" doc-key:"fieldselect.toggle"`
+		// Min minimum amount of random values to select
+		Min int `json:"min" displayname:"Minimum amount of values to select" doc-key:"fieldselect.min"`
+		// Max maximum amount of random values to select
+		Max int `json:"max" displayname:"Maximum amount of values to select" doc-key:"fieldselect.max"`
+	}
+)
+
+const (
+	// FieldSelectValues select values matching values
+	FieldSelectValues FieldSelectType = iota
+	// FieldSelectSearch select values matching search pattern
+	FieldSelectSearch
+	// FieldSelectRandom select random possible values
+	FieldSelectRandom
+	// FieldSelectPossible select all possible values
+	FieldSelectPossible
+	// FieldSelectExcluded select all excluded values
+	FieldSelectExcluded
+	// FieldSelectAlternative select all alternative values
+	FieldSelectAlternative
+	// FieldLock lock field
+	FieldLock
+	// FieldUnlock unlock field
+	FieldUnlock
+)
+
+// listObjectPageHeight max rows of list object data page, engine returns max 10000 cells in one request
+const listObjectPageHeight = 10000
+
+var fieldSelectTypeEnumMap, _ = enummap.NewEnumMap(map[string]int{
+	"values":      int(FieldSelectValues),
+	"search":      int(FieldSelectSearch),
+	"random":      int(FieldSelectRandom),
+	"possible":    int(FieldSelectPossible),
+	"excluded":    int(FieldSelectExcluded),
+	"alternative": int(FieldSelectAlternative),
+	"lock":        int(FieldLock),
+	"unlock":      int(FieldUnlock),
+})
+
+func (value FieldSelectType) GetEnumMap() *enummap.EnumMap {
+	return fieldSelectTypeEnumMap
+}
+
+// UnmarshalJSON unmarshal FieldSelectType
+func (value *FieldSelectType) UnmarshalJSON(arg []byte) error {
+	i, err := value.GetEnumMap().UnMarshal(arg)
+	if err != nil {
+		return errors.Wrap(err, "Failed to unmarshal FieldSelectType")
+	}
+
+	*value = FieldSelectType(i)
+	return nil
+}
+
+// MarshalJSON marshal FieldSelectType
+func (value FieldSelectType) MarshalJSON() ([]byte, error) {
+	str, err := value.GetEnumMap().String(int(value))
+	if err != nil {
+		return nil, errors.Errorf("Unknown FieldSelectType<%d>", value)
+	}
+	return []byte(fmt.Sprintf(`"%s"`, str)), nil
+}
+
+// String representation of FieldSelectType
+func (value FieldSelectType) String() string {
+	str, err := value.GetEnumMap().String(int(value))
+	if err != nil {
+		return strconv.Itoa(int(value))
+	}
+	return str
+}
+
+// Validate FieldSelectSettings action (Implements ActionSettings interface)
+func (settings FieldSelectSettings) Validate() error {
+	if settings.Field == "" {
+		return errors.New("Empty field name")
+	}
+
+	switch settings.Type {
+	case FieldSelectValues, FieldSelectSearch:
+		if len(settings.Values) < 1 && settings.ValueFile.IsEmpty() {
+			return errors.Errorf("No values defined for selection type<%s>", settings.Type)
+		}
+	case FieldSelectRandom:
+		if settings.Min < 1 {
+			return errors.Errorf("min<%d> selections must be >1", settings.Min)
+		}
+		if settings.Min > settings.Max {
+			return errors.Errorf("min<%d> must be less than max<%d>", settings.Min, settings.Max)
+		}
+	case FieldSelectPossible, FieldSelectExcluded, FieldSelectAlternative, FieldLock, FieldUnlock:
+	default:
+		return errors.Errorf("Unknown selection type<%s>", settings.Type)
+	}
+	return nil
+}
+
+// Execute FieldSelectSettings action (Implements ActionSettings interface)
+func (settings FieldSelectSettings) Execute(sessionState *session.State, actionState *action.State, connectionSettings *connection.ConnectionSettings, label string, reset func()) {
+	if sessionState.Connection == nil || sessionState.Connection.Sense() == nil {
+		actionState.AddErrors(errors.New("Not connected to a Sense environment"))
+		return
+	}
+
+	app := sessionState.Connection.Sense().CurrentApp
+	if app == nil {
+		actionState.AddErrors(errors.New("Not connected to a Sense app"))
+		return
+	}
+
+	var values []string
+	var elemNumbers []int
+	switch settings.Type {
+	case FieldSelectValues, FieldSelectSearch:
+		var err error
+		if values, err = settings.values(sessionState); err != nil {
+			actionState.AddErrors(errors.WithStack(err))
+			return
+		}
+		if len(values) < 1 {
+			actionState.AddErrors(errors.Errorf("No values to select in field<%s>", settings.Field))
+			return
+		}
+		if settings.Type == FieldSelectSearch {
+			// one search pattern per selection, random pattern when several defined
+			pattern, err := sessionState.Randomizer().RandString(values)
+			if err != nil {
+				actionState.AddErrors(errors.WithStack(err))
+				return
+			}
+			values = []string{pattern}
+		}
+		actionState.Details = fmt.Sprintf("%s;%s;%v", settings.Field, settings.Type, values)
+	case FieldSelectRandom:
+		possible, err := getPossibleFieldValues(sessionState, actionState, app.Doc, settings.Field)
+		if err != nil {
+			actionState.AddErrors(errors.WithStack(err))
+			return
+		}
+		if elemNumbers, err = fillSelectPosFromPossible(settings.Min, settings.Max, possible, sessionState.Randomizer()); err != nil {
+			actionState.AddErrors(errors.WithStack(err))
+			return
+		}
+		if len(elemNumbers) < 1 {
+			sessionState.LogEntry.Logf(logger.WarningLevel, "Nothing to select in field<%s>", settings.Field)
+			return
+		}
+		actionState.Details = fmt.Sprintf("%s;%s;%v", settings.Field, settings.Type, elemNumbers)
+	default:
+		actionState.Details = fmt.Sprintf("%s;%s", settings.Field, settings.Type)
+	}
+
+	var field *enigma.Field
+	if err := sessionState.SendRequest(actionState, func(ctx context.Context) error {
+		var err error
+		field, err = app.Doc.GetField(ctx, settings.Field, "")
+		return err
+	}); err != nil {
+		actionState.AddErrors(errors.Wrapf(err, "Failed to get field<%s>", settings.Field))
+		return
+	}
+
+	sessionState.QueueRequest(func(ctx context.Context) error {
+		var success bool
+		var err error
+		switch settings.Type {
+		case FieldSelectValues:
+			var selectValues []*enigma.FieldValue
+			if selectValues, err = toFieldValues(values, settings.Numeric); err != nil {
+				return errors.WithStack(err)
+			}
+			success, err = field.SelectValues(ctx, selectValues, settings.Toggle, false)
+		case FieldSelectSearch:
+			if settings.Toggle {
+				success, err = field.ToggleSelect(ctx, values[0], false, 0)
+			} else {
+				success, err = field.Select(ctx, values[0], false, 0)
+			}
+		case FieldSelectRandom:
+			success, err = field.LowLevelSelect(ctx, elemNumbers, settings.Toggle, false)
+		case FieldSelectPossible:
+			success, err = field.SelectPossible(ctx, false)
+		case FieldSelectExcluded:
+			success, err = field.SelectExcluded(ctx, false)
+		case FieldSelectAlternative:
+			success, err = field.SelectAlternative(ctx, false)
+		case FieldLock:
+			success, err = field.Lock(ctx)
+		case FieldUnlock:
+			success, err = field.Unlock(ctx)
+		default:
+			return errors.Errorf("Unknown selection type<%s>", settings.Type)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "Failed to %s field<%s>", settings.Type, settings.Field)
+		}
+		if !success {
+			return errors.Errorf("%s field<%s> unsuccessful", settings.Type, settings.Field)
+		}
+		return nil
+	}, actionState, true, fmt.Sprintf("Failed to select in field<%s>", settings.Field))
+
+	sessionState.Wait(actionState)
+}
+
+// values from templates and value file
+func (settings FieldSelectSettings) values(sessionState *session.State) ([]string, error) {
+	values := make([]string, 0, len(settings.Values)+len(settings.ValueFile.Rows()))
+	for i := range settings.Values {
+		value, err := sessionState.ReplaceSessionVariables(&settings.Values[i])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		values = append(values, value)
+	}
+	return append(values, settings.ValueFile.Rows()...), nil
+}
+
+// toFieldValues text or numeric field values of values
+func toFieldValues(values []string, numeric bool) ([]*enigma.FieldValue, error) {
+	fieldValues := make([]*enigma.FieldValue, 0, len(values))
+	for _, value := range values {
+		if !numeric {
+			fieldValues = append(fieldValues, &enigma.FieldValue{Text: value})
+			continue
+		}
+		num, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse value<%s> as number", value)
+		}
+		fieldValues = append(fieldValues, &enigma.FieldValue{IsNumeric: true, Number: enigma.Float64(num)})
+	}
+	return fieldValues, nil
+}
+
+// getPossibleFieldValues element numbers of possible values of field
+func getPossibleFieldValues(sessionState *session.State, actionState *action.State, doc *enigma.Doc, field string) ([]int, error) {
+	listbox, err := createFieldListboxAsync(sessionState, actionState, doc, field)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed creating session listbox object for field<%s>", field)
+	}
+	defer func() {
+		id := listbox.Layout().Info.Id
+		sessionState.QueueRequest(func(ctx context.Context) error {
+			_, err := doc.DestroySessionObject(ctx, id)
+			return err
+		}, actionState, false, fmt.Sprintf("Failed to destroy session listbox object for field<%s>", field))
+	}()
+
+	// get data in pages as engine limits amount of cells returned by one request
+	var dataPages []*enigma.NxDataPage
+	for _, page := range listObjectPages(listbox.Layout().ListObject.Size.Cy) {
+		if err := sessionState.SendRequest(actionState, func(ctx context.Context) error {
+			pageData, err := listbox.GetListObjectDataPages(ctx, []*enigma.NxPage{page})
+			dataPages = append(dataPages, pageData...)
+			return err
+		}); err != nil {
+			return nil, errors.Wrapf(err, "Failed to get values of field<%s>", field)
+		}
+	}
+
+	var possible uniqueInts
+	for _, dataPage := range dataPages {
+		for _, row := range dataPage.Matrix {
+			if len(row) < 1 || row[0] == nil {
+				continue
+			}
+			state, err := selectStateHandler.Int(strings.ToLower(row[0].State))
+			if err != nil {
+				return nil, errors.Wrapf(err, "Value<%s> of field<%s> has unknown state<%s>", row[0].Text, field, row[0].State)
+			}
+			if selectStates(state).isEnabled(false) {
+				possible.AddValue(row[0].ElemNumber)
+			}
+		}
+	}
+	return possible.Array(), nil
+}
+
+// listObjectPages pages of max listObjectPageHeight rows covering rows of a list object
+func listObjectPages(rows int) []*enigma.NxPage {
+	pages := make([]*enigma.NxPage, 0, rows/listObjectPageHeight+1)
+	for top := 0; top < rows; top += listObjectPageHeight {
+		height := rows - top
+		if height > listObjectPageHeight {
+			height = listObjectPageHeight
+		}
+		pages = append(pages, &enigma.NxPage{Top: top, Width: 1, Height: height})
+	}
+	return pages
+}
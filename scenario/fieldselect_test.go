@@ -0,0 +1,122 @@
+package scenario
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFieldSelectUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "fieldselect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	valueFile := filepath.Join(dir, "years.txt")
+	if err := ioutil.WriteFile(valueFile, []byte("2018\n2019\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := `{
+		"label" : "select years",
+		"action" : "fieldselect",
+		"settings": {
+			"field" : "Year",
+			"type" : "values",
+			"values" : ["2020"],
+			"valuefile" : "` + filepath.ToSlash(valueFile) + `",
+			"numeric" : true,
+			"toggle" : true
+		}
+	}`
+	var item Action
+	if err := jsonit.Unmarshal([]byte(raw), &item); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := item.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	settings, ok := item.Settings.(*FieldSelectSettings)
+	if !ok {
+		t.Fatalf("Failed to cast item settings<%T> to *FieldSelectSettings", item.Settings)
+	}
+
+	validateString(t, "field", settings.Field, "Year")
+	validateString(t, "type", settings.Type.String(), "values")
+	validateBool(t, "numeric", settings.Numeric, true)
+	validateBool(t, "toggle", settings.Toggle, true)
+	validateInt(t, "values", len(settings.Values), 1)
+	validateInt(t, "valuefile", len(settings.ValueFile.Rows()), 2)
+}
+
+func TestFieldSelectValidate(t *testing.T) {
+	t.Parallel()
+
+	settings := FieldSelectSettings{}
+	validateError(t, settings.Validate(), "Empty field name")
+	settings.Field = "Region"
+	validateError(t, settings.Validate(), "No values defined for selection type<values>")
+
+	settings.Type = FieldSelectRandom
+	validateError(t, settings.Validate(), "min<0> selections must be >1")
+	settings.Min = 3
+	settings.Max = 2
+	validateError(t, settings.Validate(), "min<3> must be less than max<2>")
+	settings.Max = 3
+	validateError(t, settings.Validate(), "")
+
+	settings.Type = FieldLock
+	validateError(t, settings.Validate(), "")
+}
+
+func TestToFieldValues(t *testing.T) {
+	t.Parallel()
+
+	values, err := toFieldValues([]string{"EMEA", "2019"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[1].Text != "2019" || values[1].IsNumeric {
+		t.Errorf("unexpected text field values<%+v>", values)
+	}
+
+	values, err = toFieldValues([]string{"2019", " 0.5"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || !values[1].IsNumeric || float64(values[1].Number) != 0.5 {
+		t.Errorf("unexpected numeric field values<%+v>", values)
+	}
+
+	if _, err := toFieldValues([]string{"EMEA"}, true); err == nil {
+		t.Error("expected error parsing non numeric value")
+	}
+}
+
+func TestListObjectPages(t *testing.T) {
+	t.Parallel()
+
+	pages := listObjectPages(25000)
+	expected := []struct{ top, height int }{{0, 10000}, {10000, 10000}, {20000, 5000}}
+	if len(pages) != len(expected) {
+		t.Fatalf("expected<%d> pages, got<%d>", len(expected), len(pages))
+	}
+	for i, page := range pages {
+		if page.Top != expected[i].top || page.Height != expected[i].height || page.Width != 1 {
+			t.Errorf("page<%d> expected top<%d> height<%d>, got<%+v>", i, expected[i].top, expected[i].height, *page)
+		}
+	}
+
+	if pages := listObjectPages(20); len(pages) != 1 || pages[0].Height != 20 {
+		t.Errorf("expected one page of 20 rows, got<%d> pages", len(pages))
+	}
+	if pages := listObjectPages(0); len(pages) != 0 {
+		t.Errorf("expected no pages for empty field, got<%d>", len(pages))
+	}
+}
@@ -63,6 +63,8 @@ const (
 	ChangeSheet
 	// ClearAll clearing all selections
 	ClearAll
+	// SetVariable setting a variable, variable and values defined using overrides
+	SetVariable
 )
 
 var (
@@ -71,6 +73,7 @@ var (
 		"sheetobjectselection": int(SheetObjectSelection),
 		"changesheet":          int(ChangeSheet),
 		"clearall":             int(ClearAll),
+		"setvariable":          int(SetVariable),
 	})
 )
 
@@ -217,6 +220,16 @@ func (settings RandomActionSettings) Execute(sessionState *session.State, state
 			item = Action{ActionCore{ActionChangeSheet, fmt.Sprintf("%s - generated changesheet", label), false}, itemSettings}
 		case ClearAll:
 			item = Action{ActionCore{ActionClearAll, fmt.Sprintf("%s - generated clearall", label), false}, ClearAllSettings{}}
+		case SetVariable:
+			if selectedAction.itemSettings == nil {
+				var err error
+				selectedAction.itemSettings, err = overrideSettings(SetVariableSettings{}, selectedAction.Overrides)
+				if err != nil {
+					state.AddErrors(errors.WithStack(err))
+					return
+				}
+			}
+			item = Action{ActionCore{ActionSetVariable, fmt.Sprintf("%s - generated setvariable", label), false}, selectedAction.itemSettings.(SetVariableSettings)}
 		default:
 			state.AddErrors(errors.Errorf("action type<%d> not supported", selectedAction.Type))
 			return
@@ -318,6 +331,13 @@ func overrideSettings(originalSettings ActionSettings, overrideSettings map[stri
 			return nil, errors.WithStack(err)
 		}
 		return newSettingsObject, nil
+	case SetVariableSettings:
+		newSettingsObject := SetVariableSettings{}
+		err = jsonit.Unmarshal(finalJSON, &newSettingsObject)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return newSettingsObject, nil
 	case ThinkTimeSettings:
 		newSettingsObject := ThinkTimeSettings{}
 		err = jsonit.Unmarshal(finalJSON, &newSettingsObject)
@@ -337,6 +357,15 @@ func (settings RandomActionSettings) Validate() error {
 		if probability <= 0 {
 			return errors.Errorf("Action weight (p=%d) should be at least 1", probability)
 		}
+		if actionTypeSettings.Type == SetVariable {
+			itemSettings, err := overrideSettings(SetVariableSettings{}, actionTypeSettings.Overrides)
+			if err != nil {
+				return errors.Wrap(err, "invalid setvariable overrides")
+			}
+			if err := itemSettings.(SetVariableSettings).Validate(); err != nil {
+				return errors.Wrap(err, "invalid setvariable overrides")
+			}
+		}
 	}
 	totalProbability := 0
 	for _, actionTypeSettings := range settings.ActionTypes {
@@ -0,0 +1,149 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/enigma-go"
+	"github.com/qlik-oss/gopherciser/action"
+	"github.com/qlik-oss/gopherciser/connection"
+	"github.com/qlik-oss/gopherciser/helpers"
+	"github.com/qlik-oss/gopherciser/senseobjdef"
+	"github.com/qlik-oss/gopherciser/session"
+)
+
+type (
+	// SearchSelectSettings search and select settings
+	SearchSelectSettings struct {
+		// ID object id
+		ID string `json:"id" displayname:"Object ID" doc-key:"searchselect.id"`
+		// QuerySource source of search string
+		QuerySource QuerySourceEnum `json:"querysource" displayname:"Query source" doc-key:"searchselect.querysource"`
+		// Query search string
+		Query session.SyncedTemplate `json:"query" displayname:"Query" doc-key:"searchselect.query"`
+		// QueryFile file with search strings, one per line
+		QueryFile helpers.RowFile `json:"queryfile" displayname:"Query file" displayelement:"file" doc-key:"searchselect.queryfile"`
+		// Accept true - accept search result. false - abort search
+		Accept bool `json:"accept" displayname:"Accept search" doc-key:"searchselect.accept"`
+		// Toggle toggle selection of search result with current selection
+		Toggle bool `json:"toggle" displayname:"Toggle selections" doc-key:"searchselect.toggle"`
+	}
+)
+
+// Validate SearchSelectSettings action (Implements ActionSettings interface)
+func (settings SearchSelectSettings) Validate() error {
+	if settings.ID == "" {
+		return errors.New("Empty object ID")
+	}
+
+	switch settings.QuerySource {
+	case QueryString:
+		if settings.Query.String() == "" {
+			return errors.New("Empty search query")
+		}
+	case FromFile:
+		if settings.QueryFile.IsEmpty() {
+			return errors.New("No query file")
+		}
+	default:
+		return errors.Errorf("Unsupported query source <%s>", settings.QuerySource)
+	}
+	return nil
+}
+
+// Execute SearchSelectSettings action (Implements ActionSettings interface)
+func (settings SearchSelectSettings) Execute(sessionState *session.State, actionState *action.State, connectionSettings *connection.ConnectionSettings, label string, reset func()) {
+	uplink := sessionState.Connection.Sense()
+	objectID := sessionState.IDMap.Get(settings.ID)
+	gob, err := uplink.Objects.GetObjectByID(objectID)
+	if err != nil {
+		actionState.AddErrors(errors.Wrapf(err, "Failed getting object<%s> from object list", objectID))
+		return
+	}
+
+	linkedObjHandle := uplink.Objects.GetObjectLink(gob.Handle)
+	if linkedObjHandle != 0 {
+		var errLink error
+		gob, errLink = uplink.Objects.GetObject(linkedObjHandle)
+		if errLink != nil {
+			actionState.AddErrors(errors.Wrapf(errLink, "Failed getting linked object<%d> object<%s>", linkedObjHandle, objectID))
+			return
+		}
+	}
+
+	genObj, ok := gob.EnigmaObject.(*enigma.GenericObject)
+	if !ok {
+		actionState.AddErrors(errors.Errorf("Unknown object type<%T>", gob.EnigmaObject))
+		return
+	}
+
+	def, err := senseobjdef.GetObjectDef(genObj.GenericType)
+	if err != nil {
+		actionState.AddErrors(errors.Wrapf(err, "Failed to get object<%s> selection definitions", genObj.GenericType))
+		return
+	}
+	if err := def.Validate(); err != nil {
+		actionState.AddErrors(errors.Wrapf(err, "Error validating object<%s> selection definitions<%+v>", genObj.GenericType, def))
+		return
+	}
+	if def.Select.Type != senseobjdef.SelectTypeListObjectValues {
+		actionState.AddErrors(errors.Errorf("object<%s> type<%s> does not support list object search", gob.ID, genObj.GenericType))
+		return
+	}
+	path := def.Select.Path
+
+	var query string
+	switch settings.QuerySource {
+	case QueryString:
+		if query, err = sessionState.ReplaceSessionVariables(&settings.Query); err != nil {
+			actionState.AddErrors(errors.WithStack(err))
+			return
+		}
+	case FromFile:
+		if query, err = sessionState.Randomizer().RandString(settings.QueryFile.Rows()); err != nil {
+			actionState.AddErrors(errors.Wrap(err, "No queries read from query file"))
+			return
+		}
+	default:
+		actionState.AddErrors(errors.Errorf("Unsupported query source <%s>", settings.QuerySource))
+		return
+	}
+	actionState.Details = fmt.Sprintf("%s;%s", gob.ID, query)
+
+	// Search, waiting for the updated layout with the search result
+	searchStart := time.Now()
+	sessionState.QueueRequest(func(ctx context.Context) error {
+		success, err := genObj.SearchListObjectFor(ctx, path, query)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if !success {
+			return errors.Errorf("Search for<%s> in object<%s> unsuccessful", query, genObj.GenericId)
+		}
+		return nil
+	}, actionState, true, fmt.Sprintf("Failed to search in object<%s>", genObj.GenericId))
+	if sessionState.Wait(actionState) {
+		return
+	}
+	searchTime := time.Since(searchStart)
+
+	step := "abort"
+	stepStart := time.Now()
+	if settings.Accept {
+		step = "accept"
+		sessionState.QueueRequest(func(ctx context.Context) error {
+			return errors.WithStack(genObj.AcceptListObjectSearch(ctx, path, settings.Toggle, false))
+		}, actionState, true, fmt.Sprintf("Failed to accept search in object<%s>", genObj.GenericId))
+	} else {
+		sessionState.QueueRequest(func(ctx context.Context) error {
+			return errors.WithStack(genObj.AbortListObjectSearch(ctx, path))
+		}, actionState, true, fmt.Sprintf("Failed to abort search in object<%s>", genObj.GenericId))
+	}
+	if sessionState.Wait(actionState) {
+		return
+	}
+
+	sessionState.LogEntry.LogInfo("SearchSelectTimes", fmt.Sprintf("search<%v> %s<%v>", searchTime, step, time.Since(stepStart)))
+}
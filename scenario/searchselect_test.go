@@ -0,0 +1,68 @@
+package scenario
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSearchSelectUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "searchselect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	queryFile := filepath.Join(dir, "queries.txt")
+	if err := ioutil.WriteFile(queryFile, []byte("Sweden\nNor*\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := `{
+		"label" : "search country",
+		"action" : "searchselect",
+		"settings": {
+			"id" : "objid1",
+			"querysource" : "fromfile",
+			"queryfile" : "` + filepath.ToSlash(queryFile) + `",
+			"accept" : true
+		}
+	}`
+	var item Action
+	if err := jsonit.Unmarshal([]byte(raw), &item); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := item.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	settings, ok := item.Settings.(*SearchSelectSettings)
+	if !ok {
+		t.Fatalf("Failed to cast item settings<%T> to *SearchSelectSettings", item.Settings)
+	}
+
+	validateString(t, "id", settings.ID, "objid1")
+	validateBool(t, "accept", settings.Accept, true)
+	validateBool(t, "toggle", settings.Toggle, false)
+	validateInt(t, "queries", len(settings.QueryFile.Rows()), 2)
+	validateString(t, "query", settings.QueryFile.Rows()[1], "Nor*")
+}
+
+func TestSearchSelectValidate(t *testing.T) {
+	t.Parallel()
+
+	settings := SearchSelectSettings{}
+	validateError(t, settings.Validate(), "Empty object ID")
+	settings.ID = "objid1"
+	validateError(t, settings.Validate(), "Empty search query")
+
+	settings.QuerySource = FromFile
+	validateError(t, settings.Validate(), "No query file")
+
+	settings.QuerySource = QueryList
+	validateError(t, settings.Validate(), "Unsupported query source <list>")
+}
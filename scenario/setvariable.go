@@ -0,0 +1,196 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/enigma-go"
+	"github.com/qlik-oss/gopherciser/action"
+	"github.com/qlik-oss/gopherciser/connection"
+	"github.com/qlik-oss/gopherciser/enummap"
+	"github.com/qlik-oss/gopherciser/randomizer"
+	"github.com/qlik-oss/gopherciser/session"
+)
+
+type (
+	// VariableValueType type of value to set on variable
+	VariableValueType int
+
+	// SetVariableSettings set variable settings
+	SetVariableSettings struct {
+		// Name of variable
+		Name string `json:"name" displayname:"Variable name" doc-key:"setvariable.name"`
+		// ID of variable
+		ID string `json:"id" displayname:"Variable ID" doc-key:"setvariable.id"`
+		// Type of value to set
+		Type VariableValueType `json:"type" displayname:"Value type" doc-key:"setvariable.type"`
+		// Value static or templated value, used with type value
+		Value session.SyncedTemplate `json:"value" displayname:"Value" doc-key:"setvariable.value"`
+		// Values to randomize from, used with type list
+		Values []string `json:"values,omitempty" displayname:"Values" doc-key:"setvariable.values"`
+		// Min lower bound of random value, used with type range
+		Min float64 `json:"min" displayname:"Minimum value" doc-key:"setvariable.min"`
+		// Max upper bound of random value, used with type range
+		Max float64 `json:"max" displayname:"Maximum value" doc-key:"setvariable.max"`
+		// Step between possible random values, used with type range
+		Step float64 `json:"step" displayname:"Step" doc-key:"setvariable.step"`
+	}
+)
+
+const (
+	// VariableValue set static or templated value
+	VariableValue VariableValueType = iota
+	// VariableRandomFromList set random value from list
+	VariableRandomFromList
+	// VariableRandomInRange set random numeric value in range
+	VariableRandomInRange
+)
+
+var variableValueTypeEnumMap, _ = enummap.NewEnumMap(map[string]int{
+	"value": int(VariableValue),
+	"list":  int(VariableRandomFromList),
+	"range": int(VariableRandomInRange),
+})
+
+func (value VariableValueType) GetEnumMap() *enummap.EnumMap {
+	return variableValueTypeEnumMap
+}
+
+// UnmarshalJSON unmarshal variable value type
+func (value *VariableValueType) UnmarshalJSON(arg []byte) error {
+	i, err := value.GetEnumMap().UnMarshal(arg)
+	if err != nil {
+		return errors.Wrap(err, "Failed to unmarshal VariableValueType")
+	}
+
+	*value = VariableValueType(i)
+	return nil
+}
+
+// MarshalJSON marshal variable value type
+func (value VariableValueType) MarshalJSON() ([]byte, error) {
+	str, err := value.GetEnumMap().String(int(value))
+	if err != nil {
+		return nil, errors.Errorf("Unknown VariableValueType<%d>", value)
+	}
+	return []byte(fmt.Sprintf(`"%s"`, str)), nil
+}
+
+// String representation of VariableValueType
+func (value VariableValueType) String() string {
+	str, err := value.GetEnumMap().String(int(value))
+	if err != nil {
+		return strconv.Itoa(int(value))
+	}
+	return str
+}
+
+// Validate SetVariableSettings action (Implements ActionSettings interface)
+func (settings SetVariableSettings) Validate() error {
+	if (settings.Name == "") == (settings.ID == "") {
+		return errors.New("specify exactly one of the following - variable name or variable id")
+	}
+
+	switch settings.Type {
+	case VariableValue:
+	case VariableRandomFromList:
+		if len(settings.Values) < 1 {
+			return errors.New("no values to randomize from")
+		}
+	case VariableRandomInRange:
+		if settings.Min > settings.Max {
+			return errors.Errorf("min<%v> must be less than max<%v>", settings.Min, settings.Max)
+		}
+		if settings.Step < 0 {
+			return errors.Errorf("illegal step<%v>", settings.Step)
+		}
+	default:
+		return errors.Errorf("unknown value type<%s>", settings.Type)
+	}
+	return nil
+}
+
+// Execute SetVariableSettings action (Implements ActionSettings interface)
+func (settings SetVariableSettings) Execute(sessionState *session.State, actionState *action.State, connectionSettings *connection.ConnectionSettings, label string, reset func()) {
+	if sessionState.Connection == nil || sessionState.Connection.Sense() == nil {
+		actionState.AddErrors(errors.New("not connected to a Sense environment"))
+		return
+	}
+
+	app := sessionState.Connection.Sense().CurrentApp
+	if app == nil {
+		actionState.AddErrors(errors.New("not connected to a Sense app"))
+		return
+	}
+
+	rnd := sessionState.Randomizer()
+	if rnd == nil {
+		actionState.AddErrors(errors.New("No randomizer set on connection"))
+		return
+	}
+
+	var text string
+	var num float64
+	switch settings.Type {
+	case VariableValue:
+		var err error
+		if text, err = sessionState.ReplaceSessionVariables(&settings.Value); err != nil {
+			actionState.AddErrors(errors.WithStack(err))
+			return
+		}
+	case VariableRandomFromList:
+		var err error
+		if text, err = rnd.RandString(settings.Values); err != nil {
+			actionState.AddErrors(errors.Wrap(err, "failed to randomize variable value"))
+			return
+		}
+	case VariableRandomInRange:
+		num = randomInRange(settings.Min, settings.Max, settings.Step, rnd)
+		text = strconv.FormatFloat(num, 'f', -1, 64)
+	default:
+		actionState.AddErrors(errors.Errorf("unknown value type<%s>", settings.Type))
+		return
+	}
+
+	variableName := settings.Name
+	if variableName == "" {
+		variableName = sessionState.IDMap.Get(settings.ID)
+	}
+	actionState.Details = fmt.Sprintf("%s;%s", variableName, text)
+
+	sessionState.QueueRequest(func(ctx context.Context) error {
+		var variable *enigma.GenericVariable
+		if settings.Name != "" {
+			var err error
+			if variable, err = app.Doc.GetVariableByName(ctx, variableName); err != nil {
+				return errors.Wrapf(err, "failed to get variable<%s>", variableName)
+			}
+		} else {
+			byID, err := app.Doc.GetVariableById(ctx, variableName)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get variable<%s>", variableName)
+			}
+			// Engine returns a GenericVariable handle also when requested by ID
+			variable = &enigma.GenericVariable{RemoteObject: byID.RemoteObject}
+		}
+
+		if settings.Type == VariableRandomInRange {
+			return errors.WithStack(variable.SetNumValue(ctx, enigma.Float64(num)))
+		}
+		return errors.WithStack(variable.SetStringValue(ctx, text))
+	}, actionState, true, fmt.Sprintf("Failed to set variable<%s>", variableName))
+
+	sessionState.Wait(actionState)
+}
+
+// randomInRange random value in [min,max], restricted to min + n*step when step is set
+func randomInRange(min, max, step float64, rnd *randomizer.Randomizer) float64 {
+	if step <= 0 {
+		return rnd.RandFloat64(min, max)
+	}
+	steps := int(math.Floor((max-min)/step + 1e-9))
+	return min + float64(rnd.Rand(steps+1))*step
+}
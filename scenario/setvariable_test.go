@@ -0,0 +1,90 @@
+package scenario
+
+import (
+	"math"
+	"testing"
+
+	"github.com/qlik-oss/gopherciser/randomizer"
+)
+
+func TestSetVariableUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	raw := `{
+		"label" : "set discount",
+		"action" : "setvariable",
+		"settings": {
+			"name" : "vDiscount",
+			"type" : "range",
+			"min" : 0.1,
+			"max" : 0.5,
+			"step" : 0.05
+		}
+	}`
+	var item Action
+	if err := jsonit.Unmarshal([]byte(raw), &item); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := item.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	settings, ok := item.Settings.(*SetVariableSettings)
+	if !ok {
+		t.Fatalf("Failed to cast item settings<%T> to *SetVariableSettings", item.Settings)
+	}
+
+	validateString(t, "name", settings.Name, "vDiscount")
+	validateString(t, "type", settings.Type.String(), "range")
+	if settings.Min != 0.1 || settings.Max != 0.5 || settings.Step != 0.05 {
+		t.Errorf("unexpected range min<%v> max<%v> step<%v>", settings.Min, settings.Max, settings.Step)
+	}
+}
+
+func TestSetVariableValidate(t *testing.T) {
+	t.Parallel()
+
+	settings := SetVariableSettings{}
+	validateError(t, settings.Validate(), "specify exactly one of the following - variable name or variable id")
+	settings.Name = "vDiscount"
+	settings.ID = "id1"
+	validateError(t, settings.Validate(), "specify exactly one of the following - variable name or variable id")
+	settings.ID = ""
+	validateError(t, settings.Validate(), "")
+
+	settings.Type = VariableRandomFromList
+	validateError(t, settings.Validate(), "no values to randomize from")
+	settings.Values = []string{"a", "b"}
+	validateError(t, settings.Validate(), "")
+
+	settings.Type = VariableRandomInRange
+	settings.Min = 2
+	settings.Max = 1
+	validateError(t, settings.Validate(), "min<2> must be less than max<1>")
+	settings.Max = 3
+	settings.Step = -1
+	validateError(t, settings.Validate(), "illegal step<-1>")
+	settings.Step = 0.5
+	validateError(t, settings.Validate(), "")
+}
+
+func TestRandomInRange(t *testing.T) {
+	t.Parallel()
+
+	rnd := randomizer.NewSeededRandomizer(randomizer.GetPredictableSeedUInt64(1, 2))
+	for i := 0; i < 1000; i++ {
+		v := randomInRange(0.1, 0.5, 0.05, rnd)
+		if v < 0.1 || v > 0.5+1e-9 {
+			t.Fatalf("value<%v> out of range", v)
+		}
+		if steps := (v - 0.1) / 0.05; math.Abs(steps-math.Round(steps)) > 1e-9 {
+			t.Fatalf("value<%v> not on step", v)
+		}
+
+		v = randomInRange(-10, 10, 0, rnd)
+		if v < -10 || v > 10 {
+			t.Fatalf("value<%v> out of range", v)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/enigma-go"
+	"github.com/qlik-oss/gopherciser/action"
+	"github.com/qlik-oss/gopherciser/connection"
+	"github.com/qlik-oss/gopherciser/enummap"
+	"github.com/qlik-oss/gopherciser/globals/constant"
+	"github.com/qlik-oss/gopherciser/helpers"
+	"github.com/qlik-oss/gopherciser/logger"
+	"github.com/qlik-oss/gopherciser/session"
+)
+
+// smartSearchGroupCount number of search groups requested, default from client
+const smartSearchGroupCount = 10
+
+type (
+	// SmartSearchSelectMode which search result to select
+	SmartSearchSelectMode int
+
+	// SmartSearchSettings smart search settings
+	SmartSearchSettings struct {
+		// QuerySource source of query
+		QuerySource QuerySourceEnum `json:"querysource" displayname:"Query source" doc-key:"smartsearch.querysource"`
+		// Query search query
+		Query session.SyncedTemplate `json:"query" displayname:"Query" doc-key:"smartsearch.query"`
+		// QueryList search queries to randomize from
+		QueryList []session.SyncedTemplate `json:"querylist,omitempty" displayname:"Query list" doc-key:"smartsearch.querylist"`
+		// QueryFile file with search queries, one per line
+		QueryFile helpers.RowFile `json:"queryfile" displayname:"Query file" displayelement:"file" doc-key:"smartsearch.queryfile"`
+		// SelectMode search result to select
+		SelectMode SmartSearchSelectMode `json:"select" displayname:"Select search result" doc-key:"smartsearch.select"`
+	}
+)
+
+const (
+	// SmartSearchSelectNone don't select any search result
+	SmartSearchSelectNone SmartSearchSelectMode = iota
+	// SmartSearchSelectFirst select first search result
+	SmartSearchSelectFirst
+	// SmartSearchSelectRandom select random search result
+	SmartSearchSelectRandom
+)
+
+var smartSearchSelectModeEnumMap, _ = enummap.NewEnumMap(map[string]int{
+	"none":   int(SmartSearchSelectNone),
+	"first":  int(SmartSearchSelectFirst),
+	"random": int(SmartSearchSelectRandom),
+})
+
+func (value SmartSearchSelectMode) GetEnumMap() *enummap.EnumMap {
+	return smartSearchSelectModeEnumMap
+}
+
+// UnmarshalJSON unmarshal SmartSearchSelectMode
+func (value *SmartSearchSelectMode) UnmarshalJSON(arg []byte) error {
+	i, err := value.GetEnumMap().UnMarshal(arg)
+	if err != nil {
+		return errors.Wrap(err, "Failed to unmarshal SmartSearchSelectMode")
+	}
+
+	*value = SmartSearchSelectMode(i)
+	return nil
+}
+
+// MarshalJSON marshal SmartSearchSelectMode
+func (value SmartSearchSelectMode) MarshalJSON() ([]byte, error) {
+	str, err := value.GetEnumMap().String(int(value))
+	if err != nil {
+		return nil, errors.Errorf("Unknown SmartSearchSelectMode<%d>", value)
+	}
+	return []byte(fmt.Sprintf(`"%s"`, str)), nil
+}
+
+// String representation of SmartSearchSelectMode
+func (value SmartSearchSelectMode) String() string {
+	str, err := value.GetEnumMap().String(int(value))
+	if err != nil {
+		return strconv.Itoa(int(value))
+	}
+	return str
+}
+
+// Validate SmartSearchSettings action (Implements ActionSettings interface)
+func (settings SmartSearchSettings) Validate() error {
+	switch settings.QuerySource {
+	case QueryString:
+		if settings.Query.String() == "" {
+			return errors.New("Empty search query")
+		}
+	case QueryList:
+		if len(settings.QueryList) < 1 {
+			return errors.New("Empty search query list")
+		}
+	case FromFile:
+		if settings.QueryFile.IsEmpty() {
+			return errors.New("No query file")
+		}
+	default:
+		return errors.Errorf("Unknown query source <%s>", settings.QuerySource)
+	}
+
+	if _, err := settings.SelectMode.GetEnumMap().String(int(settings.SelectMode)); err != nil {
+		return errors.Errorf("Unknown select mode <%s>", settings.SelectMode)
+	}
+	return nil
+}
+
+// Execute SmartSearchSettings action (Implements ActionSettings interface)
+func (settings SmartSearchSettings) Execute(sessionState *session.State, actionState *action.State, connectionSettings *connection.ConnectionSettings, label string, reset func()) {
+	if sessionState.Connection == nil || sessionState.Connection.Sense() == nil {
+		actionState.AddErrors(errors.New("Not connected to a Sense environment"))
+		return
+	}
+
+	app := sessionState.Connection.Sense().CurrentApp
+	if app == nil {
+		actionState.AddErrors(errors.New("Not connected to a Sense app"))
+		return
+	}
+
+	query, err := settings.query(sessionState)
+	if err != nil {
+		actionState.AddErrors(errors.WithStack(err))
+		return
+	}
+	terms := strings.Fields(query)
+	if len(terms) < 1 {
+		actionState.AddErrors(errors.Errorf("No search terms in query<%s>", query))
+		return
+	}
+	actionState.Details = query
+
+	options := &enigma.SearchCombinationOptions{Context: constant.SearchContextCurrentSelections}
+
+	var suggestions *enigma.SearchSuggestionResult
+	suggestStart := time.Now()
+	if err := sessionState.SendRequest(actionState, func(ctx context.Context) error {
+		var err error
+		suggestions, err = app.Doc.SearchSuggest(ctx, options, terms)
+		return err
+	}); err != nil {
+		actionState.AddErrors(errors.Wrapf(err, "SearchSuggest for query<%s> failed", query))
+		return
+	}
+	suggestTime := time.Since(suggestStart)
+
+	var result *enigma.SearchResult
+	resultsStart := time.Now()
+	if err := sessionState.SendRequest(actionState, func(ctx context.Context) error {
+		var err error
+		result, err = app.Doc.SearchResults(ctx, options, terms, &enigma.SearchPage{Count: smartSearchGroupCount})
+		return err
+	}); err != nil {
+		actionState.AddErrors(errors.Wrapf(err, "SearchResults for query<%s> failed", query))
+		return
+	}
+	resultsTime := time.Since(resultsStart)
+
+	var groups []*enigma.SearchGroup
+	totalGroups, hits := 0, 0
+	if result != nil {
+		groups = result.SearchGroupArray
+		totalGroups = result.TotalNumberOfGroups
+		for _, group := range groups {
+			if group == nil {
+				continue
+			}
+			for _, item := range group.Items {
+				if item != nil {
+					hits += item.TotalNumberOfMatches
+				}
+			}
+		}
+	}
+	suggestionCount := 0
+	if suggestions != nil {
+		suggestionCount = len(suggestions.Suggestions)
+	}
+
+	sessionState.LogEntry.LogInfo("SmartSearch", fmt.Sprintf("query<%s> suggestions<%d> groups<%d> hits<%d> suggesttime<%v> resultstime<%v>",
+		query, suggestionCount, totalGroups, hits, suggestTime, resultsTime))
+
+	if settings.SelectMode == SmartSearchSelectNone {
+		return
+	}
+	if len(groups) < 1 {
+		sessionState.LogEntry.Logf(logger.WarningLevel, "No search results to select for query<%s>", query)
+		return
+	}
+
+	group := groups[0]
+	if settings.SelectMode == SmartSearchSelectRandom {
+		group = groups[sessionState.Randomizer().Rand(len(groups))]
+	}
+	if group == nil {
+		actionState.AddErrors(errors.Errorf("Search result for query<%s> is nil", query))
+		return
+	}
+	actionState.Details = fmt.Sprintf("%s;%d", query, group.Id)
+
+	sessionState.QueueRequest(func(ctx context.Context) error {
+		return errors.WithStack(app.Doc.SelectAssociations(ctx, options, terms, group.Id, false))
+	}, actionState, true, fmt.Sprintf("Failed to select search result<%d> for query<%s>", group.Id, query))
+
+	sessionState.Wait(actionState)
+}
+
+// query to search for
+func (settings SmartSearchSettings) query(sessionState *session.State) (string, error) {
+	switch settings.QuerySource {
+	case QueryString:
+		return sessionState.ReplaceSessionVariables(&settings.Query)
+	case QueryList:
+		if len(settings.QueryList) < 1 {
+			return "", errors.New("Empty search query list")
+		}
+		i := sessionState.Randomizer().Rand(len(settings.QueryList))
+		return sessionState.ReplaceSessionVariables(&settings.QueryList[i])
+	case FromFile:
+		query, err := sessionState.Randomizer().RandString(settings.QueryFile.Rows())
+		return query, errors.Wrap(err, "No queries read from query file")
+	default:
+		return "", errors.Errorf("Unknown query source <%s>", settings.QuerySource)
+	}
+}
@@ -0,0 +1,55 @@
+package scenario
+
+import (
+	"testing"
+)
+
+func TestSmartSearchUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	raw := `{
+		"label" : "smart search",
+		"action" : "smartsearch",
+		"settings": {
+			"querysource" : "list",
+			"querylist" : ["sweden 2019", "{{.UserName}}"],
+			"select" : "random"
+		}
+	}`
+	var item Action
+	if err := jsonit.Unmarshal([]byte(raw), &item); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := item.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	settings, ok := item.Settings.(*SmartSearchSettings)
+	if !ok {
+		t.Fatalf("Failed to cast item settings<%T> to *SmartSearchSettings", item.Settings)
+	}
+
+	validateInt(t, "querylist", len(settings.QueryList), 2)
+	validateString(t, "query", settings.QueryList[1].String(), "{{.UserName}}")
+	validateString(t, "select", settings.SelectMode.String(), "random")
+
+	marshaled, err := jsonit.Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validateString(t, "json", string(marshaled), `{"action":"smartsearch","label":"smart search","disabled":false,"settings":{"querysource":"list","query":"","querylist":["sweden 2019","{{.UserName}}"],"queryfile":"","select":"random"}}`)
+}
+
+func TestSmartSearchValidate(t *testing.T) {
+	t.Parallel()
+
+	settings := SmartSearchSettings{}
+	validateError(t, settings.Validate(), "Empty search query")
+
+	settings.QuerySource = QueryList
+	validateError(t, settings.Validate(), "Empty search query list")
+
+	settings.QuerySource = QuerySourceEnum(10)
+	validateError(t, settings.Validate(), "Unknown query source <10>")
+}
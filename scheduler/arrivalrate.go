@@ -0,0 +1,284 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/connection"
+	"github.com/qlik-oss/gopherciser/control"
+	"github.com/qlik-oss/gopherciser/enummap"
+	"github.com/qlik-oss/gopherciser/globals"
+	"github.com/qlik-oss/gopherciser/helpers"
+	"github.com/qlik-oss/gopherciser/logger"
+	"github.com/qlik-oss/gopherciser/randomizer"
+	"github.com/qlik-oss/gopherciser/scenario"
+	"github.com/qlik-oss/gopherciser/users"
+)
+
+type (
+	// ArrivalDistribution distribution of time in between arrivals
+	ArrivalDistribution int
+
+	// RateChange change arrival rate at offset from start of execution
+	RateChange struct {
+		Offset helpers.TimeDuration `json:"offset" displayname:"Offset" doc-key:"config.scheduler.settings.ratechanges.offset"`
+		Rate   float64              `json:"rate" displayname:"Rate" doc-key:"config.scheduler.settings.ratechanges.rate"`
+	}
+
+	// ArrivalRateSchedSettings arrival rate scheduler settings
+	ArrivalRateSchedSettings struct {
+		ExecutionTime int                 `json:"executionTime" displayname:"Execution time" doc-key:"config.scheduler.settings.executiontime"` // in seconds
+		Rate          float64             `json:"rate" displayname:"Arrival rate" doc-key:"config.scheduler.settings.rate"`                     // sessions per second
+		Distribution  ArrivalDistribution `json:"distribution,omitempty" displayname:"Arrival distribution" doc-key:"config.scheduler.settings.distribution"`
+		RateChanges   []RateChange        `json:"ratechanges,omitempty" displayname:"Rate changes" doc-key:"config.scheduler.settings.ratechanges"`
+		MaxInFlight   int                 `json:"maxinflight" displayname:"Max sessions in flight" doc-key:"config.scheduler.settings.maxinflight"`
+	}
+
+	// ArrivalRateScheduler starts new user sessions at a rate independent of how many sessions are still running
+	ArrivalRateScheduler struct {
+		Scheduler
+		Settings ArrivalRateSchedSettings `json:"settings" doc-key:"config.scheduler.settings"`
+	}
+)
+
+const (
+	// ArrivalConstant constant time in between arrivals
+	ArrivalConstant ArrivalDistribution = iota
+	// ArrivalPoisson arrivals following a poisson process, i.e. exponentially distributed time in between arrivals
+	ArrivalPoisson
+)
+
+func (value ArrivalDistribution) GetEnumMap() *enummap.EnumMap {
+	enumMap, _ := enummap.NewEnumMap(map[string]int{
+		"constant": int(ArrivalConstant),
+		"poisson":  int(ArrivalPoisson),
+	})
+	return enumMap
+}
+
+// UnmarshalJSON unmarshal arrival distribution from JSON
+func (value *ArrivalDistribution) UnmarshalJSON(arg []byte) error {
+	i, err := value.GetEnumMap().UnMarshal(arg)
+	if err != nil {
+		return errors.Wrap(err, "Failed to unmarshal ArrivalDistribution")
+	}
+
+	*value = ArrivalDistribution(i)
+	return nil
+}
+
+// MarshalJSON marshal arrival distribution to JSON
+func (value ArrivalDistribution) MarshalJSON() ([]byte, error) {
+	str, err := value.GetEnumMap().String(int(value))
+	if err != nil {
+		return nil, errors.Errorf("Unknown ArrivalDistribution<%d>", value)
+	}
+	return []byte(fmt.Sprintf(`"%s"`, str)), nil
+}
+
+// Validate schedule
+func (sched ArrivalRateScheduler) Validate() error {
+	// validate inherited settings
+	if err := sched.Scheduler.Validate(); err != nil {
+		return err
+	}
+
+	errorMsg := "Invalid arrival rate scheduler setting: "
+	if sched.Settings.ExecutionTime < 1 && sched.Settings.ExecutionTime != -1 {
+		return errors.Errorf("%s ExecutionTime<%d>", errorMsg, sched.Settings.ExecutionTime)
+	}
+	if sched.Settings.Rate < 0 {
+		return errors.Errorf("%s Rate<%f>", errorMsg, sched.Settings.Rate)
+	}
+
+	maxRate := sched.Settings.Rate
+	var lastOffset helpers.TimeDuration
+	for i, change := range sched.Settings.RateChanges {
+		if change.Rate < 0 {
+			return errors.Errorf("%s ratechange<%d> Rate<%f>", errorMsg, i, change.Rate)
+		}
+		if change.Offset < lastOffset {
+			return errors.Errorf("%s ratechange<%d> Offset<%v> before previous offset", errorMsg, i, time.Duration(change.Offset))
+		}
+		lastOffset = change.Offset
+		if change.Rate > maxRate {
+			maxRate = change.Rate
+		}
+	}
+	if maxRate <= 0 {
+		return errors.Errorf("%s no Rate > 0", errorMsg)
+	}
+
+	if sched.Settings.MaxInFlight < 1 {
+		return errors.Errorf("%s MaxInFlight<%d>", errorMsg, sched.Settings.MaxInFlight)
+	}
+	return nil
+}
+
+// Execute execute schedule
+func (sched ArrivalRateScheduler) Execute(ctx context.Context, log *logger.Log, timeout time.Duration,
+	scenario []scenario.Action, outputsDir string, users users.UserGenerator, connectionSettings *connection.ConnectionSettings) error {
+
+	sched.connectionSettings = connectionSettings
+
+	if sched.Settings.ExecutionTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(sched.Settings.ExecutionTime)*time.Second)
+		defer cancel()
+	}
+
+	instanceID := sched.InstanceNumber
+	if instanceID < 1 {
+		instanceID = 1
+	}
+	rnd := randomizer.NewSeededRandomizer(randomizer.GetPredictableSeedUInt64(instanceID, 0))
+
+	var (
+		wg       sync.WaitGroup
+		mErr     *multierror.Error
+		mErrLock sync.Mutex
+	)
+
+	inFlight := make(chan struct{}, sched.Settings.MaxInFlight)
+	logEntry := log.NewLogEntry()
+
+	// stopping execution through control API stops arrivals, started sessions finish their iteration
+	arrivalCtx, cancelArrivals := control.WithStop(ctx)
+	defer cancelArrivals()
+
+	start := time.Now()
+	next := start
+	for {
+		if helpers.IsContextTriggered(arrivalCtx) {
+			break
+		}
+
+		rate, nextChange := sched.rateAt(time.Since(start))
+		if rate <= 0 {
+			// No arrivals until rate changes
+			if nextChange < 0 {
+				break
+			}
+			next = start.Add(nextChange)
+			helpers.WaitFor(arrivalCtx, time.Until(next))
+			continue
+		}
+
+		next = next.Add(interArrival(rate, sched.Settings.Distribution, rnd))
+		if nextChange >= 0 && !next.Before(start.Add(nextChange)) {
+			// rate changes before next arrival, wait for change and re-evaluate arrivals using new rate
+			next = start.Add(nextChange)
+			helpers.WaitFor(arrivalCtx, time.Until(next))
+			continue
+		}
+		helpers.WaitFor(arrivalCtx, time.Until(next))
+		if helpers.IsContextTriggered(arrivalCtx) {
+			break
+		}
+
+		if control.WaitWhilePaused(arrivalCtx) {
+			// don't try to catch up on arrivals while paused
+			next = time.Now()
+			continue
+		}
+
+		select {
+		case inFlight <- struct{}{}:
+		default:
+			throttleStart := time.Now()
+			logEntry.Logf(logger.WarningLevel, "arrivals throttled, max sessions in flight<%d> reached", sched.Settings.MaxInFlight)
+			select {
+			case inFlight <- struct{}{}:
+			case <-arrivalCtx.Done():
+				continue
+			}
+			logEntry.Logf(logger.InfoLevel, "arrivals resumed after being throttled for %v", time.Since(throttleStart))
+			// don't try to catch up on arrivals delayed while throttled
+			next = time.Now()
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-inFlight }()
+
+			thread := globals.Threads.Inc()
+			userScenario, user, persona, err := sched.NextUser(ctx, scenario, users)
+			if err == nil {
+				err = sched.StartNewUser(ctx, timeout, log, userScenario, thread, outputsDir, user, persona, sched.connectionSettings, 1)
+			} else if userUnavailable(ctx, log, err) {
+				return
+			}
+			if err != nil {
+				mErrLock.Lock()
+				defer mErrLock.Unlock()
+				mErr = multierror.Append(mErr, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.WithStack(helpers.FlattenMultiError(mErr))
+}
+
+// rateAt arrival rate at elapsed time since start, and offset of next rate change, -1 if there are no more changes
+func (sched *ArrivalRateScheduler) rateAt(elapsed time.Duration) (float64, time.Duration) {
+	rate := sched.Settings.Rate
+	for _, change := range sched.Settings.RateChanges {
+		if time.Duration(change.Offset) > elapsed {
+			return rate, time.Duration(change.Offset)
+		}
+		rate = change.Rate
+	}
+	return rate, -1
+}
+
+// interArrival time until next arrival
+func interArrival(rate float64, distribution ArrivalDistribution, rnd *randomizer.Randomizer) time.Duration {
+	mean := float64(time.Second) / rate
+	if distribution == ArrivalPoisson {
+		return time.Duration(rnd.ExpFloat64() * mean)
+	}
+	return time.Duration(mean)
+}
+
+// Distribute split arrival rate and max sessions in flight into parts
+func (sched ArrivalRateScheduler) Distribute(parts int) ([]IScheduler, error) {
+	if parts < 1 {
+		return nil, errors.Errorf("can't distribute scheduler into <%d> parts", parts)
+	}
+	if sched.Settings.MaxInFlight < parts {
+		return nil, errors.Errorf("can't distribute MaxInFlight<%d> into <%d> parts", sched.Settings.MaxInFlight, parts)
+	}
+
+	schedulers := make([]IScheduler, 0, parts)
+	for i := 0; i < parts; i++ {
+		part := sched
+		part.connectionSettings = nil
+		part.personas = nil
+		part.InstanceNumber = sched.distributedInstance(i, parts)
+		part.Settings.Rate = sched.Settings.Rate / float64(parts)
+		part.Settings.MaxInFlight = splitCount(sched.Settings.MaxInFlight, i, parts)
+		if len(sched.Settings.RateChanges) > 0 {
+			part.Settings.RateChanges = make([]RateChange, 0, len(sched.Settings.RateChanges))
+			for _, change := range sched.Settings.RateChanges {
+				part.Settings.RateChanges = append(part.Settings.RateChanges, RateChange{
+					Offset: change.Offset,
+					Rate:   change.Rate / float64(parts),
+				})
+			}
+		}
+		schedulers = append(schedulers, &part)
+	}
+	return schedulers, nil
+}
+
+// RequireScenario report that scheduler requires a scenario
+func (sched *ArrivalRateScheduler) RequireScenario() bool {
+	return true
+}
@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/helpers"
+)
+
+func TestArrivalRateSched(t *testing.T) {
+	sched := &ArrivalRateScheduler{}
+	sched.Settings.ExecutionTime = 60
+
+	if err := errors.Cause(sched.Validate()); err == nil || err.Error() !=
+		"Invalid arrival rate scheduler setting:  no Rate > 0" {
+		t.Log(err)
+		t.Error("Rate validation failed")
+	}
+	sched.Settings.Rate = 2
+
+	if err := errors.Cause(sched.Validate()); err == nil || err.Error() !=
+		"Invalid arrival rate scheduler setting:  MaxInFlight<0>" {
+		t.Log(err)
+		t.Error("MaxInFlight validation failed")
+	}
+	sched.Settings.MaxInFlight = 10
+
+	sched.Settings.RateChanges = []RateChange{
+		{Offset: helpers.TimeDuration(time.Minute), Rate: 5},
+		{Offset: helpers.TimeDuration(time.Second), Rate: 1},
+	}
+	if err := errors.Cause(sched.Validate()); err == nil || err.Error() !=
+		"Invalid arrival rate scheduler setting:  ratechange<1> Offset<1s> before previous offset" {
+		t.Log(err)
+		t.Error("RateChanges validation failed")
+	}
+	sched.Settings.RateChanges[1].Offset = helpers.TimeDuration(2 * time.Minute)
+
+	if err := errors.Cause(sched.Validate()); err != nil {
+		t.Log(err)
+		t.Error("validation failed")
+	}
+
+	rateTests := []struct {
+		elapsed    time.Duration
+		rate       float64
+		nextChange time.Duration
+	}{
+		{0, 2, time.Minute},
+		{90 * time.Second, 5, 2 * time.Minute},
+		{3 * time.Minute, 1, -1},
+	}
+	for _, test := range rateTests {
+		rate, nextChange := sched.rateAt(test.elapsed)
+		if rate != test.rate || nextChange != test.nextChange {
+			t.Errorf("elapsed<%v> expected rate<%f> next change<%v>, got rate<%f> next change<%v>",
+				test.elapsed, test.rate, test.nextChange, rate, nextChange)
+		}
+	}
+
+	if interval := interArrival(4, ArrivalConstant, nil); interval != 250*time.Millisecond {
+		t.Errorf("expected constant interval of 250ms, got<%v>", interval)
+	}
+}
+
+func TestArrivalRateSessions(t *testing.T) {
+	sched := &ArrivalRateScheduler{}
+	sched.Settings.Rate = 100
+	sched.Settings.MaxInFlight = 10
+	executeSessions(t, sched, &sched.TimeBuf, 200*time.Millisecond)
+}
+
+func TestArrivalRateChanges(t *testing.T) {
+	tests := []struct {
+		name        string
+		rate        float64
+		changes     []RateChange
+		minSessions uint64
+		maxSessions uint64
+	}{
+		// arrivals at 150ms, 200ms, 250ms, 300ms and 350ms, not waiting for 2s interval of initial rate
+		{"StepUp", 0.5, []RateChange{{Offset: helpers.TimeDuration(100 * time.Millisecond), Rate: 20},
+			{Offset: helpers.TimeDuration(400 * time.Millisecond), Rate: 0}}, 4, 5},
+		// arrival scheduled at 500ms is after rate changed to 0
+		{"StepToZero", 2, []RateChange{{Offset: helpers.TimeDuration(100 * time.Millisecond), Rate: 0}}, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sched := &ArrivalRateScheduler{}
+			sched.Settings.Rate = test.rate
+			sched.Settings.RateChanges = test.changes
+			sched.Settings.MaxInFlight = 10
+			if sessions := countSessions(t, sched, time.Second); sessions < test.minSessions || sessions > test.maxSessions {
+				t.Errorf("expected %d-%d sessions, got<%d>", test.minSessions, test.maxSessions, sessions)
+			}
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/connection"
+	"github.com/qlik-oss/gopherciser/control"
+	"github.com/qlik-oss/gopherciser/enummap"
+	"github.com/qlik-oss/gopherciser/helpers"
+	"github.com/qlik-oss/gopherciser/logger"
+	"github.com/qlik-oss/gopherciser/scenario"
+	"github.com/qlik-oss/gopherciser/statistics"
+	"github.com/qlik-oss/gopherciser/users"
+)
+
+type (
+	// SLAType type of SLA criterion
+	SLAType int
+
+	// SLACriterion criterion to be met by a step of capacity search
+	SLACriterion struct {
+		Type       SLAType `json:"type" displayname:"Criterion type" doc-key:"config.scheduler.settings.sla.type"`
+		Action     string  `json:"action,omitempty" displayname:"Action" doc-key:"config.scheduler.settings.sla.action"`
+		Label      string  `json:"label,omitempty" displayname:"Action label" doc-key:"config.scheduler.settings.sla.label"`
+		Percentile float64 `json:"percentile,omitempty" displayname:"Percentile" doc-key:"config.scheduler.settings.sla.percentile"`
+		Threshold  float64 `json:"threshold" displayname:"Threshold" doc-key:"config.scheduler.settings.sla.threshold"`
+	}
+
+	// CapacitySchedSettings capacity search scheduler settings
+	CapacitySchedSettings struct {
+		StartUsers   int                  `json:"startusers" displayname:"Start users" doc-key:"config.scheduler.settings.startusers"`
+		Step         int                  `json:"step" displayname:"Users per step" doc-key:"config.scheduler.settings.step"`
+		MaxUsers     int                  `json:"maxusers" displayname:"Max users" doc-key:"config.scheduler.settings.maxusers"`
+		StepDuration helpers.TimeDuration `json:"stepduration" displayname:"Step duration" doc-key:"config.scheduler.settings.stepduration"`
+		RampupDelay  float64              `json:"rampupDelay,omitempty" displayname:"Rampup delay" doc-key:"config.scheduler.settings.rampupdelay"` // in seconds
+		Resolution   int                  `json:"resolution,omitempty" displayname:"Resolution" doc-key:"config.scheduler.settings.resolution"`
+		SLA          []SLACriterion       `json:"sla" displayname:"SLA criteria" doc-key:"config.scheduler.settings.sla"`
+	}
+
+	// CapacityScheduler searches for the max concurrent users meeting SLA criteria
+	CapacityScheduler struct {
+		Scheduler
+		Settings CapacitySchedSettings `json:"settings" doc-key:"config.scheduler.settings"`
+
+		maxUsers *int
+	}
+
+	// capacitySearch step up users until SLA is not met, then binary search in between highest users meeting SLA and
+	// lowest users not meeting SLA until within resolution
+	capacitySearch struct {
+		start, step, max, resolution int
+
+		// passed highest users meeting SLA, failed lowest users not meeting SLA, 0 when not yet known
+		passed, failed int
+		probed         bool
+	}
+
+	// slaWindow action results collected during measurement of a step
+	slaWindow struct {
+		criteria []SLACriterion
+
+		mu            sync.Mutex
+		responseTimes [][]float64
+		actions       []int
+		failed        []int
+	}
+)
+
+// SLAType enum
+const (
+	// SLAResponseTime response time percentile in milliseconds
+	SLAResponseTime SLAType = iota
+	// SLAErrorRate percentage of failed actions
+	SLAErrorRate
+)
+
+// DefaultSLAPercentile default response time percentile of SLA criterion
+const DefaultSLAPercentile = 90
+
+func (value SLAType) GetEnumMap() *enummap.EnumMap {
+	enumMap, _ := enummap.NewEnumMap(map[string]int{
+		"responsetime": int(SLAResponseTime),
+		"errorrate":    int(SLAErrorRate),
+	})
+	return enumMap
+}
+
+// UnmarshalJSON unmarshal SLA type from JSON
+func (value *SLAType) UnmarshalJSON(arg []byte) error {
+	i, err := value.GetEnumMap().UnMarshal(arg)
+	if err != nil {
+		return errors.Wrap(err, "Failed to unmarshal SLAType")
+	}
+
+	*value = SLAType(i)
+	return nil
+}
+
+// MarshalJSON marshal SLA type to JSON
+func (value SLAType) MarshalJSON() ([]byte, error) {
+	str, err := value.GetEnumMap().String(int(value))
+	if err != nil {
+		return nil, errors.Errorf("Unknown SLAType<%d>", value)
+	}
+	return []byte(fmt.Sprintf(`"%s"`, str)), nil
+}
+
+// Validate SLA criterion
+func (criterion *SLACriterion) Validate() error {
+	switch criterion.Type {
+	case SLAResponseTime:
+		if criterion.Threshold <= 0 {
+			return errors.Errorf("responsetime Threshold<%v>", criterion.Threshold)
+		}
+		if criterion.Percentile < 0 || criterion.Percentile > 100 {
+			return errors.Errorf("responsetime Percentile<%v>", criterion.Percentile)
+		}
+	case SLAErrorRate:
+		if criterion.Threshold < 0 || criterion.Threshold >= 100 {
+			return errors.Errorf("errorrate Threshold<%v>", criterion.Threshold)
+		}
+	default:
+		return errors.Errorf("unknown Type<%d>", criterion.Type)
+	}
+	return nil
+}
+
+// String description of SLA criterion
+func (criterion *SLACriterion) String() string {
+	actions := "actions"
+	if criterion.Action != "" {
+		actions = criterion.Action
+	}
+	if criterion.Label != "" {
+		actions = fmt.Sprintf("%s<%s>", actions, criterion.Label)
+	}
+
+	switch criterion.Type {
+	case SLAResponseTime:
+		return fmt.Sprintf("p%v of %s below %vms", criterion.percentile(), actions, criterion.Threshold)
+	case SLAErrorRate:
+		return fmt.Sprintf("error rate of %s below %v%%", actions, criterion.Threshold)
+	default:
+		return fmt.Sprintf("unknown SLA type<%d>", criterion.Type)
+	}
+}
+
+func (criterion *SLACriterion) percentile() float64 {
+	if criterion.Percentile <= 0 {
+		return DefaultSLAPercentile
+	}
+	return criterion.Percentile
+}
+
+func (criterion *SLACriterion) matches(result statistics.ActionResult) bool {
+	return (criterion.Action == "" || criterion.Action == result.Name) &&
+		(criterion.Label == "" || criterion.Label == result.Label)
+}
+
+// Validate schedule
+func (sched CapacityScheduler) Validate() error {
+	// validate inherited settings
+	if err := sched.Scheduler.Validate(); err != nil {
+		return err
+	}
+
+	errorMsg := "Invalid capacity scheduler setting: "
+	if sched.Settings.StartUsers < 1 {
+		return errors.Errorf("%s StartUsers<%d>", errorMsg, sched.Settings.StartUsers)
+	}
+	if sched.Settings.Step < 1 {
+		return errors.Errorf("%s Step<%d>", errorMsg, sched.Settings.Step)
+	}
+	if sched.Settings.MaxUsers < sched.Settings.StartUsers {
+		return errors.Errorf("%s MaxUsers<%d> lower than StartUsers<%d>", errorMsg, sched.Settings.MaxUsers, sched.Settings.StartUsers)
+	}
+	if sched.Settings.StepDuration <= 0 {
+		return errors.Errorf("%s StepDuration<%v>", errorMsg, time.Duration(sched.Settings.StepDuration))
+	}
+	if sched.Settings.RampupDelay < 0 {
+		return errors.Errorf("%s RampupDelay<%f>", errorMsg, sched.Settings.RampupDelay)
+	}
+	if sched.Settings.Resolution < 0 {
+		return errors.Errorf("%s Resolution<%d>", errorMsg, sched.Settings.Resolution)
+	}
+	if len(sched.Settings.SLA) < 1 {
+		return errors.Errorf("%s no SLA criteria defined", errorMsg)
+	}
+	for i, criterion := range sched.Settings.SLA {
+		if err := criterion.Validate(); err != nil {
+			return errors.Wrapf(err, "%s SLA<%d>", errorMsg, i)
+		}
+	}
+
+	return nil
+}
+
+// Execute execute schedule
+func (sched *CapacityScheduler) Execute(ctx context.Context, log *logger.Log, timeout time.Duration,
+	scenario []scenario.Action, outputsDir string, users users.UserGenerator, connectionSettings *connection.ConnectionSettings) error {
+
+	sched.connectionSettings = connectionSettings
+	sched.maxUsers = nil
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	threads := newUserThreads(ctx, func(ctx context.Context, stopped func() bool) error {
+		return sched.iterateNewUsers(ctx, stopped, timeout, log, scenario, outputsDir, users, -1)
+	})
+
+	// stopping execution through control API ends search with current result
+	stepCtx, cancelSteps := control.WithStop(ctx)
+	defer cancelSteps()
+
+	logEntry := log.NewLogEntry()
+	search := newCapacitySearch(sched.Settings)
+	rampupDelay := time.Duration(sched.Settings.RampupDelay * float64(time.Second))
+
+	for users := search.next(); users > 0; users = search.next() {
+		diff := users - threads.Count()
+		if diff < 0 {
+			diff = -diff
+		}
+		runStage(stepCtx, threads, Stage{Users: users, Duration: helpers.TimeDuration(time.Duration(diff) * rampupDelay)})
+
+		window := newSLAWindow(sched.Settings.SLA)
+		removeListener := statistics.AddResultListener(window.add)
+		helpers.WaitFor(stepCtx, time.Duration(sched.Settings.StepDuration))
+		removeListener()
+		if helpers.IsContextTriggered(stepCtx) {
+			break
+		}
+
+		met, details := window.evaluate()
+		search.report(users, met)
+		logEntry.LogInfo("CapacityStep", fmt.Sprintf("users<%d> SLA met<%v>: %s", users, met, details))
+	}
+
+	maxUsers := search.passed
+	sched.maxUsers = &maxUsers
+	if search.done() {
+		logEntry.LogInfo("CapacityResult", fmt.Sprintf("max users meeting SLA<%d>", maxUsers))
+	} else {
+		logEntry.LogInfo("CapacityResult", fmt.Sprintf("search not finished, max users meeting SLA so far<%d>", maxUsers))
+	}
+
+	// users still active are disconnected the same way as when reaching execution time of the simple scheduler
+	cancel()
+
+	return errors.WithStack(threads.Wait())
+}
+
+// SummaryResults max users meeting SLA, once executed
+func (sched *CapacityScheduler) SummaryResults() []SummaryResult {
+	if sched.maxUsers == nil {
+		return nil
+	}
+	return []SummaryResult{{
+		Name:  "MaxUsersMeetingSLA",
+		Title: "Max users meeting SLA",
+		Value: strconv.Itoa(*sched.maxUsers),
+	}}
+}
+
+// RequireScenario report that scheduler requires a scenario
+func (sched *CapacityScheduler) RequireScenario() bool {
+	return true
+}
+
+func newCapacitySearch(settings CapacitySchedSettings) *capacitySearch {
+	resolution := settings.Resolution
+	if resolution < 1 {
+		resolution = settings.Step
+	}
+	return &capacitySearch{
+		start:      settings.StartUsers,
+		step:       settings.Step,
+		max:        settings.MaxUsers,
+		resolution: resolution,
+	}
+}
+
+// next users to probe, 0 when search is done
+func (search *capacitySearch) next() int {
+	if !search.probed {
+		return search.start
+	}
+	if search.done() {
+		return 0
+	}
+
+	if search.failed == 0 {
+		// step up until SLA is not met
+		next := search.passed + search.step
+		if next > search.max {
+			next = search.max
+		}
+		return next
+	}
+
+	return search.passed + (search.failed-search.passed)/2
+}
+
+// done search has found highest users meeting SLA within resolution, or SLA is met at max users
+func (search *capacitySearch) done() bool {
+	if !search.probed {
+		return false
+	}
+	if search.failed == 0 {
+		return search.passed >= search.max
+	}
+	return search.failed-search.passed <= search.resolution
+}
+
+// report result of probing users
+func (search *capacitySearch) report(users int, met bool) {
+	search.probed = true
+	if met {
+		if users > search.passed {
+			search.passed = users
+		}
+		return
+	}
+	if search.failed == 0 || users < search.failed {
+		search.failed = users
+	}
+}
+
+func newSLAWindow(criteria []SLACriterion) *slaWindow {
+	return &slaWindow{
+		criteria:      criteria,
+		responseTimes: make([][]float64, len(criteria)),
+		actions:       make([]int, len(criteria)),
+		failed:        make([]int, len(criteria)),
+	}
+}
+
+func (window *slaWindow) add(result statistics.ActionResult) {
+	window.mu.Lock()
+	defer window.mu.Unlock()
+
+	for i, criterion := range window.criteria {
+		if !criterion.matches(result) {
+			continue
+		}
+		window.actions[i]++
+		if !result.Success {
+			window.failed[i]++
+			continue
+		}
+		if criterion.Type == SLAResponseTime {
+			window.responseTimes[i] = append(window.responseTimes[i], float64(result.ResponseTime)/float64(time.Millisecond))
+		}
+	}
+}
+
+// evaluate if all SLA criteria are met, a criterion without any matching actions is not met
+func (window *slaWindow) evaluate() (bool, string) {
+	window.mu.Lock()
+	defer window.mu.Unlock()
+
+	met := true
+	details := make([]string, 0, len(window.criteria))
+	for i, criterion := range window.criteria {
+		var value string
+		criterionMet := false
+		switch criterion.Type {
+		case SLAResponseTime:
+			if len(window.responseTimes[i]) > 0 {
+				p := statistics.Percentile(window.responseTimes[i], criterion.percentile())
+				criterionMet = p < criterion.Threshold
+				value = fmt.Sprintf("%.0fms", p)
+			}
+		case SLAErrorRate:
+			if window.actions[i] > 0 {
+				rate := 100 * float64(window.failed[i]) / float64(window.actions[i])
+				criterionMet = rate < criterion.Threshold
+				value = fmt.Sprintf("%.2f%%", rate)
+			}
+		}
+		if value == "" {
+			value = "no actions"
+		}
+		met = met && criterionMet
+		details = append(details, fmt.Sprintf("%s<%s>", criterion.String(), value))
+	}
+	return met, strings.Join(details, " ")
+}
@@ -0,0 +1,175 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/helpers"
+	"github.com/qlik-oss/gopherciser/statistics"
+)
+
+func TestCapacitySched(t *testing.T) {
+	raw := []byte(`{
+		"type" : "capacity",
+		"settings" : {
+			"startusers" : 10,
+			"step" : 10,
+			"maxusers" : 100,
+			"stepduration" : "5m",
+			"rampupDelay" : 1,
+			"sla" : [
+				{ "type" : "responsetime", "action" : "changesheet", "threshold" : 3000 },
+				{ "type" : "errorrate", "threshold" : 1 }
+			]
+		}
+	}`)
+
+	sched, err := UnmarshalScheduler(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	capacity, ok := sched.(*CapacityScheduler)
+	if !ok {
+		t.Fatalf("unexpected scheduler type<%T>", sched)
+	}
+	if err := capacity.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if capacity.Settings.StepDuration != helpers.TimeDuration(5*time.Minute) || len(capacity.Settings.SLA) != 2 ||
+		capacity.Settings.SLA[1].Type != SLAErrorRate {
+		t.Errorf("unexpected settings<%+v>", capacity.Settings)
+	}
+	if results := capacity.SummaryResults(); results != nil {
+		t.Errorf("unexpected results before execution<%v>", results)
+	}
+
+	capacity.Settings.MaxUsers = 5
+	if err := errors.Cause(capacity.Validate()); err == nil || err.Error() !=
+		"Invalid capacity scheduler setting:  MaxUsers<5> lower than StartUsers<10>" {
+		t.Log(err)
+		t.Error("MaxUsers validation failed")
+	}
+	capacity.Settings.MaxUsers = 100
+
+	capacity.Settings.SLA[1].Threshold = 100
+	if err := capacity.Validate(); err == nil {
+		t.Error("SLA validation failed")
+	}
+}
+
+func TestCapacitySearch(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   CapacitySchedSettings
+		capacity   int
+		probes     []int
+		maxUsersOK int
+	}{
+		{
+			name:       "step back",
+			settings:   CapacitySchedSettings{StartUsers: 10, Step: 10, MaxUsers: 100},
+			capacity:   35,
+			probes:     []int{10, 20, 30, 40},
+			maxUsersOK: 30,
+		},
+		{
+			name:       "binary search",
+			settings:   CapacitySchedSettings{StartUsers: 10, Step: 10, MaxUsers: 100, Resolution: 1},
+			capacity:   35,
+			probes:     []int{10, 20, 30, 40, 35, 37, 36},
+			maxUsersOK: 35,
+		},
+		{
+			name:       "max users",
+			settings:   CapacitySchedSettings{StartUsers: 10, Step: 20, MaxUsers: 45},
+			capacity:   100,
+			probes:     []int{10, 30, 45},
+			maxUsersOK: 45,
+		},
+		{
+			name:       "below start",
+			settings:   CapacitySchedSettings{StartUsers: 10, Step: 10, MaxUsers: 100, Resolution: 2},
+			capacity:   3,
+			probes:     []int{10, 5, 2, 3},
+			maxUsersOK: 3,
+		},
+	}
+
+	for _, test := range tests {
+		search := newCapacitySearch(test.settings)
+		var probes []int
+		for users := search.next(); users > 0 && len(probes) < 20; users = search.next() {
+			probes = append(probes, users)
+			search.report(users, users <= test.capacity)
+		}
+
+		if len(probes) != len(test.probes) {
+			t.Errorf("%s: expected probes<%v> got<%v>", test.name, test.probes, probes)
+		} else {
+			for i := range probes {
+				if probes[i] != test.probes[i] {
+					t.Errorf("%s: expected probes<%v> got<%v>", test.name, test.probes, probes)
+					break
+				}
+			}
+		}
+		if !search.done() || search.passed != test.maxUsersOK {
+			t.Errorf("%s: expected done<true> max users<%d> got done<%v> max users<%d>", test.name, test.maxUsersOK,
+				search.done(), search.passed)
+		}
+	}
+}
+
+func TestSLAWindow(t *testing.T) {
+	window := newSLAWindow([]SLACriterion{
+		{Type: SLAResponseTime, Action: "changesheet", Threshold: 3000},
+		{Type: SLAErrorRate, Threshold: 1},
+	})
+
+	if met, details := window.evaluate(); met {
+		t.Errorf("expected SLA not met without actions: %s", details)
+	}
+
+	for i := 0; i < 100; i++ {
+		window.add(statistics.ActionResult{Name: "changesheet", Success: true, ResponseTime: time.Duration(i*25) * time.Millisecond})
+		window.add(statistics.ActionResult{Name: "select", Success: true, ResponseTime: time.Minute})
+	}
+	met, details := window.evaluate()
+	if !met {
+		t.Errorf("expected SLA met: %s", details)
+	}
+
+	// 2 failed of 202 actions, error rate ~0.99%
+	window.add(statistics.ActionResult{Name: "select", Success: false})
+	window.add(statistics.ActionResult{Name: "select", Success: false})
+	if met, details := window.evaluate(); !met {
+		t.Errorf("expected SLA met: %s", details)
+	}
+
+	window.add(statistics.ActionResult{Name: "select", Success: false})
+	if met, details := window.evaluate(); met {
+		t.Errorf("expected error rate SLA not met: %s", details)
+	}
+
+	// p90 of changesheet 2250ms -> 3025ms
+	window = newSLAWindow([]SLACriterion{{Type: SLAResponseTime, Action: "changesheet", Threshold: 3000}})
+	for i := 0; i < 100; i++ {
+		window.add(statistics.ActionResult{Name: "changesheet", Success: true, ResponseTime: time.Duration(i*25+800) * time.Millisecond})
+	}
+	if met, details := window.evaluate(); met {
+		t.Errorf("expected response time SLA not met: %s", details)
+	}
+}
+
+func TestCapacitySessions(t *testing.T) {
+	sched := &CapacityScheduler{}
+	sched.Settings = CapacitySchedSettings{
+		StartUsers:   3,
+		Step:         1,
+		MaxUsers:     3,
+		StepDuration: helpers.TimeDuration(100 * time.Millisecond),
+		SLA:          []SLACriterion{{Type: SLAErrorRate, Threshold: 1}},
+	}
+	executeSessions(t, sched, &sched.TimeBuf, time.Second)
+}
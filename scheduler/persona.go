@@ -0,0 +1,200 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/netemulation"
+	"github.com/qlik-oss/gopherciser/randomizer"
+	"github.com/qlik-oss/gopherciser/scenario"
+	"github.com/qlik-oss/gopherciser/users"
+)
+
+type (
+	// Persona named scenario executed by a part of the simulated users
+	Persona struct {
+		Name          string                   `json:"name" displayname:"Persona name" doc-key:"config.personas.name"`
+		Weight        int                      `json:"weight,omitempty" displayname:"Weight" doc-key:"config.personas.weight"`
+		Share         float64                  `json:"share,omitempty" displayname:"User share" doc-key:"config.personas.share"` // percent of users
+		Scenario      []scenario.Action        `json:"scenario" displayname:"Scenario" doc-key:"config.personas.scenario"`
+		LoginSettings *users.UserGenerator     `json:"loginSettings,omitempty" displayname:"Login settings" doc-key:"config.personas.loginsettings"`
+		Network       *netemulation.Conditions `json:"network,omitempty" displayname:"Network conditions" doc-key:"config.personas.network"`
+	}
+
+	// PersonaScheduler scheduler able to assign personas to users, implemented by all schedulers embedding Scheduler
+	PersonaScheduler interface {
+		SetPersonas(personas []Persona) error
+	}
+
+	// personaSelector assigns a persona to each new user, either randomized using weights or deterministically
+	// following user shares
+	personaSelector struct {
+		personas []Persona
+		weights  []int
+		rnd      *randomizer.Randomizer
+
+		assigned []uint64
+		total    uint64
+
+		mu sync.Mutex
+	}
+)
+
+// ValidatePersonas validate list of personas
+func ValidatePersonas(personas []Persona) error {
+	if len(personas) < 1 {
+		return nil
+	}
+
+	names := make(map[string]struct{}, len(personas))
+	useShares := personas[0].Share > 0
+	var sumShares float64
+	for i, persona := range personas {
+		if persona.Name == "" {
+			return errors.Errorf("persona<%d> has no name", i)
+		}
+		if _, exists := names[persona.Name]; exists {
+			return errors.Errorf("persona name<%s> used more than once", persona.Name)
+		}
+		names[persona.Name] = struct{}{}
+
+		if len(persona.Scenario) < 1 {
+			return errors.Errorf("persona<%s> has no scenario items defined", persona.Name)
+		}
+		for _, act := range persona.Scenario {
+			if err := act.Validate(); err != nil {
+				return errors.Wrapf(err, "persona<%s>", persona.Name)
+			}
+		}
+
+		if persona.LoginSettings != nil {
+			if persona.LoginSettings.Settings == nil {
+				return errors.Errorf("persona<%s> has invalid loginSettings", persona.Name)
+			}
+			if err := persona.LoginSettings.Settings.Validate(); err != nil {
+				return errors.Wrapf(err, "persona<%s> loginSettings validation failed", persona.Name)
+			}
+		}
+
+		if persona.Network != nil {
+			if err := persona.Network.Validate(); err != nil {
+				return errors.Wrapf(err, "persona<%s>", persona.Name)
+			}
+		}
+
+		if persona.Weight < 0 || persona.Share < 0 {
+			return errors.Errorf("persona<%s> has negative weight or share", persona.Name)
+		}
+		if useShares {
+			if persona.Weight > 0 || persona.Share <= 0 {
+				return errors.Errorf("persona<%s> has no share, either all or none of the personas should define share", persona.Name)
+			}
+			sumShares += persona.Share
+		} else if persona.Share > 0 {
+			return errors.Errorf("persona<%s> has share, either all or none of the personas should define share", persona.Name)
+		} else if persona.Weight < 1 {
+			return errors.Errorf("persona<%s> has no weight or share", persona.Name)
+		}
+	}
+
+	if useShares && (sumShares < 99.99 || sumShares > 100.01) {
+		return errors.Errorf("sum of persona shares<%f> should be 100", sumShares)
+	}
+
+	return nil
+}
+
+// SetPersonas to be assigned to new users instead of the default scenario and user generator
+func (sched *Scheduler) SetPersonas(personas []Persona) error {
+	if err := ValidatePersonas(personas); err != nil {
+		return errors.WithStack(err)
+	}
+	if len(personas) < 1 {
+		sched.personas = nil
+		return nil
+	}
+
+	instanceID := sched.InstanceNumber
+	if instanceID < 1 {
+		instanceID = 1
+	}
+
+	selector := &personaSelector{
+		personas: personas,
+		assigned: make([]uint64, len(personas)),
+	}
+	if personas[0].Share <= 0 {
+		selector.weights = make([]int, 0, len(personas))
+		for _, persona := range personas {
+			selector.weights = append(selector.weights, persona.Weight)
+		}
+		selector.rnd = randomizer.NewSeededRandomizer(randomizer.GetPredictableSeedUInt64(instanceID, 0))
+	}
+	sched.personas = selector
+
+	return nil
+}
+
+// next persona to assign to a new user
+func (selector *personaSelector) next() (*Persona, error) {
+	selector.mu.Lock()
+	defer selector.mu.Unlock()
+
+	var i int
+	if selector.weights != nil {
+		var err error
+		if i, err = selector.rnd.RandWeightedInt(selector.weights); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	} else {
+		// select persona furthest behind its share
+		selector.total++
+		maxDeficit := 0.0
+		for j, persona := range selector.personas {
+			deficit := persona.Share*float64(selector.total)/100 - float64(selector.assigned[j])
+			if j == 0 || deficit > maxDeficit {
+				i, maxDeficit = j, deficit
+			}
+		}
+	}
+	selector.assigned[i]++
+
+	return &selector.personas[i], nil
+}
+
+// personaNetwork network conditions of persona, nil when persona doesn't override network conditions
+func (sched *Scheduler) personaNetwork(name string) *netemulation.Conditions {
+	if sched.personas == nil || name == "" {
+		return nil
+	}
+	for i := range sched.personas.personas {
+		if sched.personas.personas[i].Name == name {
+			return sched.personas.personas[i].Network
+		}
+	}
+	return nil
+}
+
+// NextUser scenario, user and persona name to be used by a new user. Without personas the default scenario and user
+// generator are used and persona name is empty. When the user generator leases users, NextUser blocks until a user is
+// available and the user has to be released when the session ends.
+func (sched *Scheduler) NextUser(ctx context.Context, userScenario []scenario.Action, userGenerator users.UserGenerator) ([]scenario.Action, *users.User, string, error) {
+	if sched.personas == nil {
+		user, err := userGenerator.GetNextLeased(ctx)
+		return userScenario, user, "", errors.WithStack(err)
+	}
+
+	persona, err := sched.personas.next()
+	if err != nil {
+		return nil, nil, "", errors.Wrap(err, "failed to assign persona")
+	}
+	if persona.LoginSettings != nil {
+		userGenerator = *persona.LoginSettings
+	}
+	user, err := userGenerator.GetNextLeased(ctx)
+	if err != nil {
+		return nil, nil, "", errors.Wrapf(err, "failed to get user for persona<%s>", persona.Name)
+	}
+	return persona.Scenario, user, persona.Name, nil
+}
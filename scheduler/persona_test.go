@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/qlik-oss/gopherciser/helpers"
+	"github.com/qlik-oss/gopherciser/scenario"
+)
+
+func TestPersonaShares(t *testing.T) {
+	think := scenario.Action{ActionCore: scenario.ActionCore{Type: scenario.ActionThinkTime}, Settings: &scenario.ThinkTimeSettings{DistributionSettings: helpers.DistributionSettings{Type: helpers.StaticDistribution, Delay: 1}}}
+	personas := []Persona{
+		{Name: "consumer", Share: 80, Scenario: []scenario.Action{think}},
+		{Name: "author", Share: 20, Scenario: []scenario.Action{think}},
+	}
+
+	var sched Scheduler
+	if err := sched.SetPersonas(personas); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		persona, err := sched.personas.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[persona.Name]++
+	}
+
+	if counts["consumer"] != 80 || counts["author"] != 20 {
+		t.Errorf("unexpected persona distribution<%v>, expected consumer<80> author<20>", counts)
+	}
+}
+
+func TestPersonaValidate(t *testing.T) {
+	think := scenario.Action{ActionCore: scenario.ActionCore{Type: scenario.ActionThinkTime}, Settings: &scenario.ThinkTimeSettings{DistributionSettings: helpers.DistributionSettings{Type: helpers.StaticDistribution, Delay: 1}}}
+
+	tests := []struct {
+		name     string
+		personas []Persona
+		valid    bool
+	}{
+		{"weights", []Persona{{Name: "a", Weight: 3, Scenario: []scenario.Action{think}}, {Name: "b", Weight: 1, Scenario: []scenario.Action{think}}}, true},
+		{"noname", []Persona{{Weight: 1, Scenario: []scenario.Action{think}}}, false},
+		{"duplicate", []Persona{{Name: "a", Weight: 1, Scenario: []scenario.Action{think}}, {Name: "a", Weight: 1, Scenario: []scenario.Action{think}}}, false},
+		{"noscenario", []Persona{{Name: "a", Weight: 1}}, false},
+		{"noweight", []Persona{{Name: "a", Scenario: []scenario.Action{think}}}, false},
+		{"mixed", []Persona{{Name: "a", Share: 50, Scenario: []scenario.Action{think}}, {Name: "b", Weight: 1, Scenario: []scenario.Action{think}}}, false},
+	}
+
+	for _, test := range tests {
+		err := ValidatePersonas(test.personas)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected validation error: %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected validation error", test.name)
+		}
+	}
+}
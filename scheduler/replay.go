@@ -0,0 +1,280 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/connection"
+	"github.com/qlik-oss/gopherciser/enummap"
+	"github.com/qlik-oss/gopherciser/helpers"
+	"github.com/qlik-oss/gopherciser/logger"
+	"github.com/qlik-oss/gopherciser/scenario"
+	"github.com/qlik-oss/gopherciser/users"
+)
+
+type (
+	// ReplayMode how to interpret the values of a replayed usage curve
+	ReplayMode int
+
+	// ReplaySchedSettings replay scheduler settings
+	ReplaySchedSettings struct {
+		File            string     `json:"file" displayname:"Usage curve file" displayelement:"file" doc-key:"config.scheduler.settings.file"`
+		Mode            ReplayMode `json:"mode,omitempty" displayname:"Curve values" doc-key:"config.scheduler.settings.mode"`
+		TimeCompression float64    `json:"timecompression,omitempty" displayname:"Time compression" doc-key:"config.scheduler.settings.timecompression"`
+		MaxInFlight     int        `json:"maxinflight,omitempty" displayname:"Max sessions in flight" doc-key:"config.scheduler.settings.maxinflight"`
+	}
+
+	// ReplayScheduler replays a usage curve, e.g. exported from production monitoring
+	ReplayScheduler struct {
+		Scheduler
+		Settings ReplaySchedSettings `json:"settings" doc-key:"config.scheduler.settings"`
+	}
+
+	// replayPoint value of usage curve at offset from start of curve
+	replayPoint struct {
+		offset time.Duration
+		value  float64
+	}
+)
+
+const (
+	// ReplayConcurrentUsers curve values are concurrent users
+	ReplayConcurrentUsers ReplayMode = iota
+	// ReplaySessionStarts curve values are session starts per minute
+	ReplaySessionStarts
+)
+
+func (value ReplayMode) GetEnumMap() *enummap.EnumMap {
+	enumMap, _ := enummap.NewEnumMap(map[string]int{
+		"concurrentusers": int(ReplayConcurrentUsers),
+		"sessionstarts":   int(ReplaySessionStarts),
+	})
+	return enumMap
+}
+
+// UnmarshalJSON unmarshal replay mode from JSON
+func (value *ReplayMode) UnmarshalJSON(arg []byte) error {
+	i, err := value.GetEnumMap().UnMarshal(arg)
+	if err != nil {
+		return errors.Wrap(err, "Failed to unmarshal ReplayMode")
+	}
+
+	*value = ReplayMode(i)
+	return nil
+}
+
+// MarshalJSON marshal replay mode to JSON
+func (value ReplayMode) MarshalJSON() ([]byte, error) {
+	str, err := value.GetEnumMap().String(int(value))
+	if err != nil {
+		return nil, errors.Errorf("Unknown ReplayMode<%d>", value)
+	}
+	return []byte(fmt.Sprintf(`"%s"`, str)), nil
+}
+
+// Validate schedule
+func (sched ReplayScheduler) Validate() error {
+	// validate inherited settings
+	if err := sched.Scheduler.Validate(); err != nil {
+		return err
+	}
+
+	errorMsg := "Invalid replay scheduler setting: "
+	if sched.Settings.File == "" {
+		return errors.Errorf("%s no file defined", errorMsg)
+	}
+	if _, err := sched.Settings.Mode.GetEnumMap().String(int(sched.Settings.Mode)); err != nil {
+		return errors.Errorf("%s Mode<%d>", errorMsg, sched.Settings.Mode)
+	}
+	if sched.Settings.TimeCompression < 0 {
+		return errors.Errorf("%s TimeCompression<%f>", errorMsg, sched.Settings.TimeCompression)
+	}
+	if sched.Settings.Mode == ReplaySessionStarts && sched.Settings.MaxInFlight < 1 {
+		return errors.Errorf("%s MaxInFlight<%d>", errorMsg, sched.Settings.MaxInFlight)
+	}
+
+	points, err := readReplayFile(sched.Settings.File)
+	if err != nil {
+		return errors.Wrap(err, errorMsg)
+	}
+	maxValue := 0.0
+	for _, point := range points {
+		maxValue = math.Max(maxValue, point.value)
+	}
+	if maxValue <= 0 {
+		return errors.Errorf("%s no value > 0 in file<%s>", errorMsg, sched.Settings.File)
+	}
+
+	return nil
+}
+
+// Execute execute schedule
+func (sched ReplayScheduler) Execute(ctx context.Context, log *logger.Log, timeout time.Duration,
+	scenario []scenario.Action, outputsDir string, users users.UserGenerator, connectionSettings *connection.ConnectionSettings) error {
+
+	points, err := readReplayFile(sched.Settings.File)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var replay IScheduler
+	switch sched.Settings.Mode {
+	case ReplaySessionStarts:
+		replay = sched.arrivalRateScheduler(points)
+	default:
+		replay = sched.stagesScheduler(points)
+	}
+
+	return errors.WithStack(replay.Execute(ctx, log, timeout, scenario, outputsDir, users, connectionSettings))
+}
+
+// timeCompression factor to compress curve with, defaults to 1
+func (sched *ReplayScheduler) timeCompression() float64 {
+	if sched.Settings.TimeCompression <= 0 {
+		return 1
+	}
+	return sched.Settings.TimeCompression
+}
+
+// stagesScheduler ramping concurrent users linearly in between points of curve
+func (sched *ReplayScheduler) stagesScheduler(points []replayPoint) *StagesScheduler {
+	compression := sched.timeCompression()
+	stages := make([]Stage, 0, len(points))
+	var prevOffset time.Duration
+	for i, point := range points {
+		duration := point.offset - prevOffset
+		if i == 0 {
+			// wait until first point and start its users at once
+			stages = append(stages, Stage{Duration: helpers.TimeDuration(float64(duration) / compression)})
+			duration = 0
+		}
+		stages = append(stages, Stage{
+			Users:    int(math.Round(point.value)),
+			Duration: helpers.TimeDuration(float64(duration) / compression),
+		})
+		prevOffset = point.offset
+	}
+
+	return &StagesScheduler{
+		Scheduler: sched.Scheduler,
+		Settings:  StagesSchedSettings{Stages: stages},
+	}
+}
+
+// arrivalRateScheduler starting sessions at the rate of the curve at each point until the next point, arrivals end
+// at the last point
+func (sched *ReplayScheduler) arrivalRateScheduler(points []replayPoint) *ArrivalRateScheduler {
+	compression := sched.timeCompression()
+	changes := make([]RateChange, 0, len(points)+1)
+	for i, point := range points {
+		rate := point.value / 60 * compression
+		if i == len(points)-1 {
+			rate = 0
+		}
+		changes = append(changes, RateChange{
+			Offset: helpers.TimeDuration(float64(point.offset) / compression),
+			Rate:   rate,
+		})
+	}
+
+	return &ArrivalRateScheduler{
+		Scheduler: sched.Scheduler,
+		Settings: ArrivalRateSchedSettings{
+			ExecutionTime: -1,
+			Distribution:  ArrivalConstant,
+			RateChanges:   changes,
+			MaxInFlight:   sched.Settings.MaxInFlight,
+		},
+	}
+}
+
+// readReplayFile read usage curve from CSV file with rows of offset from start of curve and value. Offset is either
+// a duration, e.g. "1h30m", or seconds. An initial header row is allowed.
+func readReplayFile(filename string) ([]replayPoint, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open usage curve file<%s>", filename)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	points, err := readReplayCurve(file)
+	return points, errors.Wrapf(err, "failed to read usage curve file<%s>", filename)
+}
+
+func readReplayCurve(r io.Reader) ([]replayPoint, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	var points []replayPoint
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		point, err := parseReplayPoint(record)
+		if err != nil {
+			if row == 1 {
+				// header
+				continue
+			}
+			return nil, errors.Wrapf(err, "row<%d>", row)
+		}
+
+		if point.value < 0 {
+			return nil, errors.Errorf("row<%d> has negative value<%f>", row, point.value)
+		}
+		if len(points) > 0 && point.offset <= points[len(points)-1].offset {
+			return nil, errors.Errorf("row<%d> offset<%v> not after previous offset", row, point.offset)
+		}
+		points = append(points, point)
+	}
+
+	if len(points) < 1 {
+		return nil, errors.New("no data points")
+	}
+	return points, nil
+}
+
+func parseReplayPoint(record []string) (replayPoint, error) {
+	offsetField := strings.TrimSpace(record[0])
+	offset, err := time.ParseDuration(offsetField)
+	if err != nil {
+		seconds, errSeconds := strconv.ParseFloat(offsetField, 64)
+		if errSeconds != nil {
+			return replayPoint{}, errors.Errorf("invalid offset<%s>", offsetField)
+		}
+		offset = time.Duration(seconds * float64(time.Second))
+	}
+	if offset < 0 {
+		return replayPoint{}, errors.Errorf("negative offset<%s>", offsetField)
+	}
+
+	valueField := strings.TrimSpace(record[1])
+	value, err := strconv.ParseFloat(valueField, 64)
+	if err != nil {
+		return replayPoint{}, errors.Errorf("invalid value<%s>", valueField)
+	}
+
+	return replayPoint{offset: offset, value: value}, nil
+}
+
+// RequireScenario report that scheduler requires a scenario
+func (sched *ReplayScheduler) RequireScenario() bool {
+	return true
+}
@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/helpers"
+)
+
+func TestReplayCurve(t *testing.T) {
+	points, err := readReplayCurve(strings.NewReader(`offset,users
+# morning
+0,0
+10m, 20
+1200, 35.6
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []replayPoint{{0, 0}, {10 * time.Minute, 20}, {20 * time.Minute, 35.6}}
+	if len(points) != len(expected) {
+		t.Fatalf("expected<%d> points, got<%d>", len(expected), len(points))
+	}
+	for i, point := range points {
+		if point != expected[i] {
+			t.Errorf("point<%d> expected<%+v> got<%+v>", i, expected[i], point)
+		}
+	}
+
+	if _, err := readReplayCurve(strings.NewReader("0,1\n10,x\n")); err == nil {
+		t.Error("expected error on invalid value")
+	}
+	if _, err := readReplayCurve(strings.NewReader("0,1\n0,2\n")); err == nil {
+		t.Error("expected error on offset not increasing")
+	}
+	if _, err := readReplayCurve(strings.NewReader("offset,users\n")); err == nil {
+		t.Error("expected error on no data points")
+	}
+}
+
+func TestReplaySched(t *testing.T) {
+	file, err := ioutil.TempFile("", "replay*.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+	if _, err := file.WriteString("1m,10\n3m,30\n4m,0\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sched := &ReplayScheduler{}
+	if err := errors.Cause(sched.Validate()); err == nil || err.Error() !=
+		"Invalid replay scheduler setting:  no file defined" {
+		t.Log(err)
+		t.Error("File validation failed")
+	}
+
+	sched.Settings.File = file.Name()
+	sched.Settings.TimeCompression = 2
+	if err := sched.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	sched.Settings.Mode = ReplaySessionStarts
+	if err := errors.Cause(sched.Validate()); err == nil || err.Error() !=
+		"Invalid replay scheduler setting:  MaxInFlight<0>" {
+		t.Log(err)
+		t.Error("MaxInFlight validation failed")
+	}
+
+	points, err := readReplayFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stages := sched.stagesScheduler(points).Settings.Stages
+	expectedStages := []Stage{
+		{Users: 0, Duration: helpers.TimeDuration(30 * time.Second)},
+		{Users: 10, Duration: 0},
+		{Users: 30, Duration: helpers.TimeDuration(time.Minute)},
+		{Users: 0, Duration: helpers.TimeDuration(30 * time.Second)},
+	}
+	if len(stages) != len(expectedStages) {
+		t.Fatalf("expected<%d> stages, got<%d>", len(expectedStages), len(stages))
+	}
+	for i, stage := range stages {
+		if stage != expectedStages[i] {
+			t.Errorf("stage<%d> expected<%+v> got<%+v>", i, expectedStages[i], stage)
+		}
+	}
+
+	changes := sched.arrivalRateScheduler(points).Settings.RateChanges
+	expectedChanges := []RateChange{
+		{Offset: helpers.TimeDuration(30 * time.Second), Rate: 10.0 / 60 * 2},
+		{Offset: helpers.TimeDuration(90 * time.Second), Rate: 30.0 / 60 * 2},
+		{Offset: helpers.TimeDuration(2 * time.Minute), Rate: 0},
+	}
+	if len(changes) != len(expectedChanges) {
+		t.Fatalf("expected<%d> rate changes, got<%d>", len(expectedChanges), len(changes))
+	}
+	for i, change := range changes {
+		if change != expectedChanges[i] {
+			t.Errorf("rate change<%d> expected<%+v> got<%+v>", i, expectedChanges[i], change)
+		}
+	}
+}
+
+func TestReplaySessionStartsEnd(t *testing.T) {
+	sched := &ReplayScheduler{}
+	sched.Settings.MaxInFlight = 10
+
+	// arrival at 300ms, next arrival at 600ms is after the last point
+	arrivalRate := sched.arrivalRateScheduler([]replayPoint{{0, 200}, {500 * time.Millisecond, 200}})
+	if sessions := countSessions(t, arrivalRate, time.Second); sessions != 1 {
+		t.Errorf("expected 1 session started before last point, got<%d>", sessions)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"reflect"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
@@ -11,6 +14,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/qlik-oss/gopherciser/buildmetrics"
 	"github.com/qlik-oss/gopherciser/connection"
+	"github.com/qlik-oss/gopherciser/control"
 	"github.com/qlik-oss/gopherciser/enummap"
 	"github.com/qlik-oss/gopherciser/globals"
 	"github.com/qlik-oss/gopherciser/helpers"
@@ -38,6 +42,27 @@ type (
 		RequireScenario() bool
 	}
 
+	// DistributableScheduler scheduler able to split its load into parts executed by separate instances
+	DistributableScheduler interface {
+		// Distribute split scheduler into parts, each part with a unique instance number
+		Distribute(parts int) ([]IScheduler, error)
+	}
+
+	// SummaryScheduler scheduler with results of execution to add to summary
+	SummaryScheduler interface {
+		// SummaryResults results of last execution, nil if not executed
+		SummaryResults() []SummaryResult
+	}
+
+	// SummaryResult result of scheduler execution added to summary
+	SummaryResult struct {
+		// Name short name used in simple summary
+		Name string
+		// Title used in extended and full summary
+		Title string
+		Value string
+	}
+
 	// Scheduler common core of schedulers
 	Scheduler struct {
 		// SchedType type of scheduler
@@ -48,6 +73,7 @@ type (
 		InstanceNumber uint64 `json:"instance" doc-key:"config.scheduler.instance"`
 
 		connectionSettings *connection.ConnectionSettings
+		personas           *personaSelector
 	}
 
 	schedulerTmp struct {
@@ -62,27 +88,153 @@ const (
 	SchedUnknown Type = iota
 	// SchedSimple simple scheduler
 	SchedSimple
+	// SchedStages staged load profile scheduler
+	SchedStages
+	// SchedArrivalRate open model arrival rate scheduler
+	SchedArrivalRate
+	// SchedReplay replay of usage curve read from file
+	SchedReplay
+	// SchedCapacity search for max concurrent users meeting SLA
+	SchedCapacity
+)
+
+// Schedulers needs an entry in schedulerHandler
+var (
+	schedulerHandler     map[Type]IScheduler
+	schedulerTypeEnumMap *enummap.EnumMap
+	shLock               sync.Mutex
 )
 
-var schedulerTypeEnumMap, _ = enummap.NewEnumMap(map[string]int{
-	"simple": int(SchedSimple),
-})
+func init() {
+	ResetDefaultSchedulers()
+}
+
+// ResetDefaultSchedulers reset scheduler list to default list. Used e.g. for tests overriding default schedulers
+func ResetDefaultSchedulers() {
+	shLock.Lock()
+	defer shLock.Unlock()
+
+	schedulerTypeEnumMap, _ = enummap.NewEnumMap(map[string]int{
+		"simple":      int(SchedSimple),
+		"stages":      int(SchedStages),
+		"arrivalrate": int(SchedArrivalRate),
+		"replay":      int(SchedReplay),
+		"capacity":    int(SchedCapacity),
+	})
+
+	schedulerHandler = map[Type]IScheduler{
+		SchedSimple:      &SimpleScheduler{},
+		SchedStages:      &StagesScheduler{},
+		SchedArrivalRate: &ArrivalRateScheduler{},
+		SchedReplay:      &ReplayScheduler{},
+		SchedCapacity:    &CapacityScheduler{},
+	}
+}
 
 func (value Type) GetEnumMap() *enummap.EnumMap {
 	return schedulerTypeEnumMap
 }
 
-// SchedHandler get scheduler instance of type
-// Todo change to logic allowing for external schedulers
-func SchedHandler(scheduler Type) interface{} {
-	switch scheduler {
-	case SchedUnknown:
+// RegisterSchedulers register custom schedulers.
+// This should be done as early as possible and must be done before unmarshaling config
+func RegisterSchedulers(customSchedulerMap map[string]IScheduler) error {
+	return errors.WithStack(registerSchedulers(false, customSchedulerMap))
+}
+
+// RegisterSchedulersOverride register custom schedulers and override any existing with same name
+// This should be done as early as possible and must be done before unmarshaling config
+func RegisterSchedulersOverride(customSchedulerMap map[string]IScheduler) error {
+	return errors.WithStack(registerSchedulers(true, customSchedulerMap))
+}
+
+// RegisterScheduler register a custom scheduler, fails if a scheduler with same name is already registered.
+// This should be done as early as possible and must be done before unmarshaling config
+func RegisterScheduler(name string, scheduler IScheduler) error {
+	return errors.WithStack(registerScheduler(false, name, scheduler))
+}
+
+// RegisterSchedulerOverride register a custom scheduler and override any existing with same name
+// This should be done as early as possible and must be done before unmarshaling config
+func RegisterSchedulerOverride(name string, scheduler IScheduler) error {
+	return errors.WithStack(registerScheduler(true, name, scheduler))
+}
+
+func registerSchedulers(override bool, customSchedulerMap map[string]IScheduler) error {
+	for name, scheduler := range customSchedulerMap {
+		if err := registerScheduler(override, name, scheduler); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+func registerScheduler(override bool, name string, scheduler IScheduler) error {
+	if scheduler == nil {
+		return errors.Errorf("scheduler<%s> is nil", name)
+	}
+
+	shLock.Lock()
+	defer shLock.Unlock()
+
+	name = strings.ToLower(name)
+	if typ, err := schedulerTypeEnumMap.Int(name); err == nil {
+		if !override {
+			return errors.Errorf("scheduler<%s> already registered as type<%T>", name, schedulerHandler[Type(typ)])
+		}
+		schedulerHandler[Type(typ)] = scheduler
 		return nil
-	case SchedSimple:
-		return &SimpleScheduler{}
-	default:
+	}
+
+	// new scheduler name, assign next free type
+	next := int(SchedUnknown)
+	schedulerTypeEnumMap.ForEach(func(k int, v string) {
+		if k > next {
+			next = k
+		}
+	})
+	next++
+
+	if err := schedulerTypeEnumMap.Add(name, next); err != nil {
+		return errors.Wrapf(err, "failed to register scheduler<%s>", name)
+	}
+	schedulerHandler[Type(next)] = scheduler
+	return nil
+}
+
+// RegisteredSchedulers returns a list of all registered schedulers
+func RegisteredSchedulers() []string {
+	shLock.Lock()
+	defer shLock.Unlock()
+
+	return schedulerTypeEnumMap.Keys()
+}
+
+// NewScheduler new instance of scheduler registered with name, returns nil if no such scheduler is registered
+func NewScheduler(name string) IScheduler {
+	typ, err := Type(SchedUnknown).GetEnumMap().Int(strings.ToLower(name))
+	if err != nil {
 		return nil
 	}
+	scheduler, _ := SchedHandler(Type(typ)).(IScheduler)
+	return scheduler
+}
+
+// SchedHandler get new scheduler instance of type
+func SchedHandler(scheduler Type) interface{} {
+	shLock.Lock()
+	registered := schedulerHandler[scheduler]
+	shLock.Unlock()
+
+	if registered == nil {
+		return nil
+	}
+
+	typ := reflect.TypeOf(registered)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return reflect.New(typ).Interface()
 }
 
 // UnmarshalJSON unmarshal scheduler type from json
@@ -155,10 +307,53 @@ func (sched *Scheduler) Validate() error {
 	return sched.TimeBuf.Validate()
 }
 
-func (sched *Scheduler) startNewUser(ctx context.Context, timeout time.Duration, log *logger.Log,
-	userScenario []scenario.Action, thread uint64, outputsDir string, user *users.User,
+// InstanceNumber of scheduler, defaults to 1 when not set or when scheduler does not embed Scheduler
+func InstanceNumber(sched IScheduler) uint64 {
+	if withInstance, ok := sched.(interface{ instance() uint64 }); ok {
+		return withInstance.instance()
+	}
+	return 1
+}
+
+func (sched *Scheduler) instance() uint64 {
+	if sched.InstanceNumber < 1 {
+		return 1
+	}
+	return sched.InstanceNumber
+}
+
+// distributedInstance unique instance number for part of scheduler split into parts
+func (sched *Scheduler) distributedInstance(part, parts int) uint64 {
+	return (sched.instance()-1)*uint64(parts) + uint64(part) + 1
+}
+
+// splitCount share of total for part when splitting total as evenly as possible into parts
+func splitCount(total, part, parts int) int {
+	count := total / parts
+	if part < total%parts {
+		count++
+	}
+	return count
+}
+
+// StartNewUser start a new session for user and execute scenario for a number of iterations, -1 iterates until
+// context is done. Custom schedulers should use NextUser to get scenario, user and persona.
+func (sched *Scheduler) StartNewUser(ctx context.Context, timeout time.Duration, log *logger.Log,
+	userScenario []scenario.Action, thread uint64, outputsDir string, user *users.User, persona string,
+	connectionSettings *connection.ConnectionSettings, iterations int) error {
+	return sched.startNewUser(ctx, notStopped, timeout, log, userScenario, thread, outputsDir, user, persona,
+		connectionSettings, iterations)
+}
+
+// startNewUser start a new session for user and execute scenario for a number of iterations or until stopped reports
+// true
+func (sched *Scheduler) startNewUser(ctx context.Context, stopped func() bool, timeout time.Duration, log *logger.Log,
+	userScenario []scenario.Action, thread uint64, outputsDir string, user *users.User, persona string,
 	connectionSettings *connection.ConnectionSettings, iterations int) error {
 
+	// return leased user when session ends
+	defer user.Release()
+
 	sessionID := globals.Sessions.Inc()
 	instanceID := sched.InstanceNumber
 	if instanceID < 1 {
@@ -167,7 +362,19 @@ func (sched *Scheduler) startNewUser(ctx context.Context, timeout time.Duration,
 	var iteration int
 	var mErr *multierror.Error
 
+	// server node of session when connection settings define multiple servers
+	connectionSettings, err := connectionSettings.SessionSettings(user, instanceID, sessionID)
+	if err != nil {
+		return errors.Wrap(err, "failed to select server node")
+	}
+
 	sessionState := session.New(ctx, outputsDir, timeout, user, sessionID, instanceID, connectionSettings.VirtualProxy)
+	sessionState.Persona = persona
+	sessionState.Node = connectionSettings.Node()
+	sessionState.Network = connectionSettings.Network
+	if network := sched.personaNetwork(persona); network != nil {
+		sessionState.Network = network
+	}
 
 	userName := ""
 	if user != nil {
@@ -180,8 +387,15 @@ func (sched *Scheduler) startNewUser(ctx context.Context, timeout time.Duration,
 	buildmetrics.AddUser()
 	defer buildmetrics.RemoveUser()
 
+	// sessions run concurrently on the same scheduler, each session needs its own time buffer
+	timeBuf := sched.TimeBuf
+
 	for {
-		sched.TimeBuf.SetDurationStart(time.Now())
+		if !nextIteration(ctx, stopped) {
+			break
+		}
+
+		timeBuf.SetDurationStart(time.Now())
 
 		if helpers.IsContextTriggered(ctx) {
 			break
@@ -194,12 +408,12 @@ func (sched *Scheduler) startNewUser(ctx context.Context, timeout time.Duration,
 
 		setLogEntry(sessionState, log, sessionID, thread, userName)
 
-		err := sched.runIteration(userScenario, sessionState, connectionSettings, mErr, ctx)
+		err := sched.runIteration(userScenario, sessionState, connectionSettings, &timeBuf, mErr, ctx)
 		if err != nil {
 			mErr = multierror.Append(mErr, err)
 		}
 
-		if err := sched.TimeBuf.Wait(ctx, false); err != nil {
+		if err := timeBuf.Wait(ctx, false); err != nil {
 			logEntry := log.NewLogEntry()
 			logEntry.Session = sessionState.LogEntry.Session
 			logEntry.LogError(errors.Wrap(err, "time buffer in-between sequences failed"))
@@ -209,7 +423,68 @@ func (sched *Scheduler) startNewUser(ctx context.Context, timeout time.Duration,
 	return helpers.FlattenMultiError(mErr)
 }
 
-func (sched *Scheduler) runIteration(userScenario []scenario.Action, sessionState *session.State, connectionSettings *connection.ConnectionSettings, mErr *multierror.Error, ctx context.Context) error {
+// iterateNewUsers start a new user and session for each iteration, until context is done, stopped reports true
+// or iterations are done. iterations -1 means iterate until stopped.
+func (sched *Scheduler) iterateNewUsers(ctx context.Context, stopped func() bool, timeout time.Duration, log *logger.Log,
+	userScenario []scenario.Action, outputsDir string, users users.UserGenerator, iterations int) error {
+
+	thread := globals.Threads.Inc()
+
+	var (
+		mErr      *multierror.Error
+		iteration int
+	)
+
+	for {
+		if !nextIteration(ctx, stopped) {
+			break
+		}
+
+		iteration++
+		if iterations > 0 && iteration > iterations {
+			break
+		}
+
+		personaScenario, user, persona, err := sched.NextUser(ctx, userScenario, users)
+		if err != nil {
+			if userUnavailable(ctx, log, err) {
+				continue
+			}
+			return errors.WithStack(err)
+		}
+		if err := sched.StartNewUser(ctx, timeout, log, personaScenario, thread, outputsDir, user, persona, sched.connectionSettings, 1); err != nil {
+			mErr = multierror.Append(mErr, err)
+		}
+	}
+
+	return errors.WithStack(helpers.FlattenMultiError(mErr))
+}
+
+// nextIteration wait while execution is paused through the control API, returns false when no new iteration should
+// be started
+func nextIteration(ctx context.Context, stopped func() bool) bool {
+	control.WaitWhilePaused(ctx)
+	return !helpers.IsContextTriggered(ctx) && !control.Stopping() && !stopped()
+}
+
+func notStopped() bool {
+	return false
+}
+
+// userUnavailable no user was leased since context is done or all users stayed leased during lease timeout, which is
+// logged as a warning. The user should be skipped rather than the execution failed.
+func userUnavailable(ctx context.Context, log *logger.Log, err error) bool {
+	if helpers.IsContextTriggered(ctx) {
+		return true
+	}
+	if errors.Cause(err) != users.ErrLeaseTimeout {
+		return false
+	}
+	log.NewLogEntry().Logf(logger.WarningLevel, "user skipped: %v", err)
+	return true
+}
+
+func (sched *Scheduler) runIteration(userScenario []scenario.Action, sessionState *session.State, connectionSettings *connection.ConnectionSettings, timeBuf *TimeBuffer, mErr *multierror.Error, ctx context.Context) error {
 	defer sessionState.Reset(ctx)
 	defer sessionState.Disconnect() // make sure to disconnect connections at end of iteration
 	defer logErrReport(sessionState)
@@ -219,7 +494,7 @@ func (sched *Scheduler) runIteration(userScenario []scenario.Action, sessionStat
 			if isAborted, _ := scenario.CheckActionError(err); isAborted {
 				return nil
 			} else {
-				if err := sched.TimeBuf.Wait(ctx, true); err != nil {
+				if err := timeBuf.Wait(ctx, true); err != nil {
 					logEntry := sessionState.LogEntry.ShallowCopy()
 					logEntry.Action = nil
 					logEntry.LogError(errors.Wrap(err, "time buffer in-between sequences failed"))
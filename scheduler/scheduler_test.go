@@ -0,0 +1,130 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/qlik-oss/gopherciser/connection"
+	"github.com/qlik-oss/gopherciser/globals"
+	"github.com/qlik-oss/gopherciser/helpers"
+	"github.com/qlik-oss/gopherciser/logger"
+	"github.com/qlik-oss/gopherciser/scenario"
+	"github.com/qlik-oss/gopherciser/users"
+)
+
+type (
+	customSchedSettings struct {
+		Foo string `json:"foo"`
+	}
+
+	customScheduler struct {
+		Scheduler
+		Settings customSchedSettings `json:"settings"`
+	}
+)
+
+func (sched customScheduler) Validate() error {
+	return sched.Scheduler.Validate()
+}
+
+func (sched customScheduler) Execute(context.Context, *logger.Log, time.Duration, []scenario.Action, string,
+	users.UserGenerator, *connection.ConnectionSettings) error {
+	return nil
+}
+
+func (sched *customScheduler) RequireScenario() bool {
+	return false
+}
+
+func TestRegisterScheduler(t *testing.T) {
+	defer ResetDefaultSchedulers()
+
+	if err := RegisterScheduler("CustomSched", &customScheduler{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RegisterScheduler("customsched", &customScheduler{}); err == nil {
+		t.Error("expected error registering scheduler twice")
+	}
+
+	if err := RegisterScheduler("simple", &customScheduler{}); err == nil {
+		t.Error("expected error registering scheduler with default name")
+	}
+
+	raw := []byte(`{"type": "customsched", "settings": {"foo": "bar"}}`)
+	sched, err := UnmarshalScheduler(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom, ok := sched.(*customScheduler)
+	if !ok {
+		t.Fatalf("unexpected scheduler type<%T>", sched)
+	}
+	if custom.Settings.Foo != "bar" {
+		t.Errorf("expected foo<bar>, got<%s>", custom.Settings.Foo)
+	}
+
+	// new instance for each unmarshal
+	sched2, err := UnmarshalScheduler([]byte(`{"type": "customsched"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sched2 == sched {
+		t.Error("expected new scheduler instance")
+	}
+
+	if err := RegisterSchedulerOverride("simple", &customScheduler{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := NewScheduler("simple").(*customScheduler); !ok {
+		t.Error("expected simple scheduler to be overridden")
+	}
+
+	ResetDefaultSchedulers()
+	if _, ok := NewScheduler("simple").(*SimpleScheduler); !ok {
+		t.Error("expected default simple scheduler after reset")
+	}
+	if NewScheduler("customsched") != nil {
+		t.Error("expected custom scheduler to be removed after reset")
+	}
+}
+
+// executeSessions execute sched with a short think time scenario and a min duration time buffer, used to verify that
+// concurrent sessions of a scheduler don't share state (run with -race)
+func executeSessions(t *testing.T, sched IScheduler, timeBuf *TimeBuffer, duration time.Duration) {
+	t.Helper()
+
+	*timeBuf = TimeBuffer{Mode: TimeBufMinDur, Duration: helpers.TimeDuration(20 * time.Millisecond)}
+	if sessions := countSessions(t, sched, duration); sessions < 2 {
+		t.Errorf("expected concurrent sessions, got<%d> sessions", sessions)
+	}
+}
+
+// countSessions execute sched with a short think time scenario during max duration and return amount of started sessions
+func countSessions(t *testing.T, sched IScheduler, duration time.Duration) uint64 {
+	t.Helper()
+
+	var thinkTime scenario.Action
+	if err := jsonit.Unmarshal([]byte(`{"action":"thinktime","settings":{"type":"static","delay":0.01}}`), &thinkTime); err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.NewLog(logger.LogSettings{})
+	log.AddLoggers(logger.CreateDummyLogger())
+	log.StartLogger(context.Background())
+	defer func() {
+		_ = log.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	before := globals.Sessions.Current()
+	connectionSettings := &connection.ConnectionSettings{Mode: connection.WS, Server: "localhost"}
+	if err := sched.Execute(ctx, log, time.Second, []scenario.Action{thinkTime}, "", users.NewUserGeneratorPrefix("gopher"),
+		connectionSettings); err != nil {
+		t.Fatal(err)
+	}
+	return globals.Sessions.Current() - before
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 	"github.com/qlik-oss/gopherciser/connection"
+	"github.com/qlik-oss/gopherciser/control"
 	"github.com/qlik-oss/gopherciser/globals"
 	"github.com/qlik-oss/gopherciser/helpers"
 	"github.com/qlik-oss/gopherciser/logger"
@@ -30,6 +31,13 @@ type (
 		Scheduler
 		Settings SimpleSchedSettings `json:"settings" doc-key:"config.scheduler.settings"`
 	}
+
+	// usersTarget target of concurrent users, -1 for unlimited, changeable through control API while executing
+	usersTarget struct {
+		mu      sync.Mutex
+		users   int
+		changed chan struct{}
+	}
 )
 
 // Validate schedule
@@ -67,57 +75,76 @@ func (sched SimpleScheduler) Execute(ctx context.Context, log *logger.Log, timeo
 		defer cancel()
 	}
 
-	var (
-		wg           sync.WaitGroup
-		localThreads int
+	threads := newUserThreads(ctx, func(ctx context.Context, stopped func() bool) error {
+		if sched.Settings.ReuseUsers {
+			return sched.iteratorReuseUsers(ctx, stopped, timeout, log, scenario, outputsDir, users)
+		}
+		return sched.iteratorNewUsers(ctx, stopped, timeout, log, scenario, outputsDir, users)
+	})
 
-		mErr     *multierror.Error
-		mErrLock sync.Mutex
-	)
+	target := newUsersTarget(sched.Settings.ConcurrentUsers)
+	defer control.SetUsersController(target)()
+
+	sched.rampUsers(ctx, threads, target)
+
+	return errors.WithStack(threads.Wait())
+}
+
+// rampUsers start users with rampup delay in between until target of concurrent users is reached, and keep following
+// changes of target until all users are done, execution is stopped or ctx is done. Users above target are stopped
+// after finishing their ongoing iteration.
+func (sched *SimpleScheduler) rampUsers(ctx context.Context, threads *userThreads, target *usersTarget) {
+	ctx, cancel := control.WithStop(ctx)
+	defer cancel()
+
+	rampupDelay := time.Duration(sched.Settings.RampupDelay * float64(time.Second))
+	var lastStart time.Time
 
 	for {
 		if helpers.IsContextTriggered(ctx) {
-			break
-		}
-
-		localThreads++
-		if sched.Settings.ConcurrentUsers > 0 && localThreads > sched.Settings.ConcurrentUsers {
-			break
+			return
 		}
 
-		if localThreads != 1 {
-			helpers.WaitFor(ctx, time.Duration(sched.Settings.RampupDelay*float64(time.Second)))
+		users := target.ConcurrentUsers()
+		// users done with their iterations are not replaced
+		count := threads.Count() + threads.Finished()
+		if users >= 0 && count >= users {
+			if count > users {
+				threads.Remove(count - users)
+			}
+			if users > 0 && threads.Running() < 1 {
+				// all users done with their iterations
+				return
+			}
+			select {
+			case <-target.changed:
+			case <-threads.Exited():
+			case <-ctx.Done():
+			}
+			continue
 		}
 
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-
-			var err error
-			if sched.Settings.ReuseUsers {
-				err = sched.iteratorReuseUsers(ctx, timeout, log, scenario, outputsDir, users)
-			} else {
-				err = sched.iteratorNewUsers(ctx, timeout, log, scenario, outputsDir, users)
+		if !lastStart.IsZero() {
+			delay := time.NewTimer(time.Until(lastStart.Add(rampupDelay)))
+			select {
+			case <-delay.C:
+			case <-target.changed:
+				// re-evaluate target before starting next user
+				delay.Stop()
+				continue
+			case <-ctx.Done():
+				delay.Stop()
+				return
 			}
+		}
 
-			if err != nil {
-				func() { // wrapped in function to minimize locking time
-					mErrLock.Lock()
-					defer mErrLock.Unlock()
-					mErr = multierror.Append(mErr, err)
-
-				}()
-			}
-		}()
+		threads.Add(1)
+		lastStart = time.Now()
 	}
-
-	wg.Wait()
-
-	return errors.WithStack(helpers.FlattenMultiError(mErr))
 }
 
-func (sched SimpleScheduler) iteratorNewUsers(ctx context.Context, timeout time.Duration, log *logger.Log,
-	scenario []scenario.Action, outputsDir string, users users.UserGenerator) (err error) {
+func (sched SimpleScheduler) iteratorNewUsers(ctx context.Context, stopped func() bool, timeout time.Duration,
+	log *logger.Log, scenario []scenario.Action, outputsDir string, users users.UserGenerator) (err error) {
 
 	thread := globals.Threads.Inc()
 
@@ -127,7 +154,7 @@ func (sched SimpleScheduler) iteratorNewUsers(ctx context.Context, timeout time.
 	)
 
 	for {
-		if helpers.IsContextTriggered(ctx) {
+		if !nextIteration(ctx, stopped) {
 			break
 		}
 
@@ -136,8 +163,14 @@ func (sched SimpleScheduler) iteratorNewUsers(ctx context.Context, timeout time.
 			break
 		}
 
-		user := users.GetNext()
-		err = sched.startNewUser(ctx, timeout, log, scenario, thread, outputsDir, user, sched.connectionSettings, 1)
+		userScenario, user, persona, err := sched.NextUser(ctx, scenario, users)
+		if err != nil {
+			if userUnavailable(ctx, log, err) {
+				continue
+			}
+			return errors.WithStack(err)
+		}
+		err = sched.StartNewUser(ctx, timeout, log, userScenario, thread, outputsDir, user, persona, sched.connectionSettings, 1)
 		if err != nil {
 			mErr = multierror.Append(mErr, err)
 		}
@@ -146,20 +179,73 @@ func (sched SimpleScheduler) iteratorNewUsers(ctx context.Context, timeout time.
 	return errors.WithStack(helpers.FlattenMultiError(mErr))
 }
 
-func (sched SimpleScheduler) iteratorReuseUsers(ctx context.Context, timeout time.Duration, log *logger.Log,
-	scenario []scenario.Action, outputsDir string, users users.UserGenerator) (err error) {
+func (sched SimpleScheduler) iteratorReuseUsers(ctx context.Context, stopped func() bool, timeout time.Duration,
+	log *logger.Log, scenario []scenario.Action, outputsDir string, users users.UserGenerator) (err error) {
 
 	thread := globals.Threads.Inc()
 
-	var mErr *multierror.Error
+	for nextIteration(ctx, stopped) {
+		userScenario, user, persona, err := sched.NextUser(ctx, scenario, users)
+		if err != nil {
+			if userUnavailable(ctx, log, err) {
+				continue
+			}
+			return errors.WithStack(err)
+		}
+		return errors.WithStack(sched.startNewUser(ctx, stopped, timeout, log, userScenario, thread, outputsDir, user,
+			persona, sched.connectionSettings, sched.Settings.Iterations))
+	}
+	return nil
+}
 
-	user := users.GetNext()
-	err = sched.startNewUser(ctx, timeout, log, scenario, thread, outputsDir, user, sched.connectionSettings, sched.Settings.Iterations)
-	if err != nil {
-		mErr = multierror.Append(mErr, err)
+func newUsersTarget(users int) *usersTarget {
+	return &usersTarget{
+		users:   users,
+		changed: make(chan struct{}, 1),
 	}
+}
 
-	return errors.WithStack(helpers.FlattenMultiError(mErr))
+// ConcurrentUsers current target of concurrent users
+func (target *usersTarget) ConcurrentUsers() int {
+	target.mu.Lock()
+	defer target.mu.Unlock()
+	return target.users
+}
+
+// SetConcurrentUsers change target of concurrent users
+func (target *usersTarget) SetConcurrentUsers(users int) {
+	target.mu.Lock()
+	target.users = users
+	target.mu.Unlock()
+
+	select {
+	case target.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Distribute split concurrent users into parts. Rampup delay is multiplied with parts to keep the total rampup rate.
+func (sched SimpleScheduler) Distribute(parts int) ([]IScheduler, error) {
+	if parts < 1 {
+		return nil, errors.Errorf("can't distribute scheduler into <%d> parts", parts)
+	}
+	if sched.Settings.ConcurrentUsers > 0 && sched.Settings.ConcurrentUsers < parts {
+		return nil, errors.Errorf("can't distribute ConcurrentUsers<%d> into <%d> parts", sched.Settings.ConcurrentUsers, parts)
+	}
+
+	schedulers := make([]IScheduler, 0, parts)
+	for i := 0; i < parts; i++ {
+		part := sched
+		part.connectionSettings = nil
+		part.personas = nil
+		part.InstanceNumber = sched.distributedInstance(i, parts)
+		if sched.Settings.ConcurrentUsers > 0 {
+			part.Settings.ConcurrentUsers = splitCount(sched.Settings.ConcurrentUsers, i, parts)
+		}
+		part.Settings.RampupDelay = sched.Settings.RampupDelay * float64(parts)
+		schedulers = append(schedulers, &part)
+	}
+	return schedulers, nil
 }
 
 // RequireScenario report that scheduler requires a scenario
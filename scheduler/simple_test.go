@@ -1,9 +1,12 @@
 package scheduler
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/control"
 )
 
 func TestSimpleSched(t *testing.T) {
@@ -46,3 +49,123 @@ func TestSimpleSched(t *testing.T) {
 		t.Error("validation failed")
 	}
 }
+
+func TestSimpleDistribute(t *testing.T) {
+	sched := &SimpleScheduler{}
+	sched.InstanceNumber = 2
+	sched.Settings.ConcurrentUsers = 5
+	sched.Settings.RampupDelay = 1.0
+
+	if _, err := sched.Distribute(6); err == nil {
+		t.Error("expected error distributing 5 users into 6 parts")
+	}
+
+	parts, err := sched.Distribute(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 parts, got<%d>", len(parts))
+	}
+
+	expected := []struct {
+		users    int
+		instance uint64
+	}{{3, 3}, {2, 4}}
+	for i, part := range parts {
+		simple, ok := part.(*SimpleScheduler)
+		if !ok {
+			t.Fatalf("unexpected type<%T>", part)
+		}
+		if simple.Settings.ConcurrentUsers != expected[i].users {
+			t.Errorf("part<%d> expected users<%d> got<%d>", i, expected[i].users, simple.Settings.ConcurrentUsers)
+		}
+		if simple.InstanceNumber != expected[i].instance {
+			t.Errorf("part<%d> expected instance<%d> got<%d>", i, expected[i].instance, simple.InstanceNumber)
+		}
+		if simple.Settings.RampupDelay != 2.0 {
+			t.Errorf("part<%d> expected rampup delay<2.0> got<%f>", i, simple.Settings.RampupDelay)
+		}
+	}
+
+	if sched.Settings.ConcurrentUsers != 5 {
+		t.Error("distribute modified original scheduler")
+	}
+}
+
+func TestSimpleRampUsers(t *testing.T) {
+	defer control.Reset()
+
+	sched := &SimpleScheduler{}
+	sched.Settings.RampupDelay = 0.001
+
+	threads := newUserThreads(context.Background(), func(ctx context.Context, stopped func() bool) error {
+		for nextIteration(ctx, stopped) {
+			time.Sleep(time.Millisecond)
+		}
+		return nil
+	})
+	target := newUsersTarget(3)
+	defer control.SetUsersController(target)()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sched.rampUsers(context.Background(), threads, target)
+	}()
+
+	waitForCount := func(expected int) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for threads.Count() != expected {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected<%d> threads, got<%d>", expected, threads.Count())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	waitForCount(3)
+	if err := control.SetConcurrentUsers(5); err != nil {
+		t.Fatal(err)
+	}
+	waitForCount(5)
+	if err := control.SetConcurrentUsers(1); err != nil {
+		t.Fatal(err)
+	}
+	waitForCount(1)
+
+	control.Stop()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ramp up not stopped")
+	}
+	if err := threads.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSimpleRampUsersDone(t *testing.T) {
+	sched := &SimpleScheduler{}
+	sched.Settings.RampupDelay = 0.001
+
+	threads := newUserThreads(context.Background(), func(ctx context.Context, stopped func() bool) error {
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sched.rampUsers(context.Background(), threads, newUsersTarget(2))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ramp up not done when all users are done")
+	}
+	if count, finished := threads.Count(), threads.Finished(); count != 0 || finished != 2 {
+		t.Errorf("expected 2 finished users, got<%d> finished and <%d> not finished", finished, count)
+	}
+}
@@ -0,0 +1,164 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/connection"
+	"github.com/qlik-oss/gopherciser/control"
+	"github.com/qlik-oss/gopherciser/helpers"
+	"github.com/qlik-oss/gopherciser/logger"
+	"github.com/qlik-oss/gopherciser/scenario"
+	"github.com/qlik-oss/gopherciser/users"
+)
+
+type (
+	// Stage target amount of concurrent users to reach at end of stage
+	Stage struct {
+		Users    int                  `json:"users" displayname:"Target users" doc-key:"config.scheduler.settings.stages.users"`
+		Duration helpers.TimeDuration `json:"duration" displayname:"Duration" doc-key:"config.scheduler.settings.stages.duration"`
+	}
+
+	// StagesSchedSettings stages scheduler settings
+	StagesSchedSettings struct {
+		Stages []Stage `json:"stages" displayname:"Stages" doc-key:"config.scheduler.settings.stages"`
+	}
+
+	// StagesScheduler ramps concurrent users up and down linearly according to a list of stages
+	StagesScheduler struct {
+		Scheduler
+		Settings StagesSchedSettings `json:"settings" doc-key:"config.scheduler.settings"`
+	}
+)
+
+// Validate schedule
+func (sched StagesScheduler) Validate() error {
+	// validate inherited settings
+	if err := sched.Scheduler.Validate(); err != nil {
+		return err
+	}
+
+	errorMsg := "Invalid stages scheduler setting: "
+	if len(sched.Settings.Stages) < 1 {
+		return errors.Errorf("%s no stages defined", errorMsg)
+	}
+
+	maxUsers := 0
+	for i, stage := range sched.Settings.Stages {
+		if stage.Users < 0 {
+			return errors.Errorf("%s stage<%d> Users<%d>", errorMsg, i, stage.Users)
+		}
+		if stage.Duration < 0 {
+			return errors.Errorf("%s stage<%d> Duration<%v>", errorMsg, i, time.Duration(stage.Duration))
+		}
+		if stage.Users > maxUsers {
+			maxUsers = stage.Users
+		}
+	}
+	if maxUsers < 1 {
+		return errors.Errorf("%s no stage with Users > 0", errorMsg)
+	}
+
+	return nil
+}
+
+// Execute execute schedule
+func (sched StagesScheduler) Execute(ctx context.Context, log *logger.Log, timeout time.Duration,
+	scenario []scenario.Action, outputsDir string, users users.UserGenerator, connectionSettings *connection.ConnectionSettings) error {
+
+	sched.connectionSettings = connectionSettings
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	threads := newUserThreads(ctx, func(ctx context.Context, stopped func() bool) error {
+		return sched.iterateNewUsers(ctx, stopped, timeout, log, scenario, outputsDir, users, -1)
+	})
+
+	// stopping execution through control API ends current stage, users finish their ongoing iteration
+	stageCtx, cancelStages := control.WithStop(ctx)
+	defer cancelStages()
+
+	for _, stage := range sched.Settings.Stages {
+		if helpers.IsContextTriggered(stageCtx) {
+			break
+		}
+		runStage(stageCtx, threads, stage)
+	}
+
+	// Users removed during a ramp down finish their ongoing iteration, users still active when last stage is done
+	// are disconnected the same way as when reaching execution time of the simple scheduler.
+	if threads.Count() > 0 && !control.Stopping() {
+		cancel()
+	}
+
+	return errors.WithStack(threads.Wait())
+}
+
+// runStage change the amount of threads linearly from current count to stage target during stage duration
+func runStage(ctx context.Context, threads *userThreads, stage Stage) {
+	start := time.Now()
+	duration := time.Duration(stage.Duration)
+
+	diff := stage.Users - threads.Count()
+	steps := diff
+	if steps < 0 {
+		steps = -steps
+	}
+
+	if steps > 0 {
+		interval := duration / time.Duration(steps)
+		for i := 0; i < steps; i++ {
+			helpers.WaitFor(ctx, time.Until(start.Add(time.Duration(i)*interval)))
+			if helpers.IsContextTriggered(ctx) {
+				return
+			}
+			if diff > 0 {
+				threads.Add(1)
+			} else {
+				threads.Remove(1)
+			}
+		}
+	}
+
+	helpers.WaitFor(ctx, time.Until(start.Add(duration)))
+}
+
+// Distribute split the target users of each stage into parts
+func (sched StagesScheduler) Distribute(parts int) ([]IScheduler, error) {
+	if parts < 1 {
+		return nil, errors.Errorf("can't distribute scheduler into <%d> parts", parts)
+	}
+	maxUsers := 0
+	for _, stage := range sched.Settings.Stages {
+		if stage.Users > maxUsers {
+			maxUsers = stage.Users
+		}
+	}
+	if maxUsers < parts {
+		return nil, errors.Errorf("can't distribute max stage Users<%d> into <%d> parts", maxUsers, parts)
+	}
+
+	schedulers := make([]IScheduler, 0, parts)
+	for i := 0; i < parts; i++ {
+		part := sched
+		part.connectionSettings = nil
+		part.personas = nil
+		part.InstanceNumber = sched.distributedInstance(i, parts)
+		part.Settings.Stages = make([]Stage, 0, len(sched.Settings.Stages))
+		for _, stage := range sched.Settings.Stages {
+			part.Settings.Stages = append(part.Settings.Stages, Stage{
+				Users:    splitCount(stage.Users, i, parts),
+				Duration: stage.Duration,
+			})
+		}
+		schedulers = append(schedulers, &part)
+	}
+	return schedulers, nil
+}
+
+// RequireScenario report that scheduler requires a scenario
+func (sched *StagesScheduler) RequireScenario() bool {
+	return true
+}
@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/helpers"
+)
+
+func TestStagesSched(t *testing.T) {
+	sched := &StagesScheduler{}
+
+	if err := errors.Cause(sched.Validate()); err == nil || err.Error() !=
+		"Invalid stages scheduler setting:  no stages defined" {
+		t.Log(err)
+		t.Error("Stages validation failed")
+	}
+
+	sched.Settings.Stages = []Stage{{Users: -1, Duration: helpers.TimeDuration(time.Second)}}
+	if err := errors.Cause(sched.Validate()); err == nil || err.Error() !=
+		"Invalid stages scheduler setting:  stage<0> Users<-1>" {
+		t.Log(err)
+		t.Error("Users validation failed")
+	}
+
+	sched.Settings.Stages = []Stage{{Users: 0, Duration: helpers.TimeDuration(time.Second)}}
+	if err := errors.Cause(sched.Validate()); err == nil || err.Error() !=
+		"Invalid stages scheduler setting:  no stage with Users > 0" {
+		t.Log(err)
+		t.Error("max users validation failed")
+	}
+
+	sched.Settings.Stages = []Stage{
+		{Users: 10, Duration: helpers.TimeDuration(time.Minute)},
+		{Users: 0, Duration: helpers.TimeDuration(time.Minute)},
+	}
+	if err := errors.Cause(sched.Validate()); err != nil {
+		t.Log(err)
+		t.Error("validation failed")
+	}
+}
+
+func TestStagesRunStage(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		running int
+	)
+
+	threads := newUserThreads(context.Background(), func(ctx context.Context, stopped func() bool) error {
+		mu.Lock()
+		running++
+		mu.Unlock()
+		for !stopped() {
+			time.Sleep(time.Millisecond)
+		}
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+
+	runStage(context.Background(), threads, Stage{Users: 5, Duration: helpers.TimeDuration(50 * time.Millisecond)})
+	if count := threads.Count(); count != 5 {
+		t.Errorf("expected 5 threads after ramp up, got<%d>", count)
+	}
+
+	runStage(context.Background(), threads, Stage{Users: 2, Duration: helpers.TimeDuration(30 * time.Millisecond)})
+	if count := threads.Count(); count != 2 {
+		t.Errorf("expected 2 threads after ramp down, got<%d>", count)
+	}
+
+	runStage(context.Background(), threads, Stage{Users: 0})
+	if err := threads.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if running != 0 {
+		t.Errorf("expected all threads to exit, running<%d>", running)
+	}
+}
+
+func TestStagesDistribute(t *testing.T) {
+	sched := &StagesScheduler{}
+	sched.Settings.Stages = []Stage{
+		{Users: 10, Duration: helpers.TimeDuration(time.Minute)},
+		{Users: 3, Duration: helpers.TimeDuration(time.Minute)},
+	}
+
+	parts, err := sched.Distribute(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]int{{3, 1}, {3, 1}, {2, 1}, {2, 0}}
+	for i, part := range parts {
+		stages := part.(*StagesScheduler)
+		if stages.InstanceNumber != uint64(i+1) {
+			t.Errorf("part<%d> expected instance<%d> got<%d>", i, i+1, stages.InstanceNumber)
+		}
+		for j, stage := range stages.Settings.Stages {
+			if stage.Users != expected[i][j] {
+				t.Errorf("part<%d> stage<%d> expected users<%d> got<%d>", i, j, expected[i][j], stage.Users)
+			}
+		}
+	}
+
+	if sched.Settings.Stages[0].Users != 10 {
+		t.Error("distribute modified original stages")
+	}
+}
+
+func TestStagesSessions(t *testing.T) {
+	sched := &StagesScheduler{}
+	sched.Settings.Stages = []Stage{{Users: 3, Duration: helpers.TimeDuration(100 * time.Millisecond)}}
+	executeSessions(t, sched, &sched.TimeBuf, time.Second)
+}
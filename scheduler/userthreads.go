@@ -0,0 +1,148 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/helpers"
+)
+
+type (
+	// userThreads dynamic set of user threads. Threads are stopped in reverse order of start, a stopped thread
+	// finishes its ongoing iteration before exiting.
+	userThreads struct {
+		ctx context.Context
+		run func(ctx context.Context, stopped func() bool) error
+
+		mu       sync.Mutex
+		stops    []chan struct{}
+		running  int
+		finished int
+		exited   chan struct{}
+
+		wg       sync.WaitGroup
+		mErr     *multierror.Error
+		mErrLock sync.Mutex
+	}
+)
+
+func newUserThreads(ctx context.Context, run func(ctx context.Context, stopped func() bool) error) *userThreads {
+	return &userThreads{
+		ctx:    ctx,
+		run:    run,
+		exited: make(chan struct{}, 1),
+	}
+}
+
+// Count of threads currently started, not stopped and not exited
+func (threads *userThreads) Count() int {
+	threads.mu.Lock()
+	defer threads.mu.Unlock()
+	return len(threads.stops)
+}
+
+// Add start n new threads
+func (threads *userThreads) Add(n int) {
+	threads.mu.Lock()
+	defer threads.mu.Unlock()
+
+	for i := 0; i < n; i++ {
+		stop := make(chan struct{})
+		threads.stops = append(threads.stops, stop)
+
+		threads.running++
+		threads.wg.Add(1)
+		go func() {
+			defer threads.wg.Done()
+			defer threads.threadExited(stop)
+			stopped := func() bool {
+				select {
+				case <-stop:
+					return true
+				default:
+					return false
+				}
+			}
+			if err := threads.run(threads.ctx, stopped); err != nil {
+				threads.mErrLock.Lock()
+				defer threads.mErrLock.Unlock()
+				threads.mErr = multierror.Append(threads.mErr, err)
+			}
+		}()
+	}
+}
+
+// Running count of threads not yet exited, including stopped threads finishing their ongoing iteration
+func (threads *userThreads) Running() int {
+	threads.mu.Lock()
+	defer threads.mu.Unlock()
+	return threads.running
+}
+
+// Finished count of threads exited on their own without being stopped
+func (threads *userThreads) Finished() int {
+	threads.mu.Lock()
+	defer threads.mu.Unlock()
+	return threads.finished
+}
+
+// Exited is notified when a thread exits
+func (threads *userThreads) Exited() <-chan struct{} {
+	return threads.exited
+}
+
+func (threads *userThreads) threadExited(stop chan struct{}) {
+	threads.mu.Lock()
+	threads.running--
+	// thread exiting on its own, e.g. when done with its iterations, still has its stop channel
+	for i, s := range threads.stops {
+		if s == stop {
+			threads.stops = append(threads.stops[:i], threads.stops[i+1:]...)
+			threads.finished++
+			break
+		}
+	}
+	threads.mu.Unlock()
+
+	select {
+	case threads.exited <- struct{}{}:
+	default:
+	}
+}
+
+// Remove stop the n latest started threads
+func (threads *userThreads) Remove(n int) {
+	threads.mu.Lock()
+	defer threads.mu.Unlock()
+
+	for i := 0; i < n && len(threads.stops) > 0; i++ {
+		last := len(threads.stops) - 1
+		close(threads.stops[last])
+		threads.stops = threads.stops[:last]
+	}
+}
+
+// SetCount start or stop threads until count threads are running
+func (threads *userThreads) SetCount(count int) {
+	if count < 0 {
+		count = 0
+	}
+	diff := count - threads.Count()
+	switch {
+	case diff > 0:
+		threads.Add(diff)
+	case diff < 0:
+		threads.Remove(-diff)
+	}
+}
+
+// Wait for all threads, including stopped threads, to exit
+func (threads *userThreads) Wait() error {
+	threads.wg.Wait()
+
+	threads.mErrLock.Lock()
+	defer threads.mErrLock.Unlock()
+	return errors.WithStack(helpers.FlattenMultiError(threads.mErr))
+}
@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUserThreadsExited(t *testing.T) {
+	// only the first started thread exits on its own
+	exitFirst := make(chan struct{}, 1)
+	exitFirst <- struct{}{}
+	threads := newUserThreads(context.Background(), func(ctx context.Context, stopped func() bool) error {
+		select {
+		case <-exitFirst:
+			return nil
+		default:
+		}
+		for nextIteration(ctx, stopped) {
+			time.Sleep(time.Millisecond)
+		}
+		return nil
+	})
+
+	threads.Add(1)
+	select {
+	case <-threads.Exited():
+	case <-time.After(time.Second):
+		t.Fatal("thread not exited")
+	}
+	if count, finished := threads.Count(), threads.Finished(); count != 0 || finished != 1 {
+		t.Errorf("expected exited thread to be finished and not counted, got count<%d> finished<%d>", count, finished)
+	}
+
+	threads.SetCount(2)
+	if count := threads.Count(); count != 2 {
+		t.Errorf("expected<2> threads, got<%d>", count)
+	}
+	threads.SetCount(0)
+	if count, finished := threads.Count(), threads.Finished(); count != 0 || finished != 1 {
+		t.Errorf("expected stopped threads not to be finished, got count<%d> finished<%d>", count, finished)
+	}
+	if err := threads.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
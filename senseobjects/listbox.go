@@ -102,6 +102,15 @@ func (listBox *ListBox) GetListObjectData(ctx context.Context) ([]*enigma.NxData
 	})
 }
 
+// GetListObjectDataPages get requested datapages
+func (listBox *ListBox) GetListObjectDataPages(ctx context.Context, pages []*enigma.NxPage) ([]*enigma.NxDataPage, error) {
+	objDef, err := senseobjdef.GetObjectDef("listbox")
+	if err != nil {
+		return nil, err
+	}
+	return listBox.enigmaObject.GetListObjectData(ctx, string(objDef.Data[0].Requests[0].Path), pages)
+}
+
 // Layout for listBox
 func (listBox *ListBox) Layout() *ListBoxLayout {
 	return listBox.layout //TODO DECISION: wait for write lock?
@@ -3,12 +3,27 @@ package session
 import (
 	"net/http"
 	"sync"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
-// HeaderJar map between hosts and headers
-type HeaderJar struct {
-	headers *sync.Map
-}
+type (
+	// HeaderJar map between hosts and headers
+	HeaderJar struct {
+		headers *sync.Map
+	}
+
+	// HeaderRefreshFunc refresh header about to expire, returns header and expiry unchanged when not to be refreshed
+	HeaderRefreshFunc func(header http.Header, expires time.Time) (http.Header, time.Time, error)
+
+	// headerEntry header of host and its expiry, zero expiry when header doesn't expire
+	headerEntry struct {
+		header  http.Header
+		expires time.Time
+		refresh HeaderRefreshFunc
+	}
+)
 
 // NewHeaderJar returns an empty HeaderJar
 func NewHeaderJar() *HeaderJar {
@@ -19,14 +34,53 @@ func NewHeaderJar() *HeaderJar {
 
 // SetHeader set header for a particular host
 func (hj *HeaderJar) SetHeader(host string, header http.Header) {
-	hj.headers.Store(host, header)
+	hj.SetHeaderWithExpiry(host, header, time.Time{})
+}
+
+// SetHeaderWithExpiry set header for a particular host together with the time it expires
+func (hj *HeaderJar) SetHeaderWithExpiry(host string, header http.Header, expires time.Time) {
+	hj.SetHeaderWithRefresh(host, header, expires, nil)
+}
+
+// SetHeaderWithRefresh set header for a particular host together with the time it expires and function used to
+// refresh header when getting it with GetRefreshedHeader
+func (hj *HeaderJar) SetHeaderWithRefresh(host string, header http.Header, expires time.Time, refresh HeaderRefreshFunc) {
+	hj.headers.Store(host, headerEntry{header: header, expires: expires, refresh: refresh})
 }
 
 // GetHeader returns the header for the given host
 func (hj *HeaderJar) GetHeader(host string) http.Header {
+	header, _ := hj.GetHeaderWithExpiry(host)
+	return header
+}
+
+// GetHeaderWithExpiry returns the header for the given host and its expiry, zero expiry when header doesn't expire
+func (hj *HeaderJar) GetHeaderWithExpiry(host string) (http.Header, time.Time) {
 	value, found := hj.headers.Load(host)
 	if !found {
-		return nil
+		return nil, time.Time{}
+	}
+	entry := value.(headerEntry)
+	return entry.header, entry.expires
+}
+
+// GetRefreshedHeader returns the header for the given host, refreshed first if it has a refresh function
+func (hj *HeaderJar) GetRefreshedHeader(host string) (http.Header, error) {
+	value, found := hj.headers.Load(host)
+	if !found {
+		return nil, nil
+	}
+	entry := value.(headerEntry)
+	if entry.refresh == nil {
+		return entry.header, nil
+	}
+
+	header, expires, err := entry.refresh(entry.header, entry.expires)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if !expires.Equal(entry.expires) {
+		hj.SetHeaderWithRefresh(host, header, expires, entry.refresh)
 	}
-	return value.(http.Header)
+	return header, nil
 }
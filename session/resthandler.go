@@ -63,6 +63,10 @@ type (
 	// ConnectionSettings interface
 	ConnectionSettings interface {
 		AllowUntrusted() bool
+		// TLSClientConfig TLS configuration of connections for session user
+		TLSClientConfig(state *State) (*tls.Config, error)
+		// ProxyURL proxy to use for target URL, nil for direct connection
+		ProxyURL(target *url.URL) (*url.URL, error)
 	}
 
 	// Transport http transport interceptor
@@ -179,25 +183,30 @@ func (handler *RestHandler) DecPending(request *RestRequest) {
 
 // DefaultClient creates client instance with default client settings
 func DefaultClient(connectionSettings ConnectionSettings, state *State) (*http.Client, error) {
+	tlsConfig, err := connectionSettings.TLSClientConfig(state)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create TLS configuration")
+	}
+
 	// todo client values are currently from http.DefaultClient, should choose better values depending on
 	// configured timeout etc
 	client := &http.Client{
 		CheckRedirect: func(req *http.Request, via []*http.Request) error { return nil },
 		Transport: &Transport{
 			&http.Transport{
-				Proxy: http.ProxyFromEnvironment,
-				DialContext: (&net.Dialer{
+				Proxy: func(req *http.Request) (*url.URL, error) {
+					return connectionSettings.ProxyURL(req.URL)
+				},
+				DialContext: state.Network.DialContext((&net.Dialer{
 					Timeout:   30 * time.Second,
 					KeepAlive: 30 * time.Second,
 					DualStack: true,
-				}).DialContext,
+				}).DialContext),
 				MaxIdleConns:          100,
 				IdleConnTimeout:       90 * time.Second,
 				TLSHandshakeTimeout:   10 * time.Second,
 				ExpectContinueTimeout: 1 * time.Second,
-				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: connectionSettings.AllowUntrusted(),
-				},
+				TLSClientConfig:       tlsConfig,
 			},
 			state,
 		},
@@ -206,7 +215,6 @@ func DefaultClient(connectionSettings ConnectionSettings, state *State) (*http.C
 	if state.Cookies != nil {
 		client.Jar = state.Cookies
 	} else {
-		var err error
 		client.Jar, err = cookiejar.New(nil)
 		if err != nil {
 			return client, errors.Wrap(err, "failed creating cookie jar")
@@ -412,12 +420,20 @@ func (handler *RestHandler) QueueRequestWithCallback(actionState *action.State,
 			WarnOrError(actionState, logEntry, failOnError, errors.Wrapf(errRequest, "Failed to read REST response to %s", request.Destination))
 		}
 
+		// headers about to expire, e.g. a JWT, are refreshed before being used for request
+		var header http.Header
+		header, errRequest = handler.headers.GetRefreshedHeader(host)
+		if errRequest != nil {
+			WarnOrError(actionState, logEntry, failOnError, errors.Wrapf(errRequest, "Failed to get headers for request to %s", request.Destination))
+			return
+		}
+
 		if request.ContentReader == nil {
-			if errRequest = handler.performRestCall(handler.ctx, request, handler.Client, logEntry, handler.headers.GetHeader(host)); errRequest != nil {
+			if errRequest = handler.performRestCall(handler.ctx, request, handler.Client, logEntry, header); errRequest != nil {
 				WarnOrError(actionState, logEntry, failOnError, errors.WithStack(errRequest))
 			}
 		} else {
-			if errRequest = handler.postWithReader(handler.ctx, request, handler.Client, logEntry, handler.headers.GetHeader(host)); errRequest != nil {
+			if errRequest = handler.postWithReader(handler.ctx, request, handler.Client, logEntry, header); errRequest != nil {
 				WarnOrError(actionState, logEntry, failOnError, errors.WithStack(errRequest))
 			}
 		}
@@ -15,6 +15,7 @@ import (
 	"github.com/qlik-oss/gopherciser/enigmahandlers"
 	"github.com/qlik-oss/gopherciser/helpers"
 	"github.com/qlik-oss/gopherciser/logger"
+	"github.com/qlik-oss/gopherciser/netemulation"
 	"github.com/qlik-oss/gopherciser/randomizer"
 	"github.com/qlik-oss/gopherciser/requestmetrics"
 	"github.com/qlik-oss/gopherciser/statistics"
@@ -68,6 +69,12 @@ type (
 		OutputsDir   string
 		CurrentApp   *ArtifactEntry
 		CurrentUser  *elasticstructs.User
+		// Persona name of persona assigned to user, empty when not using personas
+		Persona string
+		// Node name of server node session is connected to, empty when using a single server
+		Node string
+		// Network emulated network conditions of session connections, nil when not emulating network conditions
+		Network *netemulation.Conditions
 
 		rand          *rand
 		trafficLogger enigmahandlers.ITrafficLogger
@@ -153,11 +153,12 @@ func TestState_SessionVariables(t *testing.T) {
 		},
 	})
 	state.User = &users.User{
-		UserName: "myuser",
+		UserName:   "myuser",
+		Attributes: map[string]string{"tenant": "mytenant"},
 	}
 
-	standardStrings := []string{"{{.UserName}}", "{{.Thread}}", "{{.Session}}"}
-	expectedResults := []string{"myuser", "5", "56"}
+	standardStrings := []string{"{{.UserName}}", "{{.Thread}}", "{{.Session}}", "{{.Attributes.tenant}}"}
+	expectedResults := []string{"myuser", "5", "56", "mytenant"}
 
 	if len(standardStrings) != len(expectedResults) {
 		t.Fatal("inconsistent count of patterns to test and expected results")
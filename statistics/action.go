@@ -12,6 +12,8 @@ type (
 		name    string
 		label   string
 		appGuid string
+		persona string
+		node    string
 		// RespAvg average response time for successful actions
 		RespAvg *SampleCollector
 		// Requests total count of requests sent within action
@@ -31,10 +33,22 @@ type (
 
 // NewActionStats creates a new action statistics collector
 func NewActionStats(name, label, appGUID string) *ActionStats {
+	return NewPersonaActionStats("", name, label, appGUID)
+}
+
+// NewPersonaActionStats creates a new action statistics collector for action executed by persona
+func NewPersonaActionStats(persona, name, label, appGUID string) *ActionStats {
+	return NewNodeActionStats(persona, "", name, label, appGUID)
+}
+
+// NewNodeActionStats creates a new action statistics collector for action executed by persona on server node
+func NewNodeActionStats(persona, node, name, label, appGUID string) *ActionStats {
 	return &ActionStats{
 		name:    name,
 		label:   label,
 		appGuid: appGUID,
+		persona: persona,
+		node:    node,
 		RespAvg: NewSampleCollector(),
 	}
 }
@@ -62,3 +76,19 @@ func (action *ActionStats) AppGUID() string {
 	}
 	return action.appGuid
 }
+
+// Persona executing action, empty when not using personas
+func (action *ActionStats) Persona() string {
+	if action == nil {
+		return ""
+	}
+	return action.persona
+}
+
+// Node on which action was executed, empty when using a single server
+func (action *ActionStats) Node() string {
+	if action == nil {
+		return ""
+	}
+	return action.node
+}
@@ -1,6 +1,7 @@
 package statistics
 
 import (
+	"strings"
 	"sync"
 
 	"github.com/pkg/errors"
@@ -46,11 +47,18 @@ func NewCollector() *Collector {
 
 // GetOrAddActionStats from action map, returns nil if statistics is turned off
 func (collector *Collector) GetOrAddActionStats(name, label, appGUID string) *ActionStats {
+	return collector.GetOrAddNodeActionStats("", "", name, label, appGUID)
+}
+
+// GetOrAddNodeActionStats from action map for action executed by persona on server node, returns nil if statistics
+// is turned off
+func (collector *Collector) GetOrAddNodeActionStats(persona, node, name, label, appGUID string) *ActionStats {
 	if collector == nil || !collector.IsOn() {
 		return nil
 	}
 
-	key := name + label + appGUID
+	// separate parts of key to not have e.g. persona "ab" and node "c" collide with persona "a" and node "bc"
+	key := strings.Join([]string{persona, node, name, label, appGUID}, "\x00")
 
 	// Read with Read lock as multiple reader can acquire read lock simultaneously
 	if stats := collector.readActionWithKey(key); stats != nil {
@@ -66,7 +74,7 @@ func (collector *Collector) GetOrAddActionStats(name, label, appGUID string) *Ac
 		return stats
 	}
 
-	stats := NewActionStats(name, label, appGUID)
+	stats := NewNodeActionStats(persona, node, name, label, appGUID)
 	collector.Actions[key] = stats
 	return stats
 }
@@ -82,6 +90,12 @@ func GetOrAddGlobalActionStats(name, label, appGUID string) *ActionStats {
 	return globalCollector.GetOrAddActionStats(name, label, appGUID)
 }
 
+// GetOrAddGlobalNodeActionStats from action map of global collector for action executed by persona on server node,
+// returns nil if statistics is turned off
+func GetOrAddGlobalNodeActionStats(persona, node, name, label, appGUID string) *ActionStats {
+	return globalCollector.GetOrAddNodeActionStats(persona, node, name, label, appGUID)
+}
+
 // GetOrAddRequestStats from REST request map, returns nil if StatsLevel is lower than "full"
 func (collector *Collector) GetOrAddRequestStats(method, path string) *RequestStats {
 	if collector == nil || !collector.IsFull() {
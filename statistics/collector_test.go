@@ -0,0 +1,20 @@
+package statistics
+
+import "testing"
+
+func TestCollectorActionKeys(t *testing.T) {
+	collector := NewCollector()
+	_ = collector.SetLevel(StatsLevelOn)
+
+	first := collector.GetOrAddNodeActionStats("ab", "c", "openapp", "", "guid")
+	second := collector.GetOrAddNodeActionStats("a", "bc", "openapp", "", "guid")
+	if first == second {
+		t.Error("actions of different persona and node share statistics")
+	}
+	if stats := collector.GetOrAddNodeActionStats("ab", "c", "openapp", "", "guid"); stats != first {
+		t.Error("same action got new statistics")
+	}
+	if len(collector.Actions) != 2 {
+		t.Errorf("expected 2 actions, got<%d>", len(collector.Actions))
+	}
+}
@@ -0,0 +1,75 @@
+package statistics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// ActionResult result of an executed action
+	ActionResult struct {
+		Persona      string
+		Node         string
+		Name         string
+		Label        string
+		Success      bool
+		ResponseTime time.Duration
+	}
+
+	// ResultListener called for each reported action result, has to be safe for concurrent use
+	ResultListener func(result ActionResult)
+)
+
+var (
+	resultListeners     = make(map[int]ResultListener)
+	resultListenersID   int
+	resultListenersLock sync.RWMutex
+)
+
+// AddResultListener add listener for action results, returns function removing listener. Action results are
+// reported regardless of statistics level.
+func AddResultListener(listener ResultListener) (remove func()) {
+	resultListenersLock.Lock()
+	defer resultListenersLock.Unlock()
+
+	resultListenersID++
+	id := resultListenersID
+	resultListeners[id] = listener
+
+	return func() {
+		resultListenersLock.Lock()
+		defer resultListenersLock.Unlock()
+		delete(resultListeners, id)
+	}
+}
+
+// ReportResult report action result to all listeners
+func ReportResult(result ActionResult) {
+	resultListenersLock.RLock()
+	defer resultListenersLock.RUnlock()
+
+	for _, listener := range resultListeners {
+		listener(result)
+	}
+}
+
+// Percentile p (0-100) of samples using nearest rank, samples are not modified
+func Percentile(samples []float64, p float64) float64 {
+	if len(samples) < 1 {
+		return 0
+	}
+	sorted := make([]float64, len(samples))
+	copy(sorted, samples)
+	sort.Float64s(sorted)
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
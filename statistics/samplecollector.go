@@ -77,12 +77,11 @@ func (collector *SampleCollector) emptyHotBuffer() {
 	if collector.count < 1 {
 		collector.curAvg = newSum / newCount
 		collector.count = newCount
-		return
+	} else {
+		// avoiding (curAvg*count + newAvg*newCount)/(count+newCount) for overflow protection, below equation equates to the same value
+		collector.curAvg = collector.curAvg/(1+newCount/collector.count) + newSum/(collector.count+newCount)
+		collector.count += newCount
 	}
-
-	// avoiding (curAvg*count + newAvg*newCount)/(count+newCount) for overflow protection, below equation equates to the same value
-	collector.curAvg = collector.curAvg/(1+newCount/collector.count) + newSum/(collector.count+newCount)
-	collector.count += newCount
 	collector.hotBuf = collector.hotBuf[0:0]
 	collector.bufPurgeTs = time.Now().Add(collector.bufPurgeExpiry)
 }
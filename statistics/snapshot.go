@@ -0,0 +1,221 @@
+package statistics
+
+type (
+	// SampleSnapshot average and amount of samples of a sample collector
+	SampleSnapshot struct {
+		Average float64 `json:"average"`
+		Count   float64 `json:"count"`
+	}
+
+	// ActionStatsSnapshot copy of action statistics
+	ActionStatsSnapshot struct {
+		Name      string         `json:"name"`
+		Label     string         `json:"label,omitempty"`
+		AppGUID   string         `json:"appguid,omitempty"`
+		Persona   string         `json:"persona,omitempty"`
+		Node      string         `json:"node,omitempty"`
+		RespAvg   SampleSnapshot `json:"respavg"`
+		Requests  uint64         `json:"requests"`
+		ErrCount  uint64         `json:"errors"`
+		WarnCount uint64         `json:"warnings"`
+		Sent      uint64         `json:"sent"`
+		Received  uint64         `json:"received"`
+		Failed    uint64         `json:"failed"`
+	}
+
+	// RequestStatsSnapshot copy of REST request statistics
+	RequestStatsSnapshot struct {
+		Method   string         `json:"method"`
+		Path     string         `json:"path"`
+		RespAvg  SampleSnapshot `json:"respavg"`
+		Sent     uint64         `json:"sent"`
+		Received uint64         `json:"received"`
+	}
+
+	// Snapshot serializable copy of collected statistics, used to merge statistics collected by other processes
+	Snapshot struct {
+		OpenedApps   uint64                 `json:"openedapps"`
+		CreatedApps  uint64                 `json:"createdapps"`
+		Actions      []ActionStatsSnapshot  `json:"actions,omitempty"`
+		RestRequests []RequestStatsSnapshot `json:"restrequests,omitempty"`
+	}
+)
+
+// Snapshot of current average and amount of samples
+func (collector *SampleCollector) Snapshot() SampleSnapshot {
+	avg, count := collector.Average()
+	return SampleSnapshot{Average: avg, Count: count}
+}
+
+// Merge samples summarized by snapshot into collector
+func (collector *SampleCollector) Merge(snapshot SampleSnapshot) {
+	if snapshot.Count <= 0 {
+		return
+	}
+
+	defer collector.bufLock.Unlock()
+	collector.bufLock.Lock()
+
+	collector.emptyHotBuffer()
+
+	if collector.count < 1 {
+		collector.curAvg = snapshot.Average
+		collector.count = snapshot.Count
+		return
+	}
+
+	total := collector.count + snapshot.Count
+	collector.curAvg = collector.curAvg*(collector.count/total) + snapshot.Average*(snapshot.Count/total)
+	collector.count = total
+}
+
+// Sub samples in snapshot not included in prev, where snapshot and prev are taken from the same collector
+func (snapshot SampleSnapshot) Sub(prev SampleSnapshot) SampleSnapshot {
+	count := snapshot.Count - prev.Count
+	if count <= 0 {
+		return SampleSnapshot{}
+	}
+	return SampleSnapshot{
+		Average: (snapshot.Average*snapshot.Count - prev.Average*prev.Count) / count,
+		Count:   count,
+	}
+}
+
+// Snapshot of statistics currently collected, returns nil if statistics is turned off
+func (collector *Collector) Snapshot() *Snapshot {
+	if !collector.IsOn() {
+		return nil
+	}
+
+	snapshot := &Snapshot{
+		OpenedApps:  collector.OpenedApps(),
+		CreatedApps: collector.CreatedApps(),
+	}
+
+	collector.ForEachAction(func(stats *ActionStats) {
+		snapshot.Actions = append(snapshot.Actions, ActionStatsSnapshot{
+			Name:      stats.Name(),
+			Label:     stats.Label(),
+			AppGUID:   stats.AppGUID(),
+			Persona:   stats.Persona(),
+			Node:      stats.Node(),
+			RespAvg:   stats.RespAvg.Snapshot(),
+			Requests:  stats.Requests.Current(),
+			ErrCount:  stats.ErrCount.Current(),
+			WarnCount: stats.WarnCount.Current(),
+			Sent:      stats.Sent.Current(),
+			Received:  stats.Received.Current(),
+			Failed:    stats.Failed.Current(),
+		})
+	})
+
+	collector.ForEachRequest(func(stats *RequestStats) {
+		snapshot.RestRequests = append(snapshot.RestRequests, RequestStatsSnapshot{
+			Method:   stats.Method(),
+			Path:     stats.Path(),
+			RespAvg:  stats.RespAvg.Snapshot(),
+			Sent:     stats.Sent.Current(),
+			Received: stats.Received.Current(),
+		})
+	})
+
+	return snapshot
+}
+
+// GlobalSnapshot snapshot of statistics currently collected by global collector, returns nil if statistics is
+// turned off
+func GlobalSnapshot() *Snapshot {
+	return globalCollector.Snapshot()
+}
+
+// Merge add statistics in snapshot to collector
+func (collector *Collector) Merge(snapshot *Snapshot) {
+	if snapshot == nil || !collector.IsOn() {
+		return
+	}
+
+	collector.totOpenedApps.Add(snapshot.OpenedApps)
+	collector.totCreatedApps.Add(snapshot.CreatedApps)
+
+	for _, action := range snapshot.Actions {
+		stats := collector.GetOrAddNodeActionStats(action.Persona, action.Node, action.Name, action.Label, action.AppGUID)
+		stats.RespAvg.Merge(action.RespAvg)
+		stats.Requests.Add(action.Requests)
+		stats.ErrCount.Add(action.ErrCount)
+		stats.WarnCount.Add(action.WarnCount)
+		stats.Sent.Add(action.Sent)
+		stats.Received.Add(action.Received)
+		stats.Failed.Add(action.Failed)
+	}
+
+	for _, request := range snapshot.RestRequests {
+		stats := collector.GetOrAddRequestStats(request.Method, request.Path)
+		if stats == nil {
+			continue
+		}
+		stats.RespAvg.Merge(request.RespAvg)
+		stats.Sent.Add(request.Sent)
+		stats.Received.Add(request.Received)
+	}
+}
+
+// MergeGlobal add statistics in snapshot to global collector
+func MergeGlobal(snapshot *Snapshot) {
+	globalCollector.Merge(snapshot)
+}
+
+// Sub statistics in snapshot not included in prev, where snapshot and prev are taken from the same collector. prev
+// is allowed to be nil.
+func (snapshot *Snapshot) Sub(prev *Snapshot) *Snapshot {
+	if snapshot == nil || prev == nil {
+		return snapshot
+	}
+
+	diff := &Snapshot{
+		OpenedApps:  snapshot.OpenedApps - prev.OpenedApps,
+		CreatedApps: snapshot.CreatedApps - prev.CreatedApps,
+	}
+
+	prevActions := make(map[string]ActionStatsSnapshot, len(prev.Actions))
+	for _, action := range prev.Actions {
+		prevActions[action.key()] = action
+	}
+	for _, action := range snapshot.Actions {
+		old := prevActions[action.key()]
+		diff.Actions = append(diff.Actions, ActionStatsSnapshot{
+			Name:      action.Name,
+			Label:     action.Label,
+			AppGUID:   action.AppGUID,
+			Persona:   action.Persona,
+			Node:      action.Node,
+			RespAvg:   action.RespAvg.Sub(old.RespAvg),
+			Requests:  action.Requests - old.Requests,
+			ErrCount:  action.ErrCount - old.ErrCount,
+			WarnCount: action.WarnCount - old.WarnCount,
+			Sent:      action.Sent - old.Sent,
+			Received:  action.Received - old.Received,
+			Failed:    action.Failed - old.Failed,
+		})
+	}
+
+	prevRequests := make(map[string]RequestStatsSnapshot, len(prev.RestRequests))
+	for _, request := range prev.RestRequests {
+		prevRequests[request.Method+request.Path] = request
+	}
+	for _, request := range snapshot.RestRequests {
+		old := prevRequests[request.Method+request.Path]
+		diff.RestRequests = append(diff.RestRequests, RequestStatsSnapshot{
+			Method:   request.Method,
+			Path:     request.Path,
+			RespAvg:  request.RespAvg.Sub(old.RespAvg),
+			Sent:     request.Sent - old.Sent,
+			Received: request.Received - old.Received,
+		})
+	}
+
+	return diff
+}
+
+func (action *ActionStatsSnapshot) key() string {
+	return action.Persona + action.Node + action.Name + action.Label + action.AppGUID
+}
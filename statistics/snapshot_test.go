@@ -0,0 +1,80 @@
+package statistics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSnapshotMerge(t *testing.T) {
+	worker := NewCollector()
+	_ = worker.SetLevel(StatsLevelFull)
+
+	stats := worker.GetOrAddNodeActionStats("p1", "", "openapp", "lbl", "guid")
+	stats.RespAvg.AddSample(10)
+	stats.RespAvg.AddSample(20)
+	stats.Requests.Add(3)
+	stats.Failed.Inc()
+	worker.IncOpenedApps()
+
+	first := worker.Snapshot()
+	if first == nil || len(first.Actions) != 1 {
+		t.Fatalf("unexpected snapshot<%+v>", first)
+	}
+
+	// snapshot should not alter collected samples
+	if avg, count := stats.RespAvg.Average(); avg != 15 || count != 2 {
+		t.Errorf("expected avg<15> count<2>, got avg<%f> count<%f>", avg, count)
+	}
+
+	stats.RespAvg.AddSample(30)
+	stats.Requests.Inc()
+	second := worker.Snapshot()
+
+	controller := NewCollector()
+	_ = controller.SetLevel(StatsLevelOn)
+	controller.Merge(first)
+	controller.Merge(second.Sub(first))
+
+	merged := controller.GetOrAddNodeActionStats("p1", "", "openapp", "lbl", "guid")
+	avg, count := merged.RespAvg.Average()
+	if math.Abs(avg-20) > 0.0001 || count != 3 {
+		t.Errorf("expected avg<20> count<3>, got avg<%f> count<%f>", avg, count)
+	}
+	if merged.Requests.Current() != 4 {
+		t.Errorf("expected 4 requests, got<%d>", merged.Requests.Current())
+	}
+	if merged.Failed.Current() != 1 {
+		t.Errorf("expected 1 failed, got<%d>", merged.Failed.Current())
+	}
+	if controller.OpenedApps() != 1 {
+		t.Errorf("expected 1 opened app, got<%d>", controller.OpenedApps())
+	}
+	if len(controller.RestRequests) != 0 {
+		t.Errorf("expected no REST requests with statistics level on, got<%d>", len(controller.RestRequests))
+	}
+}
+
+func TestSnapshotMergeNodes(t *testing.T) {
+	worker := NewCollector()
+	_ = worker.SetLevel(StatsLevelOn)
+
+	worker.GetOrAddNodeActionStats("p1", "node1", "openapp", "", "guid").RespAvg.AddSample(10)
+	worker.GetOrAddNodeActionStats("p1", "node2", "openapp", "", "guid").RespAvg.AddSample(30)
+
+	controller := NewCollector()
+	_ = controller.SetLevel(StatsLevelOn)
+	controller.Merge(worker.Snapshot())
+
+	if len(controller.Actions) != 2 {
+		t.Fatalf("expected action statistics per node, got<%d> entries", len(controller.Actions))
+	}
+	for node, expected := range map[string]float64{"node1": 10, "node2": 30} {
+		stats := controller.GetOrAddNodeActionStats("p1", node, "openapp", "", "guid")
+		if stats.Node() != node {
+			t.Errorf("unexpected node<%s>, expected<%s>", stats.Node(), node)
+		}
+		if avg, _ := stats.RespAvg.Average(); avg != expected {
+			t.Errorf("node<%s> expected avg<%f>, got<%f>", node, expected, avg)
+		}
+	}
+}
@@ -10,6 +10,11 @@ type (
 		UserName  string   `json:"username" displayname:"Username"`
 		Password  Password `json:"password,omitempty" displayname:"Password"`
 		Directory string   `json:"directory,omitempty" displayname:"User directory"`
+		// Attributes custom user attributes, e.g. tenant or group, available in templates as {{.Attributes.tenant}}
+		Attributes map[string]string `json:"attributes,omitempty" displayname:"User attributes"`
+
+		// release leased user
+		release func()
 	}
 
 	CircularUsers struct {
@@ -24,7 +29,7 @@ type (
 func NewCircularUsers() *CircularUsers {
 	return &CircularUsers{
 		mtx:      &sync.Mutex{},
-		UserList: []*User{{"", "", ""}},
+		UserList: []*User{{}},
 	}
 }
 
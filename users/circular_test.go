@@ -108,3 +108,13 @@ func TestCircularUsers_Iterate_concurrent(t *testing.T) {
 		t.Log(usersString)
 	}
 }
+
+func TestUser_MarshalAttributes(t *testing.T) {
+	raw, err := jsonit.Marshal(User{UserName: "u"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"username":"u","password":"***"}`, string(raw))
+
+	raw, err = jsonit.Marshal(User{UserName: "u", Attributes: map[string]string{"tenant": "t1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"username":"u","password":"***","attributes":{"tenant":"t1"}}`, string(raw))
+}
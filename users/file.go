@@ -0,0 +1,174 @@
+package users
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// FileUsers users read from a CSV or TSV file with a header row
+	FileUsers struct {
+		FileName  string   `json:"filename" displayname:"Users file" displayelement:"file" doc-key:"config.loginSettings.settings.filename"`
+		Separator string   `json:"separator,omitempty" displayname:"Column separator" doc-key:"config.loginSettings.settings.separator"`
+		Password  Password `json:"password,omitempty" displayname:"Password" doc-key:"config.loginSettings.settings.password"`
+		Directory string   `json:"directory,omitempty" displayname:"User directory" doc-key:"config.loginSettings.settings.directory"`
+
+		load     sync.Once
+		loadErr  error
+		userList []*User
+	}
+)
+
+const (
+	fileColumnUserName  = "username"
+	fileColumnPassword  = "password"
+	fileColumnDirectory = "directory"
+)
+
+// Iterate returns the next user of the file in a circular manner, iteration should always be > 0
+func (users *FileUsers) Iterate(iteration uint64) *User {
+	if users == nil || iteration < 1 {
+		return nil
+	}
+	if err := users.loadFile(); err != nil {
+		return nil
+	}
+	return users.userList[(iteration-1)%uint64(len(users.userList))]
+}
+
+// Validate validates settings and reads users file
+func (users *FileUsers) Validate() error {
+	if users.FileName == "" {
+		return errors.New("login type<file> requires filename to be set")
+	}
+	if _, err := users.separator(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(users.loadFile())
+}
+
+// loadFile read users file once
+func (users *FileUsers) loadFile() error {
+	users.load.Do(func() {
+		users.userList, users.loadErr = users.readFile()
+	})
+	return users.loadErr
+}
+
+func (users *FileUsers) readFile() ([]*User, error) {
+	separator, err := users.separator()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	file, err := os.Open(users.FileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open users file<%s>", users.FileName)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	userList, err := users.readUsers(file, separator)
+	return userList, errors.Wrapf(err, "failed to read users file<%s>", users.FileName)
+}
+
+// separator defaults to tab for .tsv files and comma otherwise
+func (users *FileUsers) separator() (rune, error) {
+	if users.Separator == "" {
+		if strings.EqualFold(filepath.Ext(users.FileName), ".tsv") {
+			return '\t', nil
+		}
+		return ',', nil
+	}
+	if utf8.RuneCountInString(users.Separator) != 1 {
+		return 0, errors.Errorf("separator<%s> needs to be a single character", users.Separator)
+	}
+	separator, _ := utf8.DecodeRuneInString(users.Separator)
+	return separator, nil
+}
+
+// readUsers from rows of CSV with a header row. Columns username, password and directory are set on the user, any
+// other column is added as a user attribute named as the column header.
+func (users *FileUsers) readUsers(r io.Reader, separator rune) ([]*User, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = separator
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("no header row")
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	hasUserName := false
+	for i := range header {
+		// spreadsheet exports might start with a byte order mark
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+		if header[i] == "" {
+			return nil, errors.Errorf("column<%d> has no header", i+1)
+		}
+		if strings.EqualFold(header[i], fileColumnUserName) {
+			hasUserName = true
+		}
+	}
+	if !hasUserName {
+		return nil, errors.Errorf("header has no %s column", fileColumnUserName)
+	}
+
+	var userList []*User
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		user := &User{
+			Password:  users.Password,
+			Directory: users.Directory,
+		}
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			switch strings.ToLower(header[i]) {
+			case fileColumnUserName:
+				user.UserName = value
+			case fileColumnPassword:
+				if value != "" {
+					user.Password = Password(value)
+				}
+			case fileColumnDirectory:
+				if value != "" {
+					user.Directory = value
+				}
+			default:
+				if user.Attributes == nil {
+					user.Attributes = make(map[string]string, len(record))
+				}
+				user.Attributes[header[i]] = value
+			}
+		}
+
+		if user.UserName == "" {
+			return nil, errors.Errorf("row<%d> has no %s", row, fileColumnUserName)
+		}
+		userList = append(userList, user)
+	}
+
+	if len(userList) < 1 {
+		return nil, errors.New("no users")
+	}
+	return userList, nil
+}
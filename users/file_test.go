@@ -0,0 +1,84 @@
+package users
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileUsers_readUsers(t *testing.T) {
+	users := &FileUsers{Password: "defaultpw", Directory: "defaultdir"}
+
+	csvData := "\ufeffUserName,password,tenant,group\n" +
+		"# comment\n" +
+		"user1,pw1,tenantA,admins\n" +
+		"user2,,tenantB,\n"
+	userList, err := users.readUsers(strings.NewReader(csvData), ',')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, userList, 2) {
+		return
+	}
+
+	assert.Equal(t, "user1", userList[0].UserName)
+	assert.Equal(t, Password("pw1"), userList[0].Password)
+	assert.Equal(t, "defaultdir", userList[0].Directory)
+	assert.Equal(t, map[string]string{"tenant": "tenantA", "group": "admins"}, userList[0].Attributes)
+
+	assert.Equal(t, Password("defaultpw"), userList[1].Password)
+	assert.Equal(t, "tenantB", userList[1].Attributes["tenant"])
+	assert.Equal(t, "", userList[1].Attributes["group"])
+
+	_, err = users.readUsers(strings.NewReader("name,tenant\nuser1,tenantA\n"), ',')
+	assert.Error(t, err, "expected error on missing username column")
+
+	_, err = users.readUsers(strings.NewReader("username,tenant\n,tenantA\n"), ',')
+	assert.Error(t, err, "expected error on empty username")
+
+	_, err = users.readUsers(strings.NewReader("username,tenant\n"), ',')
+	assert.Error(t, err, "expected error on no users")
+}
+
+func TestFileUsers_Iterate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fileusers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	filename := filepath.Join(dir, "users.tsv")
+	if err := ioutil.WriteFile(filename, []byte("username\tdirectory\tregion\nuser1\tdir1\teu\nuser2\tdir2\tus\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var gen UserGenerator
+	if err := jsonit.Unmarshal([]byte(`{"type":"file","settings":{"filename":"`+filepath.ToSlash(filename)+`"}}`), &gen); err != nil {
+		t.Fatal(err)
+	}
+	if err := gen.Settings.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"eu", "us", "eu"}
+	for i, region := range expected {
+		user := gen.Settings.Iterate(uint64(i + 1))
+		if !assert.NotNil(t, user) {
+			return
+		}
+		assert.Equal(t, region, user.Attributes["region"])
+	}
+
+	missing := NewUserGeneratorFile(filepath.Join(dir, "missing.csv"))
+	assert.Error(t, missing.Settings.Validate(), "expected error on missing file")
+	assert.Nil(t, missing.Settings.Iterate(1))
+
+	invalid := &FileUsers{FileName: filename, Separator: ";;"}
+	assert.Error(t, invalid.Validate(), "expected error on invalid separator")
+}
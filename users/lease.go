@@ -0,0 +1,137 @@
+package users
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/qlik-oss/gopherciser/globals"
+	"github.com/qlik-oss/gopherciser/helpers"
+)
+
+type (
+	// leasePool users currently leased to sessions
+	leasePool struct {
+		timeout time.Duration
+
+		mu        sync.Mutex
+		iteration uint64
+		leased    map[string]struct{}
+		released  chan struct{}
+	}
+)
+
+// DefaultLeaseTimeout default max time to wait for a user to be released when all users are leased
+const DefaultLeaseTimeout = time.Minute
+
+// ErrLeaseTimeout no user was released within lease timeout
+var ErrLeaseTimeout = errors.New("no user available to lease within lease timeout")
+
+func newLeasePool(timeout time.Duration) *leasePool {
+	if timeout <= 0 {
+		timeout = DefaultLeaseTimeout
+	}
+	return &leasePool{
+		timeout:  timeout,
+		leased:   make(map[string]struct{}),
+		released: make(chan struct{}),
+	}
+}
+
+// EnableLeasing lend each user to one session at a time, waiting max timeout for a user to be released when all users
+// are leased. timeout <= 0 uses DefaultLeaseTimeout.
+func (value *UserGenerator) EnableLeasing(timeout time.Duration) {
+	value.Lease = true
+	value.LeaseTimeout = helpers.TimeDuration(timeout)
+	value.pool = newLeasePool(timeout)
+}
+
+// GetNextLeased user to simulate. When leasing is enabled the user is not handed out again until released with
+// User.Release, and GetNextLeased blocks while all users are leased. Returns ErrLeaseTimeout when no user was released
+// within lease timeout. When leasing is disabled, or users are anonymous (type none), it's the same as GetNext.
+func (value *UserGenerator) GetNextLeased(ctx context.Context) (*User, error) {
+	if !value.Lease || value.GeneratorType == UserGeneratorNone {
+		return value.GetNext(), nil
+	}
+	if value.pool == nil {
+		return nil, errors.New("user leasing enabled but not initialized")
+	}
+	return value.pool.lease(ctx, value.Settings)
+}
+
+// lease first user not already leased, in iteration order
+func (pool *leasePool) lease(ctx context.Context, settings Settings) (*User, error) {
+	timer := time.NewTimer(pool.timeout)
+	defer timer.Stop()
+
+	for {
+		user, released := pool.tryLease(settings)
+		if user != nil {
+			globals.Users.Inc()
+			return user, nil
+		}
+
+		select {
+		case <-released:
+		case <-timer.C:
+			return nil, errors.WithStack(ErrLeaseTimeout)
+		case <-ctx.Done():
+			return nil, errors.WithStack(ctx.Err())
+		}
+	}
+}
+
+// tryLease user not already leased, or channel closed on next release if all users are leased. Iterates until a free
+// user is found or until a user is repeated, i.e. all users of the generator have been tried.
+func (pool *leasePool) tryLease(settings Settings) (*User, <-chan struct{}) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	tried := make(map[string]struct{})
+	for {
+		user := settings.Iterate(pool.iteration + 1)
+		if user == nil {
+			return nil, pool.released
+		}
+		key := user.leaseKey()
+		if _, ok := tried[key]; ok {
+			return nil, pool.released
+		}
+		tried[key] = struct{}{}
+		pool.iteration++
+
+		if _, ok := pool.leased[key]; ok {
+			continue
+		}
+
+		pool.leased[key] = struct{}{}
+		user.release = func() {
+			pool.release(key)
+		}
+		return user, nil
+	}
+}
+
+func (pool *leasePool) release(key string) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	delete(pool.leased, key)
+	close(pool.released)
+	pool.released = make(chan struct{})
+}
+
+func (user *User) leaseKey() string {
+	return user.Directory + "\\" + user.UserName
+}
+
+// Release leased user, making it available to other sessions. Does nothing if user is not leased.
+func (user *User) Release() {
+	if user == nil || user.release == nil {
+		return
+	}
+	release := user.release
+	user.release = nil
+	release()
+}
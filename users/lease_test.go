@@ -0,0 +1,75 @@
+package users
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserGenerator_GetNextLeased(t *testing.T) {
+	gen := NewUserGeneratorCircular(createUserList([]string{"one", "two"}, somePassword))
+	gen.EnableLeasing(50 * time.Millisecond)
+	ctx := context.Background()
+
+	one, err := gen.GetNextLeased(ctx)
+	assert.NoError(t, err)
+	two, err := gen.GetNextLeased(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "one", one.UserName)
+	assert.Equal(t, "two", two.UserName)
+
+	// all users leased
+	_, err = gen.GetNextLeased(ctx)
+	assert.Equal(t, ErrLeaseTimeout, errors.Cause(err))
+
+	// released user is handed out again, while leased user is skipped
+	one.Release()
+	user, err := gen.GetNextLeased(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "one", user.UserName)
+
+	// blocked lease continues when user is released
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		two.Release()
+	}()
+	user, err = gen.GetNextLeased(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "two", user.UserName)
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = gen.GetNextLeased(cancelCtx)
+	assert.Equal(t, context.Canceled, errors.Cause(err))
+}
+
+func TestUserGenerator_UnmarshalLease(t *testing.T) {
+	var gen UserGenerator
+	raw := []byte(`{
+		"type" : "userlist",
+		"lease" : true,
+		"leasetimeout" : "10ms",
+		"settings" : {
+			"userlist" : [{ "username" : "one" }]
+		}
+	}`)
+	if err := jsonit.Unmarshal(raw, &gen); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, gen.Lease)
+
+	// copies of the generator share leased users
+	genCopy := gen
+	user, err := genCopy.GetNextLeased(context.Background())
+	assert.NoError(t, err)
+	_, err = gen.GetNextLeased(context.Background())
+	assert.Equal(t, ErrLeaseTimeout, errors.Cause(err))
+
+	user.Release()
+	user.Release() // releasing twice is a no-op
+	_, err = gen.GetNextLeased(context.Background())
+	assert.NoError(t, err)
+}
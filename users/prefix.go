@@ -13,9 +13,8 @@ type PrefixUsers struct {
 // Iterate returns the next user in a circular manner
 func (users *PrefixUsers) Iterate(iteration uint64) *User {
 	return &User{
-		fmt.Sprintf("%s_%d", users.Prefix, iteration),
-		"",
-		users.Directory,
+		UserName:  fmt.Sprintf("%s_%d", users.Prefix, iteration),
+		Directory: users.Directory,
 	}
 }
 
@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"runtime"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
 	"github.com/pkg/errors"
 	"github.com/qlik-oss/gopherciser/enummap"
 	"github.com/qlik-oss/gopherciser/globals"
+	"github.com/qlik-oss/gopherciser/helpers"
 )
 
 type (
@@ -24,7 +26,9 @@ type (
 	}
 
 	GeneratorCore struct {
-		GeneratorType Type `json:"type" displayname:"User generator type" doc-key:"config.loginSettings.type"`
+		GeneratorType Type                 `json:"type" displayname:"User generator type" doc-key:"config.loginSettings.type"`
+		Lease         bool                 `json:"lease,omitempty" displayname:"Lease users" doc-key:"config.loginSettings.lease"`
+		LeaseTimeout  helpers.TimeDuration `json:"leasetimeout,omitempty" displayname:"Lease timeout" doc-key:"config.loginSettings.leasetimeout"`
 	}
 
 	generatorTmp struct {
@@ -36,6 +40,9 @@ type (
 	UserGenerator struct {
 		GeneratorCore
 		Settings Settings `json:"settings" doc-key:"config.loginSettings.settings"`
+
+		// pool of leased users, shared by copies of the generator
+		pool *leasePool
 	}
 )
 
@@ -48,6 +55,8 @@ const (
 	UserGeneratorPrefix
 	// UserGeneratorNone no user creation
 	UserGeneratorNone
+	// UserGeneratorFile users according to CSV or TSV file
+	UserGeneratorFile
 )
 
 var (
@@ -55,6 +64,7 @@ var (
 		"userlist": int(UserGeneratorCircular),
 		"prefix":   int(UserGeneratorPrefix),
 		"none":     int(UserGeneratorNone),
+		"file":     int(UserGeneratorFile),
 	})
 	jsonit = jsoniter.ConfigCompatibleWithStandardLibrary
 )
@@ -73,6 +83,8 @@ func UserGenHandler(generator Type) interface{} {
 		return &PrefixUsers{}
 	case UserGeneratorNone:
 		return &NoneUsers{}
+	case UserGeneratorFile:
+		return &FileUsers{}
 	default:
 		return nil
 	}
@@ -84,10 +96,10 @@ func NewUserGeneratorCircular(users []*User) UserGenerator {
 	circularUsers.UserList = users
 
 	uGen := UserGenerator{
-		GeneratorCore{
+		GeneratorCore: GeneratorCore{
 			GeneratorType: UserGeneratorCircular,
 		},
-		circularUsers,
+		Settings: circularUsers,
 	}
 
 	return uGen
@@ -96,10 +108,10 @@ func NewUserGeneratorCircular(users []*User) UserGenerator {
 // NewUserGeneratorPrefix create new prefix user generator
 func NewUserGeneratorPrefix(prefix string) UserGenerator {
 	return UserGenerator{
-		GeneratorCore{
+		GeneratorCore: GeneratorCore{
 			GeneratorType: UserGeneratorPrefix,
 		},
-		&PrefixUsers{
+		Settings: &PrefixUsers{
 			Prefix: prefix,
 		},
 	}
@@ -108,10 +120,22 @@ func NewUserGeneratorPrefix(prefix string) UserGenerator {
 // NewUserGeneratorNone create new "none" user generator (to be used with e.g. Qlik Core)
 func NewUserGeneratorNone() UserGenerator {
 	return UserGenerator{
-		GeneratorCore{
+		GeneratorCore: GeneratorCore{
 			GeneratorType: UserGeneratorNone,
 		},
-		&NoneUsers{},
+		Settings: &NoneUsers{},
+	}
+}
+
+// NewUserGeneratorFile create new user generator with users read from CSV or TSV file
+func NewUserGeneratorFile(filename string) UserGenerator {
+	return UserGenerator{
+		GeneratorCore: GeneratorCore{
+			GeneratorType: UserGeneratorFile,
+		},
+		Settings: &FileUsers{
+			FileName: filename,
+		},
 	}
 }
 
@@ -168,7 +192,10 @@ func (value *UserGenerator) UnmarshalJSON(arg []byte) error {
 		return errors.Wrap(err, "Failed to unmarshal user generator")
 	}
 
-	(*value).GeneratorType = gen.GeneratorType
+	(*value).GeneratorCore = gen.GeneratorCore
+	if gen.Lease {
+		(*value).pool = newLeasePool(time.Duration(gen.LeaseTimeout))
+	}
 
 	settings := UserGenHandler(gen.GeneratorType)
 	if gen.GeneratorType == UserGeneratorNone {